@@ -93,6 +93,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpoint":         schema_libcalico_go_lib_apis_v3_WorkloadEndpoint(ref),
 		"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointList":     schema_libcalico_go_lib_apis_v3_WorkloadEndpointList(ref),
 		"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointSpec":     schema_libcalico_go_lib_apis_v3_WorkloadEndpointSpec(ref),
+		"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointStatus":   schema_libcalico_go_lib_apis_v3_WorkloadEndpointStatus(ref),
 	}
 }
 
@@ -2932,11 +2933,18 @@ func schema_libcalico_go_lib_apis_v3_WorkloadEndpoint(ref common.ReferenceCallba
 							Ref:         ref("github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointSpec"),
 						},
 					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the WorkloadEndpoint as observed on the host.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointStatus"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointSpec", "github.com/projectcalico/libcalico-go/lib/apis/v3.WorkloadEndpointStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 	}
 }
 
@@ -3138,3 +3146,46 @@ func schema_libcalico_go_lib_apis_v3_WorkloadEndpointSpec(ref common.ReferenceCa
 			"github.com/projectcalico/api/pkg/apis/projectcalico/v3.EndpointPort", "github.com/projectcalico/libcalico-go/lib/apis/v3.IPNAT"},
 	}
 }
+
+func schema_libcalico_go_lib_apis_v3_WorkloadEndpointStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WorkloadEndpointStatus contains the observed state of a WorkloadEndpoint resource.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"activeInterfaceName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ActiveInterfaceName is the name of the interface on the host, as seen by the kernel, that is currently handling traffic for the endpoint.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"activeIPNetworks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ActiveIPNetworks is the list of subnets that are currently active on the endpoint interface.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"lastUpdated": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastUpdated is the time at which the status was last updated.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
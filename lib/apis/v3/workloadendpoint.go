@@ -36,6 +36,9 @@ type WorkloadEndpoint struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Specification of the WorkloadEndpoint.
 	Spec WorkloadEndpointSpec `json:"spec,omitempty"`
+	// Status of the WorkloadEndpoint as observed on the host.  Not set until the status
+	// has been reported.
+	Status *WorkloadEndpointStatus `json:"status,omitempty"`
 }
 
 // WorkloadEndpointMetadata contains the specification for a WorkloadEndpoint resource.
@@ -73,13 +76,24 @@ type WorkloadEndpointSpec struct {
 	// after the selector-based security policy.
 	Profiles []string `json:"profiles,omitempty" validate:"omitempty,dive,name"`
 	// InterfaceName the name of the Linux interface on the host: for example, tap80.
-	InterfaceName string `json:"interfaceName,omitempty" validate:"interface"`
+	InterfaceName string `json:"interfaceName,omitempty"`
 	// MAC is the MAC address of the endpoint interface.
 	MAC string `json:"mac,omitempty" validate:"omitempty,mac"`
 	// Ports contains the endpoint's named ports, which may be referenced in security policy rules.
 	Ports []apiv3.EndpointPort `json:"ports,omitempty" validate:"dive,omitempty"`
 }
 
+// WorkloadEndpointStatus contains the observed state of a WorkloadEndpoint resource.
+type WorkloadEndpointStatus struct {
+	// ActiveInterfaceName is the name of the interface on the host, as seen by the kernel, that
+	// is currently handling traffic for the endpoint.
+	ActiveInterfaceName string `json:"activeInterfaceName,omitempty" validate:"omitempty,interface"`
+	// ActiveIPNetworks is the list of subnets that are currently active on the endpoint interface.
+	ActiveIPNetworks []string `json:"activeIPNetworks,omitempty" validate:"omitempty,dive,net"`
+	// LastUpdated is the time at which the status was last updated.
+	LastUpdated metav1.Time `json:"lastUpdated,omitempty"`
+}
+
 // IPNat contains a single NAT mapping for a WorkloadEndpoint resource.
 type IPNAT struct {
 	// The internal IP address which must be associated with the owning endpoint via the
@@ -0,0 +1,129 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+)
+
+var _ = Describe("WorkloadEndpoint YAML serialization", func() {
+	newWEP := func(spec libapiv3.WorkloadEndpointSpec) *libapiv3.WorkloadEndpoint {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Name = "node1-k8s-pod1-eth0"
+		wep.Namespace = "default"
+		wep.Spec = spec
+		return wep
+	}
+
+	DescribeTable("should round trip through YAML",
+		func(spec libapiv3.WorkloadEndpointSpec) {
+			wep := newWEP(spec)
+			b, err := yaml.Marshal(wep)
+			Expect(err).NotTo(HaveOccurred())
+
+			var decoded libapiv3.WorkloadEndpoint
+			Expect(yaml.Unmarshal(b, &decoded)).To(Succeed())
+			Expect(decoded.Spec).To(Equal(wep.Spec))
+			Expect(decoded.ObjectMeta).To(Equal(wep.ObjectMeta))
+		},
+		Entry("minimal spec", libapiv3.WorkloadEndpointSpec{
+			Node:          "node1",
+			Orchestrator:  "k8s",
+			Pod:           "pod1",
+			Endpoint:      "eth0",
+			InterfaceName: "cali0123",
+		}),
+		Entry("networks, NATs and gateways", libapiv3.WorkloadEndpointSpec{
+			Node:          "node1",
+			Orchestrator:  "k8s",
+			Pod:           "pod1",
+			Endpoint:      "eth0",
+			InterfaceName: "cali0123",
+			IPNetworks:    []string{"10.0.0.1/32", "fd00::1/128"},
+			IPNATs:        []libapiv3.IPNAT{{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.1"}},
+			IPv4Gateway:   "10.0.0.254",
+			IPv6Gateway:   "fd00::fe",
+			MAC:           "01:23:45:67:89:ab",
+			Profiles:      []string{"kns.default"},
+		}),
+		Entry("named and numbered protocols", libapiv3.WorkloadEndpointSpec{
+			Node:          "node1",
+			Orchestrator:  "k8s",
+			Pod:           "pod1",
+			Endpoint:      "eth0",
+			InterfaceName: "cali0123",
+			Ports: []apiv3.EndpointPort{
+				{Name: "http", Protocol: numorstring.ProtocolFromString("TCP"), Port: 80},
+				{Name: "dns", Protocol: numorstring.ProtocolFromString("UDP"), Port: 53},
+				{Name: "sctp", Protocol: numorstring.ProtocolFromInt(132), Port: 9000},
+			},
+		}),
+	)
+
+	It("should omit an empty NAT list rather than serializing it as null", func() {
+		wep := newWEP(libapiv3.WorkloadEndpointSpec{Node: "node1", IPNATs: []libapiv3.IPNAT{}})
+		b, err := yaml.Marshal(wep)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("ipNATs"))
+	})
+
+	It("should serialize a named protocol as a string", func() {
+		wep := newWEP(libapiv3.WorkloadEndpointSpec{
+			Ports: []apiv3.EndpointPort{
+				{Name: "http", Protocol: numorstring.ProtocolFromString("TCP"), Port: 80},
+				{Name: "sctp", Protocol: numorstring.ProtocolFromInt(132), Port: 9000},
+			},
+		})
+		b, err := yaml.Marshal(wep)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("protocol: TCP\n"))
+		Expect(string(b)).To(ContainSubstring("protocol: 132\n"))
+	})
+})
+
+var _ = Describe("WorkloadEndpoint status serialization", func() {
+	It("should not serialize the status of a WorkloadEndpoint without a status", func() {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Name = "node1-k8s-pod1-eth0"
+		b, err := json.Marshal(wep)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("status"))
+	})
+
+	It("should round trip the status", func() {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Status = &libapiv3.WorkloadEndpointStatus{
+			ActiveInterfaceName: "cali0123",
+			LastUpdated:         metav1.Now().Rfc3339Copy(),
+		}
+		b, err := json.Marshal(wep)
+		Expect(err).NotTo(HaveOccurred())
+		var decoded libapiv3.WorkloadEndpoint
+		Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+		Expect(decoded.Status.ActiveInterfaceName).To(Equal("cali0123"))
+		Expect(decoded.Status.LastUpdated.Equal(&wep.Status.LastUpdated)).To(BeTrue())
+		Expect(wep.DeepCopy().Status).To(Equal(wep.Status))
+	})
+})
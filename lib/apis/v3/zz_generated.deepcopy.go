@@ -601,6 +601,11 @@ func (in *WorkloadEndpoint) DeepCopyInto(out *WorkloadEndpoint) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(WorkloadEndpointStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -690,3 +695,25 @@ func (in *WorkloadEndpointSpec) DeepCopy() *WorkloadEndpointSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadEndpointStatus) DeepCopyInto(out *WorkloadEndpointStatus) {
+	*out = *in
+	if in.ActiveIPNetworks != nil {
+		in, out := &in.ActiveIPNetworks, &out.ActiveIPNetworks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadEndpointStatus.
+func (in *WorkloadEndpointStatus) DeepCopy() *WorkloadEndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadEndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -101,7 +101,10 @@ type Client interface {
 	DeleteKVP(ctx context.Context, object *model.KVPair) (*model.KVPair, error)
 
 	// Get returns the object identified by the given key as a KVPair with
-	// revision information.
+	// revision information.  If a revision is specified, the object is returned
+	// as it was at that revision (where supported by the datastore), otherwise the
+	// current object is returned.  The returned revision may be used on a
+	// subsequent Update to perform a compare-and-swap.
 	Get(ctx context.Context, key model.Key, revision string) (*model.KVPair, error)
 
 	// List returns a slice of KVPairs matching the input list options.
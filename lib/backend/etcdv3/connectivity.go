@@ -0,0 +1,77 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/apiconfig"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+// ConnectivityErrors is the error returned by CheckDatastoreConnectivity.  It contains an
+// entry for each issue detected with the datastore.
+type ConnectivityErrors []error
+
+func (e ConnectivityErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "etcd datastore connectivity check failed: " + strings.Join(msgs, "; ")
+}
+
+// CheckDatastoreConnectivity checks that the etcdv3 datastore described by the supplied
+// config is reachable and healthy.  It reads the default ClusterInformation, checks the
+// cluster member list and runs the same health check as HealthCheck, and returns a
+// ConnectivityErrors containing all of the issues found, or nil if there are none.
+func CheckDatastoreConnectivity(ctx context.Context, config *apiconfig.EtcdConfig) error {
+	client, _, err := newEtcdClient(config)
+	if err != nil {
+		return ConnectivityErrors{err}
+	}
+	defer client.Close()
+
+	var errs ConnectivityErrors
+	// The key does not need to exist, it is only read to check that the datastore can be read.
+	key, err := model.KeyToDefaultPath(model.ResourceKey{Kind: apiv3.KindClusterInformation, Name: "default"})
+	if err != nil {
+		return ConnectivityErrors{err}
+	}
+	if _, err := client.Get(ctx, key); err != nil {
+		errs = append(errs, fmt.Errorf("unable to read from the datastore: %v", err))
+	}
+
+	if resp, err := client.MemberList(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to list the cluster members: %v", err))
+	} else if len(resp.Members) == 0 {
+		errs = append(errs, fmt.Errorf("the cluster has no members"))
+	}
+
+	if err := checkHealth(ctx, client); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		log.WithError(errs).Warning("Detected issues with the etcd datastore")
+		return errs
+	}
+	return nil
+}
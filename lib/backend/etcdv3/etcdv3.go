@@ -51,12 +51,26 @@ const (
 
 type etcdV3Client struct {
 	etcdClient *clientv3.Client
+
+	// The source of the client certificate, or nil if the client does not use TLS.
+	tlsConfig *rotatingTLSConfig
 }
 
 func NewEtcdV3Client(config *apiconfig.EtcdConfig) (api.Client, error) {
+	client, tlsConfig, err := newEtcdClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &etcdV3Client{etcdClient: client, tlsConfig: tlsConfig}, nil
+}
+
+// newEtcdClient creates the underlying etcd client from the supplied config, along with
+// the rotatingTLSConfig that supplies its client certificate if TLS is configured.
+func newEtcdClient(config *apiconfig.EtcdConfig) (*clientv3.Client, *rotatingTLSConfig, error) {
 	if config.EtcdEndpoints != "" && config.EtcdDiscoverySrv != "" {
 		log.Warning("Multiple etcd endpoint discovery methods specified in etcdv3 API config")
-		return nil, errors.New("multiple discovery or bootstrap options specified, use either \"etcdEndpoints\" or \"etcdDiscoverySrv\"")
+		return nil, nil, errors.New("multiple discovery or bootstrap options specified, use either \"etcdEndpoints\" or \"etcdDiscoverySrv\"")
 	}
 
 	// Split the endpoints into a location slice.
@@ -68,17 +82,50 @@ func NewEtcdV3Client(config *apiconfig.EtcdConfig) (api.Client, error) {
 	if config.EtcdDiscoverySrv != "" {
 		srvs, srvErr := srv.GetClient("etcd-client", config.EtcdDiscoverySrv, "")
 		if srvErr != nil {
-			return nil, fmt.Errorf("failed to discover etcd endpoints through SRV discovery: %v", srvErr)
+			return nil, nil, fmt.Errorf("failed to discover etcd endpoints through SRV discovery: %v", srvErr)
 		}
 		etcdLocation = srvs.Endpoints
 	}
 
 	if len(etcdLocation) == 0 {
 		log.Warning("No etcd endpoints specified in etcdv3 API config")
-		return nil, errors.New("no etcd endpoints specified")
+		return nil, nil, errors.New("no etcd endpoints specified")
+	}
+
+	tls, rotating, err := newClientTLSConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Build the etcdv3 config.
+	cfg := clientv3.Config{
+		Endpoints:            etcdLocation,
+		TLS:                  tls,
+		DialTimeout:          clientTimeout,
+		DialKeepAliveTime:    keepaliveTime,
+		DialKeepAliveTimeout: keepaliveTimeout,
+	}
+
+	// Plumb through the username and password if both are configured.
+	if config.EtcdUsername != "" && config.EtcdPassword != "" {
+		cfg.Username = config.EtcdUsername
+		cfg.Password = config.EtcdPassword
+	}
+
+	client, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
+	return client, rotating, nil
+}
 
-	// Create the etcd client
+// newClientTLSConfig returns the TLS config of the etcd client, built from the supplied
+// config.  If certificates or keys are supplied, inline or as files, the client certificate
+// is taken from the returned rotatingTLSConfig, so that it can be rotated without recreating
+// the client; otherwise the returned rotatingTLSConfig is nil.  Note that the certificate
+// files are re-read by the etcd transport for each handshake, so rotating the files does not
+// require a rotation.
+func newClientTLSConfig(config *apiconfig.EtcdConfig) (*tls.Config, *rotatingTLSConfig, error) {
 	// If Etcd Certificate and Key are provided inline through command line argument,
 	// then the inline values take precedence over the ones in the config file.
 	// All the three parameters, Certificate, key and CA certificate are to be provided inline for processing.
@@ -89,7 +136,7 @@ func NewEtcdV3Client(config *apiconfig.EtcdConfig) (api.Client, error) {
 	haveFiles := config.EtcdCertFile != "" || config.EtcdKeyFile != "" || config.EtcdCACertFile != ""
 
 	if haveInline && haveFiles {
-		return nil, fmt.Errorf("Cannot mix inline certificate-key and certificate / key files")
+		return nil, nil, fmt.Errorf("Cannot mix inline certificate-key and certificate / key files")
 	}
 
 	if haveInline {
@@ -109,30 +156,16 @@ func NewEtcdV3Client(config *apiconfig.EtcdConfig) (api.Client, error) {
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("could not initialize etcdv3 client: %+v", err)
-	}
-
-	// Build the etcdv3 config.
-	cfg := clientv3.Config{
-		Endpoints:            etcdLocation,
-		TLS:                  tls,
-		DialTimeout:          clientTimeout,
-		DialKeepAliveTime:    keepaliveTime,
-		DialKeepAliveTimeout: keepaliveTimeout,
-	}
-
-	// Plumb through the username and password if both are configured.
-	if config.EtcdUsername != "" && config.EtcdPassword != "" {
-		cfg.Username = config.EtcdUsername
-		cfg.Password = config.EtcdPassword
+		return nil, nil, fmt.Errorf("could not initialize etcdv3 client: %+v", err)
 	}
 
-	client, err := clientv3.New(cfg)
-	if err != nil {
-		return nil, err
+	// Without any certificates or keys the transport still returns a TLS config, but
+	// there is no client certificate to rotate.
+	if !haveInline && !haveFiles {
+		return tls, nil, nil
 	}
-
-	return &etcdV3Client{etcdClient: client}, nil
+	rotating, tls := newRotatingTLSConfig(tls)
+	return tls, rotating, nil
 }
 
 // Create an entry in the datastore.  If the entry already exists, this will return
@@ -350,6 +383,52 @@ func (c *etcdV3Client) Delete(ctx context.Context, k model.Key, revision string)
 	return previousValue, nil
 }
 
+// DeleteKVPs removes each of the objects specified by the KVPairs in a single transaction,
+// so either all or none of the objects are deleted.  If a KVPair contains revision
+// information, the delete only succeeds if the revision is still current.  If any of the
+// objects does not exist or has been modified, nothing is deleted and an
+// ErrorResourceUpdateConflict is returned.  Unlike Delete, the deleted objects are not
+// returned.  This is not part of the exposed API, but is public
+// to allow direct consumers of the backend API to access this.
+func (c *etcdV3Client) DeleteKVPs(ctx context.Context, kvps []*model.KVPair) error {
+	conds := []clientv3.Cmp{}
+	ops := []clientv3.Op{}
+	keys := []model.Key{}
+	for _, kvp := range kvps {
+		keys = append(keys, kvp.Key)
+		key, err := model.KeyToDefaultPath(kvp.Key)
+		if err != nil {
+			return err
+		}
+		if len(kvp.Revision) != 0 {
+			rev, err := parseRevision(kvp.Revision)
+			if err != nil {
+				return err
+			}
+			conds = append(conds, clientv3.Compare(clientv3.ModRevision(key), "=", rev))
+		} else {
+			// Only delete the objects that exist.
+			conds = append(conds, clientv3.Compare(clientv3.CreateRevision(key), ">", 0))
+		}
+		ops = append(ops, clientv3.OpDelete(key))
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	log.WithField("count", len(ops)).Debug("Performing etcdv3 transaction for DeleteKVPs request")
+	txnResp, err := c.etcdClient.Txn(ctx).If(conds...).Then(ops...).Commit()
+	if err != nil {
+		log.WithError(err).Warning("DeleteKVPs failed")
+		return cerrors.ErrorDatastoreError{Err: err}
+	}
+	if !txnResp.Succeeded {
+		log.Debug("DeleteKVPs transaction failed due to resource update conflict")
+		return cerrors.ErrorResourceUpdateConflict{Identifier: keys}
+	}
+	return nil
+}
+
 // Get an entry from the datastore.  This errors if the entry does not exist.
 func (c *etcdV3Client) Get(ctx context.Context, k model.Key, revision string) (*model.KVPair, error) {
 	logCxt := log.WithFields(log.Fields{"model-etcdKey": k, "rev": revision})
@@ -1,6 +1,9 @@
 package etcdv3_test
 
 import (
+	"context"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -226,3 +229,29 @@ var _ = Describe("RulesAPIToBackend", func() {
 		Expect(err).To(MatchError(ContainSubstring("failed to discover etcd endpoints through SRV discovery")))
 	})
 })
+
+var _ = Describe("CheckDatastoreConnectivity", func() {
+	It("should return the client config error", func() {
+		err := etcdv3.CheckDatastoreConnectivity(context.Background(), &apiconfig.EtcdConfig{})
+		Expect(err).To(HaveOccurred())
+		errs, ok := err.(etcdv3.ConnectivityErrors)
+		Expect(ok).To(BeTrue())
+		Expect(errs).To(HaveLen(1))
+		Expect(err).To(MatchError(ContainSubstring("no etcd endpoints specified")))
+	})
+
+	It("should report every issue with an unreachable datastore", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		err := etcdv3.CheckDatastoreConnectivity(ctx, &apiconfig.EtcdConfig{
+			EtcdEndpoints: "http://127.0.0.1:1",
+		})
+		Expect(err).To(HaveOccurred())
+		errs, ok := err.(etcdv3.ConnectivityErrors)
+		Expect(ok).To(BeTrue())
+		Expect(errs).To(HaveLen(3))
+		Expect(err).To(MatchError(ContainSubstring("unable to read from the datastore")))
+		Expect(err).To(MatchError(ContainSubstring("unable to list the cluster members")))
+		Expect(err).To(MatchError(ContainSubstring("etcd is not healthy: unable to read the health key")))
+	})
+})
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+// healthKey is the key read to check the health of etcd.  This matches the key used by
+// the etcd /health endpoint and "etcdctl endpoint health".
+const healthKey = "health"
+
+// healthClient is the subset of the etcd client used to check the health of etcd.  It is
+// satisfied by *clientv3.Client, and allows the health check to be tested without etcd.
+type healthClient interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error)
+	Endpoints() []string
+}
+
+// HealthCheck checks the health of etcd in the same way as the etcd /health endpoint.  etcd
+// is healthy if it can serve a quorum read of the health key and every endpoint has a
+// leader and reports no alarm.  A descriptive error is returned if etcd is not healthy.
+//
+// HealthCheck is not part of the api.Client interface; callers holding an api.Client may
+// check for it with a type assertion.
+func (c *etcdV3Client) HealthCheck(ctx context.Context) error {
+	return checkHealth(ctx, c.etcdClient)
+}
+
+func checkHealth(ctx context.Context, client healthClient) error {
+	// A permission denied error still requires a quorum read, and so indicates that etcd
+	// is healthy even though the client cannot read the key.
+	if _, err := client.Get(ctx, healthKey); err != nil && err != rpctypes.ErrPermissionDenied {
+		log.WithError(err).Info("etcd health check read failed")
+		return fmt.Errorf("etcd is not healthy: unable to read the health key: %v", err)
+	}
+
+	var problems []string
+	for _, ep := range client.Endpoints() {
+		resp, err := client.Status(ctx, ep)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("unable to query the status of endpoint %s: %v", ep, err))
+			continue
+		}
+		if resp.Leader == 0 {
+			problems = append(problems, fmt.Sprintf("endpoint %s has no leader", ep))
+		}
+		for _, e := range resp.Errors {
+			problems = append(problems, fmt.Sprintf("endpoint %s reported an error: %s", ep, e))
+		}
+	}
+	if len(problems) > 0 {
+		log.WithField("problems", problems).Info("etcd health check failed")
+		return fmt.Errorf("etcd is not healthy: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+type fakeHealthClient struct {
+	getErr    error
+	statusErr map[string]error
+	alarms    map[string][]string
+	noLeader  map[string]bool
+}
+
+func (f *fakeHealthClient) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	Expect(key).To(Equal(healthKey))
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	return &clientv3.GetResponse{}, nil
+}
+
+func (f *fakeHealthClient) Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	if err := f.statusErr[endpoint]; err != nil {
+		return nil, err
+	}
+	resp := &clientv3.StatusResponse{Errors: f.alarms[endpoint]}
+	if !f.noLeader[endpoint] {
+		resp.Leader = 1
+	}
+	return resp, nil
+}
+
+func (f *fakeHealthClient) Endpoints() []string {
+	return []string{"http://etcd1:2379", "http://etcd2:2379"}
+}
+
+var _ = DescribeTable("etcd health check",
+	func(client *fakeHealthClient, expectedErrs []string) {
+		err := checkHealth(context.Background(), client)
+		if len(expectedErrs) == 0 {
+			Expect(err).NotTo(HaveOccurred())
+			return
+		}
+		Expect(err).To(HaveOccurred())
+		for _, e := range expectedErrs {
+			Expect(err.Error()).To(ContainSubstring(e))
+		}
+	},
+	Entry("healthy", &fakeHealthClient{}, nil),
+	Entry("healthy but the health key cannot be read",
+		&fakeHealthClient{getErr: rpctypes.ErrPermissionDenied}, nil,
+	),
+	Entry("read failure",
+		&fakeHealthClient{getErr: errors.New("context deadline exceeded")},
+		[]string{"unable to read the health key: context deadline exceeded"},
+	),
+	Entry("endpoint alarm",
+		&fakeHealthClient{alarms: map[string][]string{"http://etcd2:2379": {"memberID:1 alarm:NOSPACE "}}},
+		[]string{"endpoint http://etcd2:2379 reported an error: memberID:1 alarm:NOSPACE"},
+	),
+	Entry("endpoint with no leader",
+		&fakeHealthClient{noLeader: map[string]bool{"http://etcd1:2379": true}},
+		[]string{"endpoint http://etcd1:2379 has no leader"},
+	),
+	Entry("every endpoint failure is reported",
+		&fakeHealthClient{statusErr: map[string]error{
+			"http://etcd1:2379": errors.New("connection refused"),
+			"http://etcd2:2379": errors.New("connection reset"),
+		}},
+		[]string{
+			"unable to query the status of endpoint http://etcd1:2379: connection refused",
+			"unable to query the status of endpoint http://etcd2:2379: connection reset",
+		},
+	),
+)
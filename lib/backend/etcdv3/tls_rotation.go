@@ -0,0 +1,77 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"crypto/tls"
+	"errors"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rotatingTLSConfig supplies the client certificate presented to etcd from a TLS config
+// that can be replaced while the client is running.
+type rotatingTLSConfig struct {
+	current atomic.Value
+}
+
+// newRotatingTLSConfig returns a rotatingTLSConfig that initially uses the supplied TLS
+// config, and a copy of that config, for use by the etcd client, that takes its client
+// certificate from the rotatingTLSConfig.
+func newRotatingTLSConfig(cfg *tls.Config) (*rotatingTLSConfig, *tls.Config) {
+	r := &rotatingTLSConfig{}
+	r.current.Store(cfg)
+
+	clientCfg := cfg.Clone()
+	clientCfg.Certificates = nil
+	clientCfg.GetClientCertificate = r.getClientCertificate
+	return r, clientCfg
+}
+
+// getClientCertificate returns the client certificate of the current TLS config.  It is
+// called for each TLS handshake, so a rotated certificate is used for every connection
+// established after the rotation.
+func (r *rotatingTLSConfig) getClientCertificate(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cfg := r.current.Load().(*tls.Config)
+	if cfg.GetClientCertificate != nil {
+		return cfg.GetClientCertificate(cri)
+	}
+	if len(cfg.Certificates) > 0 {
+		return &cfg.Certificates[0], nil
+	}
+	// An empty certificate means that no client certificate is sent.
+	return &tls.Certificate{}, nil
+}
+
+// RotateTLSConfig replaces the client certificate that the etcdv3 client presents to
+// etcd with the certificate of the supplied TLS config, without restarting the client.
+// Connections that are already established continue to use the previous certificate;
+// the new certificate is presented when the client next connects to etcd.
+//
+// Only the client certificate is rotated.  Other settings of the supplied config, such
+// as the trusted CA certificates, are not used and still require the client to be
+// recreated.
+func (c *etcdV3Client) RotateTLSConfig(cfg *tls.Config) error {
+	if c.tlsConfig == nil {
+		return errors.New("the etcdv3 client is not configured to use TLS")
+	}
+	if cfg == nil || (len(cfg.Certificates) == 0 && cfg.GetClientCertificate == nil) {
+		return errors.New("the TLS config has no client certificate")
+	}
+	c.tlsConfig.current.Store(cfg)
+	log.Info("Rotated the etcdv3 client TLS certificate")
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/apiconfig"
+)
+
+// newTestCertificate returns a self-signed certificate with the given common name.
+func newTestCertificate(cn string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+var _ = Describe("etcdv3 client TLS certificate rotation", func() {
+	var listener net.Listener
+	var clientCNs chan string
+
+	BeforeEach(func() {
+		// Start a TLS server that requires a client certificate, reports the common
+		// name of each client, and echoes each line that it receives.
+		var err error
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{newTestCertificate("server")},
+			ClientAuth:   tls.RequireAnyClientCert,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		clientCNs = make(chan string, 10)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func(conn *tls.Conn) {
+					defer conn.Close()
+					if err := conn.Handshake(); err != nil {
+						return
+					}
+					cert, err := x509.ParseCertificate(conn.ConnectionState().PeerCertificates[0].Raw)
+					if err == nil {
+						clientCNs <- cert.Subject.CommonName
+					}
+					r := bufio.NewReader(conn)
+					for {
+						line, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						if _, err := conn.Write([]byte(line)); err != nil {
+							return
+						}
+					}
+				}(conn.(*tls.Conn))
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	clientConfig := func(cn string) *tls.Config {
+		return &tls.Config{
+			Certificates:       []tls.Certificate{newTestCertificate(cn)},
+			InsecureSkipVerify: true,
+		}
+	}
+
+	dial := func(cfg *tls.Config) *tls.Conn {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), cfg)
+		Expect(err).NotTo(HaveOccurred())
+		return conn
+	}
+
+	echo := func(conn *tls.Conn, msg string) string {
+		_, err := conn.Write([]byte(msg + "\n"))
+		Expect(err).NotTo(HaveOccurred())
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+		return line
+	}
+
+	It("should present the rotated certificate on new connections", func() {
+		rotating, cfg := newRotatingTLSConfig(clientConfig("client-1"))
+		c := &etcdV3Client{tlsConfig: rotating}
+
+		conn1 := dial(cfg)
+		defer conn1.Close()
+		Expect(echo(conn1, "before")).To(Equal("before\n"))
+		Eventually(clientCNs).Should(Receive(Equal("client-1")))
+
+		Expect(c.RotateTLSConfig(clientConfig("client-2"))).To(Succeed())
+
+		// The existing connection is not affected by the rotation.
+		Expect(echo(conn1, "during")).To(Equal("during\n"))
+
+		conn2 := dial(cfg)
+		defer conn2.Close()
+		Expect(echo(conn2, "after")).To(Equal("after\n"))
+		Eventually(clientCNs).Should(Receive(Equal("client-2")))
+	})
+
+	It("should use the GetClientCertificate callback of the rotated config", func() {
+		rotating, cfg := newRotatingTLSConfig(clientConfig("client-1"))
+		c := &etcdV3Client{tlsConfig: rotating}
+		cert := newTestCertificate("client-callback")
+		Expect(c.RotateTLSConfig(&tls.Config{
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return &cert, nil },
+		})).To(Succeed())
+
+		conn := dial(cfg)
+		defer conn.Close()
+		Expect(echo(conn, "hello")).To(Equal("hello\n"))
+		Eventually(clientCNs).Should(Receive(Equal("client-callback")))
+	})
+
+	It("should reject a config without a client certificate", func() {
+		rotating, _ := newRotatingTLSConfig(clientConfig("client-1"))
+		c := &etcdV3Client{tlsConfig: rotating}
+		Expect(c.RotateTLSConfig(nil)).To(MatchError("the TLS config has no client certificate"))
+		Expect(c.RotateTLSConfig(&tls.Config{})).To(MatchError("the TLS config has no client certificate"))
+	})
+
+	It("should reject a rotation if the client does not use TLS", func() {
+		c := &etcdV3Client{}
+		Expect(c.RotateTLSConfig(clientConfig("client-1"))).To(MatchError("the etcdv3 client is not configured to use TLS"))
+	})
+
+	It("should only rotate the client certificate if certificates are configured", func() {
+		cfg, rotating, err := newClientTLSConfig(&apiconfig.EtcdConfig{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).NotTo(BeNil())
+		Expect(rotating).To(BeNil())
+		c := &etcdV3Client{tlsConfig: rotating}
+		Expect(c.RotateTLSConfig(clientConfig("client-1"))).To(MatchError("the etcdv3 client is not configured to use TLS"))
+
+		cert := newTestCertificate("client-1")
+		key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+		Expect(err).NotTo(HaveOccurred())
+		cfg, rotating, err = newClientTLSConfig(&apiconfig.EtcdConfig{
+			EtcdCert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})),
+			EtcdKey:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key})),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotating).NotTo(BeNil())
+		Expect(cfg.GetClientCertificate).NotTo(BeNil())
+	})
+})
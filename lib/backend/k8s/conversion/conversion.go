@@ -209,6 +209,11 @@ func getPodIPs(pod *kapiv1.Pod) ([]*cnet.IPNet, error) {
 	}
 	var podIPNets []*cnet.IPNet
 	for _, ip := range podIPs {
+		// The pod IP may be an address or a CIDR: check the address is strictly valid.
+		if _, err := cnet.ParseIPStrict(strings.SplitN(ip, "/", 2)[0]); err != nil {
+			log.WithFields(log.Fields{"ip": ip, "pod": pod.Name}).WithError(err).Error("Failed to parse pod IP")
+			return nil, err
+		}
 		_, ipNet, err := cnet.ParseCIDROrIP(ip)
 		if err != nil {
 			log.WithFields(log.Fields{"ip": ip, "pod": pod.Name}).WithError(err).Error("Failed to parse pod IP")
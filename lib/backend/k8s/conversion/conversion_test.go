@@ -514,6 +514,28 @@ var _ = Describe("Test Pod conversion", func() {
 
 	})
 
+	It("should return an error for a floating IP with a leading zero", func() {
+		pod := kapiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "podA",
+				Namespace: "default",
+				Annotations: map[string]string{
+					"cni.projectcalico.org/podIP":       "192.168.0.1",
+					"cni.projectcalico.org/floatingIPs": `["1.1.1.01"]`,
+				},
+				ResourceVersion: "1234",
+			},
+			Spec: kapiv1.PodSpec{
+				NodeName:   "nodeA",
+				Containers: []kapiv1.Container{},
+			},
+		}
+
+		_, err := podToWorkloadEndpoint(c, &pod)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1.1.1.01"))
+	})
+
 	It("should return an error for a bad pod IP", func() {
 		pod := kapiv1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
@@ -534,6 +556,35 @@ var _ = Describe("Test Pod conversion", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	DescribeTable("should return an error for a pod IP that is not strictly valid",
+		func(annotations map[string]string, status kapiv1.PodStatus, badIP string) {
+			pod := kapiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "podA",
+					Namespace:       "default",
+					Annotations:     annotations,
+					ResourceVersion: "1234",
+				},
+				Spec: kapiv1.PodSpec{
+					NodeName:   "nodeA",
+					Containers: []kapiv1.Container{},
+				},
+				Status: status,
+			}
+
+			_, err := podToWorkloadEndpoint(c, &pod)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(badIP))
+		},
+		Entry("PodIP with a leading zero", nil, kapiv1.PodStatus{PodIP: "192.168.0.01"}, "192.168.0.01"),
+		Entry("PodIPs with a zone ID", nil,
+			kapiv1.PodStatus{PodIPs: []kapiv1.PodIP{{IP: "192.168.0.1"}, {IP: "fe80::1%eth0"}}}, "fe80::1%eth0"),
+		Entry("podIP annotation CIDR with a leading zero",
+			map[string]string{"cni.projectcalico.org/podIP": "192.168.0.01/32"}, kapiv1.PodStatus{}, "192.168.0.01"),
+		Entry("podIPs annotation with a leading zero",
+			map[string]string{"cni.projectcalico.org/podIPs": "192.168.0.1,010.0.0.1"}, kapiv1.PodStatus{}, "010.0.0.1"),
+	)
+
 	It("should return an error for a bad podIPs annotation", func() {
 		pod := kapiv1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
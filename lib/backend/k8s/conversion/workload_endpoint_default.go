@@ -148,32 +148,36 @@ func (wc defaultWorkloadEndpointConverter) podToDefaultWorkloadEndpoint(pod *kap
 		for _, ipNet := range podIPNets {
 			if ipNet.IP.To4() != nil {
 				podnetV4 = ipNet
-				netmask, _ := podnetV4.Mask.Size()
-				if netmask != 32 {
+				if !podnetV4.IsHostRoute() {
+					netmask, _ := podnetV4.Mask.Size()
 					return nil, fmt.Errorf("PodIP %v is not a valid IPv4: Mask size is %d, not 32", ipNet, netmask)
 				}
 			} else {
 				podnetV6 = ipNet
-				netmask, _ := podnetV6.Mask.Size()
-				if netmask != 128 {
+				if !podnetV6.IsHostRoute() {
+					netmask, _ := podnetV6.Mask.Size()
 					return nil, fmt.Errorf("PodIP %v is not a valid IPv6: Mask size is %d, not 128", ipNet, netmask)
 				}
 			}
 		}
 
 		for _, ip := range ips {
-			if strings.Contains(ip, ":") {
+			floatingIP, err := cnet.ParseIPStrict(ip)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse floating IP '%s': %s", ip, err)
+			}
+			if floatingIP.Version() == 6 {
 				if podnetV6 != nil {
 					floatingIPs = append(floatingIPs, libapiv3.IPNAT{
 						InternalIP: podnetV6.IP.String(),
-						ExternalIP: ip,
+						ExternalIP: floatingIP.String(),
 					})
 				}
 			} else {
 				if podnetV4 != nil {
 					floatingIPs = append(floatingIPs, libapiv3.IPNAT{
 						InternalIP: podnetV4.IP.String(),
-						ExternalIP: ip,
+						ExternalIP: floatingIP.String(),
 					})
 				}
 			}
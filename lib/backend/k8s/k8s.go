@@ -536,6 +536,28 @@ func (c *KubeClient) Update(ctx context.Context, d *model.KVPair) (*model.KVPair
 	return client.Update(ctx, d)
 }
 
+// statusUpdater is implemented by the resource clients that can update the status of a
+// resource without modifying the rest of it.
+type statusUpdater interface {
+	UpdateStatus(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error)
+}
+
+// UpdateStatus updates the status of an existing entry in the datastore without modifying
+// the rest of the entry.  This errors if the entry does not exist, or if the resource type
+// does not support status-only updates.
+func (c *KubeClient) UpdateStatus(ctx context.Context, d *model.KVPair) (*model.KVPair, error) {
+	log.Debugf("Performing 'UpdateStatus' for %+v", d)
+	client, ok := c.getResourceClientFromKey(d.Key).(statusUpdater)
+	if !ok {
+		log.Debug("Attempt to 'UpdateStatus' using kubernetes backend is not supported.")
+		return nil, cerrors.ErrorOperationNotSupported{
+			Identifier: d.Key,
+			Operation:  "UpdateStatus",
+		}
+	}
+	return client.UpdateStatus(ctx, d)
+}
+
 // Set an existing entry in the datastore.  This ignores whether an entry already
 // exists.  This is not exposed in the main client - but we keep here for the backend
 // API.
@@ -99,6 +99,45 @@ func (c *nodeClient) Update(ctx context.Context, kvp *model.KVPair) (*model.KVPa
 	return newCalicoNode, nil
 }
 
+// UpdateStatus updates the Calico Node status stored on the Kubernetes node.  Only the
+// annotation holding the Wireguard public key is patched, so concurrent changes to the rest
+// of the node are not overwritten.  The PodCIDRs are derived from the Kubernetes node spec
+// and are not written.  The revision, if set, is used to detect update conflicts.
+func (c *nodeClient) UpdateStatus(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	log.Debug("Received UpdateStatus request on Node type")
+	name := kvp.Key.(model.ResourceKey).Name
+	var publicKey interface{}
+	if wgKey := kvp.Value.(*libapiv3.Node).Status.WireguardPublicKey; wgKey != "" {
+		publicKey = wgKey
+	}
+	metadata := map[string]interface{}{
+		"annotations": map[string]interface{}{
+			nodeWireguardPublicKeyAnnotation: publicKey,
+		},
+	}
+	if kvp.Revision != "" {
+		metadata["resourceVersion"] = kvp.Revision
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return nil, err
+	}
+
+	newNode, err := c.clientSet.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		log.WithError(err).Info("Error updating Node status")
+		return nil, K8sErrorToCalico(err, kvp.Key)
+	}
+
+	newCalicoNode, err := K8sNodeToCalico(newNode, c.usePodCIDR)
+	if err != nil {
+		log.Errorf("Failed to parse returned Node after call to patch %+v", newNode)
+		return nil, err
+	}
+
+	return newCalicoNode, nil
+}
+
 func (c *nodeClient) DeleteKVP(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
 	return c.Delete(ctx, kvp.Key, kvp.Revision, kvp.UID)
 }
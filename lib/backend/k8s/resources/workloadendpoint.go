@@ -286,7 +286,12 @@ func (c *WorkloadEndpointClient) listUsingName(ctx context.Context, listOptions
 
 // list lists all the Workload endpoints for the namespace given in listOptions.
 func (c *WorkloadEndpointClient) list(ctx context.Context, listOptions model.ResourceListOptions, revision string) (*model.KVPairList, error) {
-	podList, err := c.clientSet.CoreV1().Pods(listOptions.Namespace).List(ctx, metav1.ListOptions{ResourceVersion: revision})
+	opts := metav1.ListOptions{ResourceVersion: revision}
+	if listOptions.Node != "" {
+		// The API server indexes pods by node, so this avoids listing every pod in the cluster.
+		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", listOptions.Node).String()
+	}
+	podList, err := c.clientSet.CoreV1().Pods(listOptions.Namespace).List(ctx, opts)
 	if err != nil {
 		return nil, K8sErrorToCalico(err, listOptions)
 	}
@@ -40,6 +40,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var _ = Describe("WorkloadEndpointClient", func() {
@@ -605,6 +606,30 @@ var _ = Describe("WorkloadEndpointClient", func() {
 				)
 			})
 		})
+
+		Context("node is specified", func() {
+			It("only lists the pods scheduled to the node", func() {
+				k8sClient := fake.NewSimpleClientset(&k8sapi.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "simplePod", Namespace: "testNamespace"},
+					Spec:       k8sapi.PodSpec{NodeName: "test-node"},
+					Status:     k8sapi.PodStatus{PodIP: "192.168.91.113"},
+				})
+				var fieldSelector string
+				k8sClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					fieldSelector = action.(k8stesting.ListAction).GetListRestrictions().Fields.String()
+					return false, nil, nil
+				})
+				wepClient := resources.NewWorkloadEndpointClient(k8sClient).(*resources.WorkloadEndpointClient)
+
+				kvps, err := wepClient.List(context.Background(), model.ResourceListOptions{
+					Kind: libapiv3.KindWorkloadEndpoint,
+					Node: "test-node",
+				}, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fieldSelector).To(Equal("spec.nodeName=test-node"))
+				Expect(kvps.KVPairs).To(HaveLen(1))
+			})
+		})
 	})
 	Describe("Watch", func() {
 		Context("Pod added", func() {
@@ -0,0 +1,43 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var usedAddresses []net.IP
+
+// fullBlock returns a fully allocated /26 block (64 addresses).
+func fullBlock() *model.AllocationBlock {
+	block := &model.AllocationBlock{CIDR: mustParseCIDR("10.0.0.0/26")}
+	for i := 0; i < block.NumAddresses(); i++ {
+		block.Allocations = append(block.Allocations, intPtr(0))
+	}
+	return block
+}
+
+func BenchmarkUsedAddressesFullBlock(b *testing.B) {
+	block := fullBlock()
+	var r []net.IP
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		r = block.UsedAddresses()
+	}
+	usedAddresses = r
+}
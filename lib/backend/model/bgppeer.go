@@ -107,7 +107,7 @@ func (options NodeBGPPeerListOptions) KeyFromDefaultPath(path string) Key {
 		return nil
 	}
 
-	if options.PeerIP.IP != nil && !options.PeerIP.Equal(peerIP.IP) {
+	if options.PeerIP.IP != nil && !options.PeerIP.Equal(peerIP) {
 		log.Debugf("Didn't match peerIP %s != %s", options.PeerIP.String(), peerIP.String())
 		return nil
 	}
@@ -184,7 +184,7 @@ func (options GlobalBGPPeerListOptions) KeyFromDefaultPath(path string) Key {
 		return nil
 	}
 
-	if options.PeerIP.IP != nil && !options.PeerIP.Equal(peerIP.IP) {
+	if options.PeerIP.IP != nil && !options.PeerIP.Equal(peerIP) {
 		log.Debugf("Didn't match peerIP %s != %s", options.PeerIP.String(), peerIP.String())
 		return nil
 	}
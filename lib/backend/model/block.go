@@ -160,6 +160,66 @@ func (b *AllocationBlock) NonAffineAllocations() []Allocation {
 	return allocs
 }
 
+// UsedAddresses returns the addresses in the block that are currently allocated.
+func (b *AllocationBlock) UsedAddresses() []net.IP {
+	var ips []net.IP
+	for ordinal, attrIdx := range b.Allocations {
+		if attrIdx != nil {
+			ips = append(ips, b.OrdinalToIP(ordinal))
+		}
+	}
+	return ips
+}
+
+// FreeAddresses returns the addresses in the block that are not allocated.
+func (b *AllocationBlock) FreeAddresses() []net.IP {
+	var ips []net.IP
+	for ordinal, attrIdx := range b.Allocations {
+		if attrIdx == nil {
+			ips = append(ips, b.OrdinalToIP(ordinal))
+		}
+	}
+	return ips
+}
+
+// Utilization returns the fraction of the addresses in the block that are allocated.
+func (b *AllocationBlock) Utilization() float64 {
+	used := 0
+	for _, attrIdx := range b.Allocations {
+		if attrIdx != nil {
+			used++
+		}
+	}
+	return float64(used) / float64(b.NumAddresses())
+}
+
+// MaxContiguousFreeRange returns the ordinals of the largest contiguous range of free
+// addresses in the block, as the half-open range [start, end).  If there are multiple
+// ranges of the same size, the first is returned.  If no addresses are free, start and
+// end are both 0.
+func (b *AllocationBlock) MaxContiguousFreeRange() (start, end int) {
+	runStart := 0
+	for ordinal, attrIdx := range b.Allocations {
+		if attrIdx != nil {
+			runStart = ordinal + 1
+			continue
+		}
+		if ordinal+1-runStart > end-start {
+			start, end = runStart, ordinal+1
+		}
+	}
+	return start, end
+}
+
+// FragmentationRatio returns the fraction of the addresses in the block that are not part
+// of the largest contiguous range of free addresses.  This is 0 for an empty block, and
+// tends towards 1 as the free addresses are split into smaller ranges by allocations.
+func (b *AllocationBlock) FragmentationRatio() float64 {
+	start, end := b.MaxContiguousFreeRange()
+	capacity := b.NumAddresses()
+	return float64(capacity-(end-start)) / float64(capacity)
+}
+
 // Get number of addresses covered by the block
 func (b *AllocationBlock) NumAddresses() int {
 	ones, size := b.CIDR.Mask.Size()
@@ -167,6 +227,17 @@ func (b *AllocationBlock) NumAddresses() int {
 	return numAddresses
 }
 
+// Contains returns true if the given IP lies within the block.  An IPv4 address held as an
+// IPv4-mapped IPv6 address is treated as the IPv4 address.
+func (b *AllocationBlock) Contains(ip net.IP) bool {
+	ip = ip.ToCanonical()
+	if ip.Version() != b.CIDR.Version() {
+		return false
+	}
+	_, err := b.IPToOrdinal(ip)
+	return err == nil
+}
+
 // Find the ordinal (i.e. how far into the block) a given IP lies.  Returns an error if the IP is outside the block.
 func (b *AllocationBlock) IPToOrdinal(ip net.IP) (int, error) {
 	ipAsInt := net.IPToBigInt(ip)
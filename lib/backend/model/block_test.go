@@ -89,6 +89,87 @@ var _ = Describe("AllocationBlock tests", func() {
 		Entry("10.0.128.0/17 256", "10.0.128.0/17", 256, "10.0.129.0"),
 		Entry("10.0.128.0/17 257", "10.0.128.0/17", 257, "10.0.129.1"),
 	)
+
+	DescribeTable("address usage tests",
+		func(cidr string, allocated []int, expectedUsed, expectedFree []string, expectedUtilization float64) {
+			block := model.AllocationBlock{
+				CIDR:        mustParseCIDR(cidr),
+				Allocations: make([]*int, 0),
+			}
+			for i := 0; i < block.NumAddresses(); i++ {
+				block.Allocations = append(block.Allocations, nil)
+			}
+			for _, ord := range allocated {
+				block.Allocations[ord] = intPtr(0)
+			}
+
+			used := []string{}
+			for _, ip := range block.UsedAddresses() {
+				used = append(used, ip.String())
+			}
+			Expect(used).To(Equal(expectedUsed))
+			Expect(block.FreeAddresses()).To(HaveLen(block.NumAddresses() - len(expectedUsed)))
+			if expectedFree != nil {
+				free := []string{}
+				for _, ip := range block.FreeAddresses() {
+					free = append(free, ip.String())
+				}
+				Expect(free).To(Equal(expectedFree))
+			}
+			Expect(block.Utilization()).To(Equal(expectedUtilization))
+		},
+		Entry("empty IPv4 /26", "10.0.0.0/26", []int{}, []string{}, nil, 0.0),
+		Entry("partially allocated IPv4 /26", "10.0.0.0/26", []int{0, 1, 63},
+			[]string{"10.0.0.0", "10.0.0.1", "10.0.0.63"}, nil, 3.0/64),
+		Entry("fully allocated IPv4 /30", "10.0.0.4/30", []int{0, 1, 2, 3},
+			[]string{"10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7"}, []string{}, 1.0),
+		Entry("partially allocated IPv6 /122", "fd00::/122", []int{1, 32},
+			[]string{"fd00::1", "fd00::20"}, nil, 2.0/64),
+		Entry("IPv6 /126 with one address free", "fd00::100/126", []int{0, 1, 3},
+			[]string{"fd00::100", "fd00::101", "fd00::103"}, []string{"fd00::102"}, 0.75),
+	)
+
+	DescribeTable("fragmentation tests",
+		func(cidr string, allocated []int, expectedStart, expectedEnd int, expectedRatio float64) {
+			block := model.AllocationBlock{
+				CIDR:        mustParseCIDR(cidr),
+				Allocations: make([]*int, 0),
+			}
+			for i := 0; i < block.NumAddresses(); i++ {
+				block.Allocations = append(block.Allocations, nil)
+			}
+			for _, ord := range allocated {
+				block.Allocations[ord] = intPtr(0)
+			}
+
+			start, end := block.MaxContiguousFreeRange()
+			Expect(start).To(Equal(expectedStart))
+			Expect(end).To(Equal(expectedEnd))
+			Expect(block.FragmentationRatio()).To(Equal(expectedRatio))
+		},
+		Entry("empty IPv4 /26", "10.0.0.0/26", []int{}, 0, 64, 0.0),
+		Entry("fully allocated IPv4 /30", "10.0.0.4/30", []int{0, 1, 2, 3}, 0, 0, 1.0),
+		Entry("alternating allocations in an IPv4 /29", "10.0.0.0/29", []int{0, 2, 4, 6}, 1, 2, 7.0/8),
+		Entry("alternating allocations starting free in an IPv4 /29", "10.0.0.0/29", []int{1, 3, 5, 7}, 0, 1, 7.0/8),
+		Entry("largest range at the end of an IPv4 /29", "10.0.0.0/29", []int{1, 4}, 5, 8, 5.0/8),
+		Entry("first of two equal ranges in an IPv6 /126", "fd00::/126", []int{1, 2}, 0, 1, 0.75),
+		Entry("single allocation in the middle of an IPv6 /125", "fd00::/125", []int{3}, 4, 8, 0.5),
+	)
+
+	DescribeTable("contains tests",
+		func(cidr string, ip net.IP, expected bool) {
+			block := model.AllocationBlock{
+				CIDR: mustParseCIDR(cidr),
+			}
+			Expect(block.Contains(ip)).To(Equal(expected))
+		},
+		Entry("IPv4 address in the block", "10.0.0.0/30", net.MustParseIP("10.0.0.3"), true),
+		Entry("IPv4 address outside the block", "10.0.0.0/30", net.MustParseIP("10.0.0.4"), false),
+		Entry("IPv4-mapped IPv6 address in the block", "10.0.0.0/30", net.IP{IP: net.MustParseIP("10.0.0.1").To16()}, true),
+		Entry("IPv4-compatible IPv6 address", "10.0.0.0/30", net.MustParseIP("::a00:1"), false),
+		Entry("IPv6 address in the block", "fd00::/126", net.MustParseIP("fd00::3"), true),
+		Entry("IPv4 address in an IPv6 block", "::/126", net.MustParseIP("0.0.0.1"), false),
+	)
 })
 
 func intPtr(i int) *int {
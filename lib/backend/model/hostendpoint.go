@@ -37,6 +37,21 @@ type HostEndpointKey struct {
 	EndpointID string `json:"-" validate:"required,namespacedName"`
 }
 
+// ParseHostEndpointKey parses the canonical etcd path of a HostEndpoint, i.e.
+// /calico/v1/host/<node>/endpoint/<endpoint>, into a HostEndpointKey.  The endpoint ID
+// is unescaped.
+func ParseHostEndpointKey(path string) (*HostEndpointKey, error) {
+	k := HostEndpointListOptions{}.KeyFromDefaultPath(path)
+	if k == nil {
+		return nil, errors.ErrorParsingDatastoreEntry{
+			RawKey: path,
+			Err:    fmt.Errorf("not a HostEndpoint path"),
+		}
+	}
+	key := k.(HostEndpointKey)
+	return &key, nil
+}
+
 func (key HostEndpointKey) defaultPath() (string, error) {
 	if key.Hostname == "" {
 		return "", errors.ErrorInsufficientIdentifiers{Name: "node"}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+var _ = DescribeTable("ParseHostEndpointKey",
+	func(path string, expected *HostEndpointKey) {
+		key, err := ParseHostEndpointKey(path)
+		if expected == nil {
+			Expect(err).To(HaveOccurred())
+			Expect(key).To(BeNil())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(Equal(expected))
+
+		// The key should convert back to the same path, which should parse to the
+		// same key.
+		p, err := KeyToDefaultPath(*key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p).To(Equal("/" + strings.TrimPrefix(path, "/")))
+		Expect(ParseHostEndpointKey(p)).To(Equal(key))
+	},
+	Entry("host endpoint", "/calico/v1/host/node1/endpoint/eth0",
+		&HostEndpointKey{Hostname: "node1", EndpointID: "eth0"},
+	),
+	Entry("path without a leading slash", "calico/v1/host/node1/endpoint/eth0",
+		&HostEndpointKey{Hostname: "node1", EndpointID: "eth0"},
+	),
+	Entry("escaped characters in the endpoint ID", "/calico/v1/host/node.example.com/endpoint/eth0%2f100%25",
+		&HostEndpointKey{Hostname: "node.example.com", EndpointID: "eth0/100%"},
+	),
+	Entry("endpoint list path", "/calico/v1/host/node1/endpoint", nil),
+	Entry("workload endpoint path", "/calico/v1/host/node1/workload/k8s/default.frontend/endpoint/eth0", nil),
+	Entry("extra path segments", "/calico/v1/host/node1/endpoint/eth0/extra", nil),
+	Entry("empty path", "", nil),
+)
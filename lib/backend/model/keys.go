@@ -100,6 +100,15 @@ type KVPair struct {
 	TTL      time.Duration // For writes, if non-zero, key has a TTL.
 }
 
+// DeepEqual returns true if the supplied KVPair has the same key and a deeply equal value.
+// The Revision, UID and TTL are not compared, since they are managed by the datastore.
+func (kvp *KVPair) DeepEqual(other *KVPair) bool {
+	if kvp == nil || other == nil {
+		return kvp == other
+	}
+	return reflect.DeepEqual(kvp.Key, other.Key) && reflect.DeepEqual(kvp.Value, other.Value)
+}
+
 // KVPairList hosts a slice of KVPair structs and a Revision, returned from a Ls
 type KVPairList struct {
 	KVPairs  []*KVPair
@@ -137,6 +146,17 @@ func KeyToDefaultDeletePath(key Key) (string, error) {
 	return key.defaultDeletePath()
 }
 
+// KeyToEtcdKeyString returns the etcd key used to store the object identified by
+// the Key, which is its default path.  An empty string is returned if the Key does
+// not contain enough identifiers to construct the etcd key.
+func KeyToEtcdKeyString(key Key) string {
+	path, err := key.defaultPath()
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
 func KeyToValueType(key Key) (reflect.Type, error) {
 	return key.valueType()
 }
@@ -288,6 +288,38 @@ var _ = DescribeTable(
 	),
 )
 
+var _ = DescribeTable(
+	"KeyToEtcdKeyString",
+	func(key Key, expected string) {
+		Expect(KeyToEtcdKeyString(key)).To(Equal(expected))
+	},
+	Entry(
+		"Workload endpoint key",
+		WorkloadEndpointKey{
+			Hostname:       "h1",
+			OrchestratorID: "k8s",
+			WorkloadID:     "default.pod1",
+			EndpointID:     "eth0",
+		},
+		"/calico/v1/host/h1/workload/k8s/default.pod1/endpoint/eth0",
+	),
+	Entry(
+		"Host endpoint key",
+		HostEndpointKey{Hostname: "h1", EndpointID: "hep1"},
+		"/calico/v1/host/h1/endpoint/hep1",
+	),
+	Entry(
+		"Profile rules key",
+		ProfileRulesKey{ProfileKey: ProfileKey{Name: "prof1"}},
+		"/calico/v1/policy/profile/prof1/rules",
+	),
+	Entry(
+		"Host endpoint key with missing hostname",
+		HostEndpointKey{EndpointID: "hep1"},
+		"",
+	),
+)
+
 func mustParseCIDR(s string) net.IPNet {
 	_, ipNet, err := net.ParseCIDR(s)
 	if err != nil {
@@ -295,3 +327,27 @@ func mustParseCIDR(s string) net.IPNet {
 	}
 	return *ipNet
 }
+
+var _ = DescribeTable("KVPair DeepEqual",
+	func(a, b *KVPair, expected bool) {
+		Expect(a.DeepEqual(b)).To(Equal(expected))
+		Expect(b.DeepEqual(a)).To(Equal(expected))
+	},
+	Entry("both nil", nil, nil, true),
+	Entry("one nil", &KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}}, nil, false),
+	Entry("equal, ignoring the revision and TTL",
+		&KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}, Value: &HostEndpoint{Name: "eth0", ProfileIDs: []string{"a"}}, Revision: "1"},
+		&KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}, Value: &HostEndpoint{Name: "eth0", ProfileIDs: []string{"a"}}, Revision: "2", TTL: 10},
+		true,
+	),
+	Entry("different keys",
+		&KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}, Value: "bar"},
+		&KVPair{Key: HostConfigKey{Hostname: "h2", Name: "foo"}, Value: "bar"},
+		false,
+	),
+	Entry("different values",
+		&KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}, Value: &HostEndpoint{Name: "eth0", ProfileIDs: []string{"a"}}},
+		&KVPair{Key: HostConfigKey{Hostname: "h1", Name: "foo"}, Value: &HostEndpoint{Name: "eth0", ProfileIDs: []string{"b"}}},
+		false,
+	),
+)
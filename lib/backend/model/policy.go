@@ -23,8 +23,10 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/selector/parser"
 )
 
 var (
@@ -124,3 +126,82 @@ func (p Policy) String() string {
 	parts = append(parts, fmt.Sprintf("types:%v", strings.Join(p.Types, ";")))
 	return strings.Join(parts, ",")
 }
+
+// ToNetworkPolicySelector converts the policy selector into the equivalent Kubernetes label
+// selector.  Only selectors made up of "key == value" and "key in {values}" expressions, and
+// conjunctions of these, are converted; "all()" and the empty selector convert to the empty
+// label selector, which matches everything.  An error is returned for any other expression.
+func (p Policy) ToNetworkPolicySelector() (*metav1.LabelSelector, error) {
+	sel, err := parser.Parse(p.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &labelSelectorVisitor{selector: &metav1.LabelSelector{}}
+	sel.AcceptVisitor(v)
+	if v.unsupported != "" {
+		return nil, fmt.Errorf("selector %q cannot be represented as a Kubernetes label selector: %s is not supported",
+			p.Selector, v.unsupported)
+	}
+	return v.selector, nil
+}
+
+// labelSelectorVisitor builds a Kubernetes label selector from the nodes of a parsed
+// selector.  The label selector requirements are ANDed, so only AND nodes may contain
+// other nodes.
+type labelSelectorVisitor struct {
+	selector    *metav1.LabelSelector
+	unsupported string
+}
+
+func (v *labelSelectorVisitor) Visit(n interface{}) {
+	switch np := n.(type) {
+	case *parser.AndNode, *parser.AllNode:
+	case *parser.LabelEqValueNode:
+		if existing, ok := v.selector.MatchLabels[np.LabelName]; !ok {
+			if v.selector.MatchLabels == nil {
+				v.selector.MatchLabels = map[string]string{}
+			}
+			v.selector.MatchLabels[np.LabelName] = np.Value
+		} else if existing != np.Value {
+			// A second, different, value for the same label cannot be stored in the
+			// MatchLabels map, so add it as a requirement.
+			v.addRequirement(np.LabelName, []string{np.Value})
+		}
+	case *parser.LabelInSetNode:
+		v.addRequirement(np.LabelName, []string(np.Value))
+	case *parser.HasNode:
+		v.setUnsupported(fmt.Sprintf("has(%s)", np.LabelName))
+	case *parser.NotNode:
+		v.setUnsupported("negation")
+	case *parser.OrNode:
+		v.setUnsupported("the || operator")
+	case *parser.LabelNeValueNode:
+		v.setUnsupported("the != operator")
+	case *parser.LabelNotInSetNode:
+		v.setUnsupported("the not in operator")
+	case *parser.LabelContainsValueNode:
+		v.setUnsupported("the contains operator")
+	case *parser.LabelStartsWithValueNode:
+		v.setUnsupported("the starts with operator")
+	case *parser.LabelEndsWithValueNode:
+		v.setUnsupported("the ends with operator")
+	default:
+		v.setUnsupported(fmt.Sprintf("%T", n))
+	}
+}
+
+// setUnsupported records the first unsupported expression found in the selector.
+func (v *labelSelectorVisitor) setUnsupported(expr string) {
+	if v.unsupported == "" {
+		v.unsupported = expr
+	}
+}
+
+func (v *labelSelectorVisitor) addRequirement(key string, values []string) {
+	v.selector.MatchExpressions = append(v.selector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      key,
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   append([]string{}, values...),
+	})
+}
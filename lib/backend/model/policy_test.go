@@ -15,9 +15,12 @@
 package model_test
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/projectcalico/libcalico-go/lib/backend/model"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -37,3 +40,47 @@ var _ = Describe("Policy functions", func() {
 		Expect(p.String()).To(Equal(`order:10.5,selector:"apples=='oranges'",inbound:Deny,outbound:Allow,untracked:false,pre_dnat:true,apply_on_forward:true,types:Ingress;Egress`))
 	})
 })
+
+var _ = DescribeTable("Policy selector to Kubernetes label selector conversion",
+	func(selector string, expected *metav1.LabelSelector) {
+		sel, err := model.Policy{Selector: selector}.ToNetworkPolicySelector()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sel).To(Equal(expected))
+	},
+	Entry("empty selector", "", &metav1.LabelSelector{}),
+	Entry("all()", "all()", &metav1.LabelSelector{}),
+	Entry("equality", "app == 'web'", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}),
+	Entry("in", "tier in {'db', 'cache'}", &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"cache", "db"}},
+		},
+	}),
+	Entry("conjunction", "app == 'web' && env == 'prod' && tier in {'fe'}", &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web", "env": "prod"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"fe"}},
+		},
+	}),
+	Entry("conflicting equalities", "app == 'web' && app == 'db'", &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"db"}},
+		},
+	}),
+)
+
+var _ = DescribeTable("Policy selector to Kubernetes label selector conversion errors",
+	func(selector, expectedErr string) {
+		_, err := model.Policy{Selector: selector}.ToNetworkPolicySelector()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(expectedErr))
+	},
+	Entry("has", "has(app)", "has(app) is not supported"),
+	Entry("not has", "!has(app)", "negation is not supported"),
+	Entry("or", "app == 'web' || app == 'db'", "the || operator is not supported"),
+	Entry("not equal", "app != 'web'", "the != operator is not supported"),
+	Entry("not in", "app not in {'web'}", "the not in operator is not supported"),
+	Entry("contains", "app contains 'we'", "the contains operator is not supported"),
+	Entry("nested in a conjunction", "app == 'web' && has(env)", "has(env) is not supported"),
+	Entry("invalid selector", "app ==", "Expected string"),
+)
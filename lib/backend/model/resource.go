@@ -182,6 +182,10 @@ type ResourceListOptions struct {
 	Kind string
 	// Whether the name is prefix rather than the full name.
 	Prefix bool
+	// The node of the resource.  Only used for WorkloadEndpoints, and only by the Kubernetes
+	// datastore to narrow the query to the pods scheduled to the node; other datastores
+	// ignore it, so callers must still filter the results by node.
+	Node string
 }
 
 // If the Kind, Namespace and Name are specified, but the Name is a prefix then the
@@ -261,3 +261,125 @@ func (r Rule) String() string {
 
 	return strings.Join(parts, " ")
 }
+
+// ToHumanReadable returns a compact English description of the rule, for example
+// "allow TCP from 10.0.0.0/8 port 443 to any".  Unlike String, which mirrors the structure
+// of the rule, the description is intended for audit logs and other output read by people.
+func (r Rule) ToHumanReadable() string {
+	parts := make([]string, 0)
+	if r.Action != "" {
+		parts = append(parts, strings.ToLower(r.Action))
+	} else {
+		parts = append(parts, "allow")
+	}
+
+	if r.IPVersion != nil {
+		parts = append(parts, fmt.Sprintf("IPv%d", *r.IPVersion))
+	}
+	if r.Protocol != nil {
+		parts = append(parts, r.Protocol.String())
+	}
+	if r.NotProtocol != nil {
+		parts = append(parts, "not", r.NotProtocol.String())
+	}
+	if r.ICMPType != nil {
+		parts = append(parts, "type", strconv.Itoa(*r.ICMPType))
+	}
+	if r.ICMPCode != nil {
+		parts = append(parts, "code", strconv.Itoa(*r.ICMPCode))
+	}
+	if r.NotICMPType != nil {
+		parts = append(parts, "not type", strconv.Itoa(*r.NotICMPType))
+	}
+	if r.NotICMPCode != nil {
+		parts = append(parts, "not code", strconv.Itoa(*r.NotICMPCode))
+	}
+
+	parts = append(parts, "from", describeRuleEndpoint(
+		r.AllSrcNets(), r.AllNotSrcNets(),
+		r.SrcSelector, r.NotSrcSelector,
+		r.SrcTag, r.NotSrcTag,
+		r.SrcPorts, r.NotSrcPorts,
+	))
+	parts = append(parts, "to", describeRuleEndpoint(
+		r.AllDstNets(), r.AllNotDstNets(),
+		r.DstSelector, r.NotDstSelector,
+		r.DstTag, r.NotDstTag,
+		r.DstPorts, r.NotDstPorts,
+	))
+
+	if r.HTTPMatch != nil {
+		if len(r.HTTPMatch.Methods) > 0 {
+			parts = append(parts, "HTTP method", strings.Join(r.HTTPMatch.Methods, " or "))
+		}
+		if len(r.HTTPMatch.Paths) > 0 {
+			paths := make([]string, len(r.HTTPMatch.Paths))
+			for i, p := range r.HTTPMatch.Paths {
+				if p.Exact != "" {
+					paths[i] = p.Exact
+				} else {
+					paths[i] = p.Prefix + "*"
+				}
+			}
+			parts = append(parts, "HTTP path", strings.Join(paths, " or "))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// describeRuleEndpoint returns the English description of the source or destination
+// match criteria of a rule, used by ToHumanReadable.  The address criteria are described
+// as "any" if there are none.
+func describeRuleEndpoint(
+	nets, notNets []*net.IPNet,
+	selector, notSelector string,
+	tag, notTag string,
+	ports, notPorts []numorstring.Port,
+) string {
+	joinOr := func(nets []*net.IPNet) string {
+		return strings.Replace(joinNets(nets), ",", " or ", -1)
+	}
+	describePorts := func(ports []numorstring.Port) string {
+		strs := make([]string, len(ports))
+		for i, p := range ports {
+			strs[i] = p.String()
+		}
+		if len(ports) == 1 {
+			return "port " + strs[0]
+		}
+		return "ports " + strings.Join(strs, ",")
+	}
+
+	addrs := make([]string, 0)
+	if len(nets) > 0 {
+		addrs = append(addrs, joinOr(nets))
+	}
+	if selector != "" {
+		addrs = append(addrs, fmt.Sprintf("selector %q", selector))
+	}
+	if tag != "" {
+		addrs = append(addrs, "tag "+tag)
+	}
+	if len(notNets) > 0 {
+		addrs = append(addrs, "not "+joinOr(notNets))
+	}
+	if notSelector != "" {
+		addrs = append(addrs, fmt.Sprintf("not selector %q", notSelector))
+	}
+	if notTag != "" {
+		addrs = append(addrs, "not tag "+notTag)
+	}
+
+	desc := "any"
+	if len(addrs) > 0 {
+		desc = strings.Join(addrs, " and ")
+	}
+	if len(ports) > 0 {
+		desc += " " + describePorts(ports)
+	}
+	if len(notPorts) > 0 {
+		desc += " not " + describePorts(notPorts)
+	}
+	return desc
+}
@@ -125,3 +125,53 @@ var _ = Describe("Rule", func() {
 		})
 	}
 })
+
+var namedPort, _ = numorstring.PortFromString("https")
+var _, srcCIDR, _ = net.ParseCIDR("10.0.0.0/8")
+var _, srcCIDR2, _ = net.ParseCIDR("192.168.0.0/16")
+var ipv6 = 6
+
+var ruleHumanReadableTests = []ruleTest{
+	// Empty
+	{model.Rule{}, "allow from any to any"},
+
+	// Ingress rules.
+	{model.Rule{Action: "Allow", Protocol: &tcpProto, SrcNet: srcCIDR, SrcPorts: []numorstring.Port{numorstring.SinglePort(443)}},
+		"allow TCP from 10.0.0.0/8 port 443 to any"},
+	{model.Rule{Action: "Deny", SrcNets: []*net.IPNet{srcCIDR, srcCIDR2}, NotSrcSelector: "role == 'db'"},
+		`deny from 10.0.0.0/8 or 192.168.0.0/16 and not selector "role == 'db'" to any`},
+	{model.Rule{Action: "Allow", Protocol: &tcpProto, SrcSelector: "all()", DstPorts: ports},
+		`allow TCP from selector "all()" to any ports 1234,10:20`},
+
+	// Egress rules.
+	{model.Rule{Action: "Allow", IPVersion: &ipv6, Protocol: &tcpProto, DstTag: "web", NotDstPorts: ports2},
+		"allow IPv6 TCP from any to tag web not port 4567"},
+	{model.Rule{Action: "Log", DstNet: cidr, NotDstNets: []*net.IPNet{srcCIDR2}},
+		"log from any to 10.0.0.0/16 and not 192.168.0.0/16"},
+
+	// ICMP rules.
+	{model.Rule{Action: "Deny", Protocol: &icmpProto, ICMPType: &icmpType, ICMPCode: &icmpCode},
+		"deny ICMP type 10 code 6 from any to any"},
+	{model.Rule{Protocol: &icmpProto, NotICMPType: &icmpTypeZero},
+		"allow ICMP not type 0 from any to any"},
+	{model.Rule{NotProtocol: &icmpProto}, "allow not ICMP from any to any"},
+
+	// Named port rules.
+	{model.Rule{Protocol: &tcpProto, DstSelector: "app == 'web'", DstPorts: []numorstring.Port{namedPort}},
+		`allow TCP from any to selector "app == 'web'" port https`},
+
+	// Application layer rules.
+	{model.Rule{DstPorts: ports2, HTTPMatch: &model.HTTPMatch{Methods: []string{"GET", "PUT"}, Paths: []apiv3.HTTPPath{{Exact: "/foo"}, {Prefix: "/bar"}}}},
+		"allow from any to any port 4567 HTTP method GET or PUT HTTP path /foo or /bar*"},
+}
+
+var _ = Describe("Rule human readable description", func() {
+	for _, test := range ruleHumanReadableTests {
+		test := test // For closure
+		Describe(fmt.Sprintf("%#v", test.rule), func() {
+			It("should be described as "+test.expectedOutput, func() {
+				Expect(test.rule.ToHumanReadable()).To(Equal(test.expectedOutput))
+			})
+		})
+	}
+})
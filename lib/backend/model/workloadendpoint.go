@@ -20,9 +20,11 @@ import (
 	"regexp"
 
 	"reflect"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 	"github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/net"
@@ -30,6 +32,7 @@ import (
 
 var (
 	matchWorkloadEndpoint = regexp.MustCompile("^/?calico/v1/host/([^/]+)/workload/([^/]+)/([^/]+)/endpoint/([^/]+)$")
+	openStackWorkloadID   = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
 )
 
 type WorkloadEndpointKey struct {
@@ -39,6 +42,81 @@ type WorkloadEndpointKey struct {
 	EndpointID     string `json:"-"`
 }
 
+// ParseWorkloadEndpointKey parses the canonical etcd path of a WorkloadEndpoint, i.e.
+// /calico/v1/host/<node>/workload/<orchestrator>/<workload>/endpoint/<endpoint>, into a
+// WorkloadEndpointKey.  The orchestrator, workload and endpoint IDs are unescaped.
+func ParseWorkloadEndpointKey(path string) (*WorkloadEndpointKey, error) {
+	k := WorkloadEndpointListOptions{}.KeyFromDefaultPath(path)
+	if k == nil {
+		return nil, errors.ErrorParsingDatastoreEntry{
+			RawKey: path,
+			Err:    fmt.Errorf("not a WorkloadEndpoint path"),
+		}
+	}
+	key := k.(WorkloadEndpointKey)
+	return &key, nil
+}
+
+// KeyValidationError is returned when a key fails validation.  It lists each invalid
+// field of the key.
+type KeyValidationError struct {
+	Key           Key
+	ErroredFields []errors.ErroredField
+}
+
+func (e KeyValidationError) Error() string {
+	fields := make([]string, len(e.ErroredFields))
+	for i, f := range e.ErroredFields {
+		fields[i] = f.String()
+	}
+	return fmt.Sprintf("invalid key %s: %s", e.Key, strings.Join(fields, ", "))
+}
+
+// Validate checks that the identifiers of the key are populated, and that the workload ID
+// has the format used by the orchestrator: <namespace>.<pod> for Kubernetes and a UUID for
+// OpenStack.  A KeyValidationError listing every invalid field is returned if not.
+func (key WorkloadEndpointKey) Validate() error {
+	var fields []errors.ErroredField
+	for _, f := range []struct{ name, value string }{
+		{"Hostname", key.Hostname},
+		{"OrchestratorID", key.OrchestratorID},
+		{"WorkloadID", key.WorkloadID},
+		{"EndpointID", key.EndpointID},
+	} {
+		if f.value == "" {
+			fields = append(fields, errors.ErroredField{Name: f.name, Reason: "must be specified"})
+		}
+	}
+
+	if key.WorkloadID != "" {
+		switch key.OrchestratorID {
+		case apiv3.OrchestratorKubernetes:
+			// The namespace cannot contain a dot, but the pod name can, so the workload ID
+			// is split at the first dot.
+			if parts := strings.SplitN(key.WorkloadID, ".", 2); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				fields = append(fields, errors.ErroredField{
+					Name:   "WorkloadID",
+					Value:  key.WorkloadID,
+					Reason: "must be of the form <namespace>.<pod> for a Kubernetes workload",
+				})
+			}
+		case apiv3.OrchestratorOpenStack:
+			if !openStackWorkloadID.MatchString(key.WorkloadID) {
+				fields = append(fields, errors.ErroredField{
+					Name:   "WorkloadID",
+					Value:  key.WorkloadID,
+					Reason: "must be a UUID for an OpenStack workload",
+				})
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return KeyValidationError{Key: key, ErroredFields: fields}
+	}
+	return nil
+}
+
 func (key WorkloadEndpointKey) defaultPath() (string, error) {
 	if key.Hostname == "" {
 		return "", errors.ErrorInsufficientIdentifiers{Name: "node"}
@@ -165,6 +243,223 @@ type WorkloadEndpoint struct {
 	GenerateName     string            `json:"generate_name,omitempty"`
 }
 
+// AllNets returns the IPv4 and IPv6 networks of the endpoint, IPv4 first.  It is safe to
+// call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) AllNets() []net.IPNet {
+	if w == nil || len(w.IPv4Nets)+len(w.IPv6Nets) == 0 {
+		return nil
+	}
+	nets := make([]net.IPNet, 0, len(w.IPv4Nets)+len(w.IPv6Nets))
+	nets = append(nets, w.IPv4Nets...)
+	return append(nets, w.IPv6Nets...)
+}
+
+// AllIPs returns the host IP of each of the IPv4 and IPv6 networks of the endpoint, IPv4
+// first.  Endpoint networks are /32 (IPv4) or /128 (IPv6), so this is the network address.
+// It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) AllIPs() []net.IP {
+	nets := w.AllNets()
+	if nets == nil {
+		return nil
+	}
+	ips := make([]net.IP, 0, len(nets))
+	for _, n := range nets {
+		ips = append(ips, net.IP{IP: n.IP})
+	}
+	return ips
+}
+
+// ActiveIPv4 returns the host IP of the first IPv4 network of the endpoint, or nil if the
+// endpoint has no IPv4 networks.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) ActiveIPv4() *net.IP {
+	if w == nil || len(w.IPv4Nets) == 0 {
+		return nil
+	}
+	return &net.IP{IP: w.IPv4Nets[0].IP}
+}
+
+// ActiveIPv6 returns the host IP of the first IPv6 network of the endpoint, or nil if the
+// endpoint has no IPv6 networks.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) ActiveIPv6() *net.IP {
+	if w == nil || len(w.IPv6Nets) == 0 {
+		return nil
+	}
+	return &net.IP{IP: w.IPv6Nets[0].IP}
+}
+
+// The address families returned by WorkloadEndpoint.AddressFamily.
+const (
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+	AddressFamilyDual = "dual"
+)
+
+// AddressFamily returns AddressFamilyIPv4 or AddressFamilyIPv6 if the endpoint only has
+// networks of that IP version, AddressFamilyDual if it has both, or an empty string if it
+// has no networks.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) AddressFamily() string {
+	switch {
+	case w.IsDualStack():
+		return AddressFamilyDual
+	case w.ActiveIPv4() != nil:
+		return AddressFamilyIPv4
+	case w.ActiveIPv6() != nil:
+		return AddressFamilyIPv6
+	}
+	return ""
+}
+
+// IsDualStack returns true if the endpoint has both IPv4 and IPv6 networks.  It is safe to
+// call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) IsDualStack() bool {
+	return w != nil && len(w.IPv4Nets) > 0 && len(w.IPv6Nets) > 0
+}
+
+// AllNATs returns the IPv4 and IPv6 NAT mappings of the endpoint, IPv4 first.  It is safe
+// to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) AllNATs() []IPNAT {
+	if w == nil || len(w.IPv4NAT)+len(w.IPv6NAT) == 0 {
+		return nil
+	}
+	nats := make([]IPNAT, 0, len(w.IPv4NAT)+len(w.IPv6NAT))
+	nats = append(nats, w.IPv4NAT...)
+	return append(nats, w.IPv6NAT...)
+}
+
+// HasNAT returns true if the endpoint has any IPv4 or IPv6 NAT mappings.  It is safe to
+// call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) HasNAT() bool {
+	return w != nil && len(w.IPv4NAT)+len(w.IPv6NAT) > 0
+}
+
+// NATsForIP returns the NAT mappings of the endpoint whose internal IP is the supplied IP,
+// or nil if there are none.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) NATsForIP(ip net.IP) []IPNAT {
+	var nats []IPNAT
+	for _, nat := range w.AllNATs() {
+		if nat.IntIP.Equal(ip) {
+			nats = append(nats, nat)
+		}
+	}
+	return nats
+}
+
+// HasNATForIP returns true if the endpoint has a NAT mapping whose internal IP is the
+// supplied IP.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) HasNATForIP(ip net.IP) bool {
+	for _, nat := range w.AllNATs() {
+		if nat.IntIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllNATExternalIPs returns the external IP of each of the IPv4 and IPv6 NAT mappings of
+// the endpoint, IPv4 first.  It is safe to call on a nil WorkloadEndpoint.
+func (w *WorkloadEndpoint) AllNATExternalIPs() []net.IP {
+	nats := w.AllNATs()
+	if nats == nil {
+		return nil
+	}
+	ips := make([]net.IP, 0, len(nats))
+	for _, nat := range nats {
+		ips = append(ips, nat.ExtIP)
+	}
+	return ips
+}
+
+// Sanitize normalises the fields of the endpoint in place, so that equivalent endpoints
+// compare as equal: whitespace is trimmed from the interface name, and duplicate profile
+// IDs and networks are removed.  The profiles are applied in order, so their order is
+// preserved rather than sorted.  An ErrorValidation is returned if the MAC address is set
+// to all zeros.
+func (w *WorkloadEndpoint) Sanitize() error {
+	if w.Mac != nil && len(w.Mac.HardwareAddr) > 0 && isZeroMAC(w.Mac.HardwareAddr) {
+		return errors.ErrorValidation{ErroredFields: []errors.ErroredField{{
+			Name:   "Mac",
+			Value:  w.Mac.String(),
+			Reason: "must not be all zeros",
+		}}}
+	}
+
+	w.Name = strings.TrimSpace(w.Name)
+	if w.ProfileIDs != nil {
+		seen := make(map[string]bool, len(w.ProfileIDs))
+		profileIDs := make([]string, 0, len(w.ProfileIDs))
+		for _, id := range w.ProfileIDs {
+			if !seen[id] {
+				seen[id] = true
+				profileIDs = append(profileIDs, id)
+			}
+		}
+		w.ProfileIDs = profileIDs
+	}
+	w.IPv4Nets = dedupeNets(w.IPv4Nets)
+	w.IPv6Nets = dedupeNets(w.IPv6Nets)
+	return nil
+}
+
+// MigrateProfileRefs rewrites the profile IDs of the endpoint in place, replacing each
+// prefix in rewriteRules with the corresponding value, e.g. {"k8s_ns.": "kns."}.  If more
+// than one prefix matches a profile ID, the longest is used.  An ErrorValidation is
+// returned, and the profile IDs are left unchanged, if two different profile IDs would be
+// rewritten to the same value.  Profile IDs that are already duplicated are left to
+// Sanitize.
+func (w *WorkloadEndpoint) MigrateProfileRefs(rewriteRules map[string]string) error {
+	profileIDs := make([]string, len(w.ProfileIDs))
+	seen := make(map[string]string, len(w.ProfileIDs))
+	for i, id := range w.ProfileIDs {
+		rewritten := id
+		matched := -1
+		for prefix, replacement := range rewriteRules {
+			if strings.HasPrefix(id, prefix) && len(prefix) > matched {
+				matched = len(prefix)
+				rewritten = replacement + strings.TrimPrefix(id, prefix)
+			}
+		}
+		if orig, ok := seen[rewritten]; ok && orig != id {
+			return errors.ErrorValidation{ErroredFields: []errors.ErroredField{{
+				Name:   "ProfileIDs",
+				Value:  id,
+				Reason: fmt.Sprintf("rewrites to %q, which duplicates the profile %q", rewritten, orig),
+			}}}
+		}
+		seen[rewritten] = id
+		profileIDs[i] = rewritten
+	}
+	if w.ProfileIDs != nil {
+		w.ProfileIDs = profileIDs
+	}
+	return nil
+}
+
+// dedupeNets returns a copy of the networks with the duplicates removed, preserving the
+// order of the first occurrence of each network.
+func dedupeNets(nets []net.IPNet) []net.IPNet {
+	if nets == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(nets))
+	result := make([]net.IPNet, 0, len(nets))
+	for _, n := range nets {
+		if s := n.String(); !seen[s] {
+			seen[s] = true
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+func isZeroMAC(mac []byte) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 type EndpointPort struct {
 	Name     string               `json:"name" validate:"name"`
 	Protocol numorstring.Protocol `json:"protocol"`
@@ -0,0 +1,315 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model_test
+
+import (
+	"net"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/projectcalico/libcalico-go/lib/backend/model"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("WorkloadEndpoint address helpers", func() {
+	wep := &WorkloadEndpoint{
+		IPv4Nets: []cnet.IPNet{cnet.MustParseNetwork("10.0.0.1/32"), cnet.MustParseNetwork("10.0.0.2/32")},
+		IPv6Nets: []cnet.IPNet{cnet.MustParseNetwork("fd00::1/128")},
+		IPv4NAT: []IPNAT{
+			{IntIP: cnet.MustParseIP("10.0.0.1"), ExtIP: cnet.MustParseIP("172.16.0.1")},
+			{IntIP: cnet.MustParseIP("10.0.0.1"), ExtIP: cnet.MustParseIP("172.16.0.2")},
+		},
+		IPv6NAT: []IPNAT{
+			{IntIP: cnet.MustParseIP("fd00::1"), ExtIP: cnet.MustParseIP("fd01::1")},
+		},
+	}
+
+	It("should return the IPv4 and then the IPv6 networks", func() {
+		Expect(wep.AllNets()).To(Equal([]cnet.IPNet{
+			cnet.MustParseNetwork("10.0.0.1/32"),
+			cnet.MustParseNetwork("10.0.0.2/32"),
+			cnet.MustParseNetwork("fd00::1/128"),
+		}))
+	})
+
+	It("should not modify the endpoint networks when the returned networks are appended to", func() {
+		nets := wep.AllNets()[:1]
+		_ = append(nets, cnet.MustParseNetwork("10.0.0.3/32"))
+		Expect(wep.IPv4Nets[1]).To(Equal(cnet.MustParseNetwork("10.0.0.2/32")))
+	})
+
+	It("should return the host IPs", func() {
+		ips := []string{}
+		for _, ip := range wep.AllIPs() {
+			ips = append(ips, ip.String())
+		}
+		Expect(ips).To(Equal([]string{"10.0.0.1", "10.0.0.2", "fd00::1"}))
+	})
+
+	It("should return the first IPv4 and IPv6 host IPs as the active IPs", func() {
+		Expect(wep.ActiveIPv4().String()).To(Equal("10.0.0.1"))
+		Expect(wep.ActiveIPv6().String()).To(Equal("fd00::1"))
+	})
+
+	It("should return no active IP for a missing IP version", func() {
+		v4Only := &WorkloadEndpoint{IPv4Nets: wep.IPv4Nets}
+		Expect(v4Only.ActiveIPv4().String()).To(Equal("10.0.0.1"))
+		Expect(v4Only.ActiveIPv6()).To(BeNil())
+	})
+
+	It("should return the NATs and the NAT external IPs", func() {
+		Expect(wep.AllNATs()).To(HaveLen(3))
+		ips := []string{}
+		for _, ip := range wep.AllNATExternalIPs() {
+			ips = append(ips, ip.String())
+		}
+		Expect(ips).To(Equal([]string{"172.16.0.1", "172.16.0.2", "fd01::1"}))
+	})
+
+	It("should return the NATs for an internal IP", func() {
+		Expect(wep.HasNAT()).To(BeTrue())
+		Expect(wep.NATsForIP(cnet.MustParseIP("10.0.0.1"))).To(Equal(wep.IPv4NAT))
+		Expect(wep.NATsForIP(cnet.MustParseIP("fd00::1"))).To(Equal(wep.IPv6NAT))
+		Expect(wep.NATsForIP(cnet.MustParseIP("10.0.0.2"))).To(BeNil())
+		Expect(wep.HasNATForIP(cnet.MustParseIP("10.0.0.1"))).To(BeTrue())
+		Expect(wep.HasNATForIP(cnet.MustParseIP("fd00::1"))).To(BeTrue())
+		Expect(wep.HasNATForIP(cnet.MustParseIP("10.0.0.2"))).To(BeFalse())
+
+		// An external IP is not an internal IP.
+		Expect(wep.HasNATForIP(cnet.MustParseIP("172.16.0.1"))).To(BeFalse())
+
+		// An IPv4-mapped IPv6 address matches the IPv4 address.
+		Expect(wep.HasNATForIP(cnet.IP{IP: net.ParseIP("10.0.0.1").To16()})).To(BeTrue())
+	})
+
+	DescribeTable("should return the address family",
+		func(v4, v6 []cnet.IPNet, expected string) {
+			w := &WorkloadEndpoint{IPv4Nets: v4, IPv6Nets: v6}
+			Expect(w.AddressFamily()).To(Equal(expected))
+			Expect(w.IsDualStack()).To(Equal(expected == AddressFamilyDual))
+		},
+		Entry("IPv4 only", []cnet.IPNet{cnet.MustParseNetwork("10.0.0.1/32")}, nil, AddressFamilyIPv4),
+		Entry("IPv6 only", nil, []cnet.IPNet{cnet.MustParseNetwork("fd00::1/128")}, AddressFamilyIPv6),
+		Entry("dual stack", []cnet.IPNet{cnet.MustParseNetwork("10.0.0.1/32")}, []cnet.IPNet{cnet.MustParseNetwork("fd00::1/128")}, AddressFamilyDual),
+		Entry("no networks", nil, nil, ""),
+	)
+
+	It("should return nil for an endpoint with no addresses", func() {
+		empty := &WorkloadEndpoint{}
+		Expect(empty.AllNets()).To(BeNil())
+		Expect(empty.AllIPs()).To(BeNil())
+		Expect(empty.ActiveIPv4()).To(BeNil())
+		Expect(empty.ActiveIPv6()).To(BeNil())
+		Expect(empty.AllNATs()).To(BeNil())
+		Expect(empty.AllNATExternalIPs()).To(BeNil())
+		Expect(empty.HasNAT()).To(BeFalse())
+		Expect(empty.NATsForIP(cnet.MustParseIP("10.0.0.1"))).To(BeNil())
+		Expect(empty.HasNATForIP(cnet.MustParseIP("10.0.0.1"))).To(BeFalse())
+	})
+
+	It("should return nil for a nil endpoint", func() {
+		var nilWEP *WorkloadEndpoint
+		Expect(nilWEP.AllNets()).To(BeNil())
+		Expect(nilWEP.AllIPs()).To(BeNil())
+		Expect(nilWEP.ActiveIPv4()).To(BeNil())
+		Expect(nilWEP.ActiveIPv6()).To(BeNil())
+		Expect(nilWEP.AddressFamily()).To(BeEmpty())
+		Expect(nilWEP.IsDualStack()).To(BeFalse())
+		Expect(nilWEP.AllNATs()).To(BeNil())
+		Expect(nilWEP.AllNATExternalIPs()).To(BeNil())
+		Expect(nilWEP.HasNAT()).To(BeFalse())
+		Expect(nilWEP.NATsForIP(cnet.MustParseIP("10.0.0.1"))).To(BeNil())
+		Expect(nilWEP.HasNATForIP(cnet.MustParseIP("10.0.0.1"))).To(BeFalse())
+	})
+})
+
+var _ = DescribeTable("ParseWorkloadEndpointKey",
+	func(path string, expected *WorkloadEndpointKey) {
+		key, err := ParseWorkloadEndpointKey(path)
+		if expected == nil {
+			Expect(err).To(HaveOccurred())
+			Expect(key).To(BeNil())
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(key).To(Equal(expected))
+
+		// The key should convert back to the same path.
+		p, err := KeyToDefaultPath(*key)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p).To(Equal("/" + strings.TrimPrefix(path, "/")))
+	},
+	Entry("k8s endpoint",
+		"/calico/v1/host/node1/workload/k8s/default.frontend-5gs43/endpoint/eth0",
+		&WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "default.frontend-5gs43", EndpointID: "eth0"},
+	),
+	Entry("path without a leading slash",
+		"calico/v1/host/node1/workload/cni/a1b2c3/endpoint/eth0",
+		&WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "cni", WorkloadID: "a1b2c3", EndpointID: "eth0"},
+	),
+	Entry("escaped slashes in the workload ID",
+		"/calico/v1/host/node1/workload/k8s/default%2ffrontend/endpoint/eth0",
+		&WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "default/frontend", EndpointID: "eth0"},
+	),
+	Entry("escaped percent in the workload ID",
+		"/calico/v1/host/node1/workload/openstack/100%25_%2fwork%2520load/endpoint/tap%2f1",
+		&WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "openstack", WorkloadID: "100%_/work%20load", EndpointID: "tap/1"},
+	),
+	Entry("special characters in the workload ID",
+		"/calico/v1/host/node.example.com/workload/libnetwork/my:work@load+1=2/endpoint/ep-1",
+		&WorkloadEndpointKey{Hostname: "node.example.com", OrchestratorID: "libnetwork", WorkloadID: "my:work@load+1=2", EndpointID: "ep-1"},
+	),
+	Entry("endpoint list path", "/calico/v1/host/node1/workload/k8s/default.frontend/endpoint", nil),
+	Entry("host endpoint path", "/calico/v1/host/node1/endpoint/eth0", nil),
+	Entry("extra path segments", "/calico/v1/host/node1/workload/k8s/default/frontend/endpoint/eth0", nil),
+	Entry("empty path", "", nil),
+)
+
+var _ = Describe("WorkloadEndpointKey validation", func() {
+	It("should accept a valid Kubernetes key", func() {
+		key := WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "default.pod1", EndpointID: "eth0"}
+		Expect(key.Validate()).To(Succeed())
+	})
+
+	It("should accept a Kubernetes pod name that contains dots", func() {
+		key := WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "default.pod.1", EndpointID: "eth0"}
+		Expect(key.Validate()).To(Succeed())
+	})
+
+	It("should accept a valid OpenStack key", func() {
+		key := WorkloadEndpointKey{
+			Hostname:       "node1",
+			OrchestratorID: "openstack",
+			WorkloadID:     "7f3d0b0e-1b2c-4d5e-8f90-a1b2c3d4e5f6",
+			EndpointID:     "tap1234",
+		}
+		Expect(key.Validate()).To(Succeed())
+	})
+
+	It("should accept any workload ID for other orchestrators", func() {
+		key := WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "cni", WorkloadID: "a.b.c", EndpointID: "eth0"}
+		Expect(key.Validate()).To(Succeed())
+	})
+
+	DescribeTable("should list each invalid field",
+		func(key WorkloadEndpointKey, expectedFields ...string) {
+			err := key.Validate()
+			Expect(err).To(HaveOccurred())
+			kve, ok := err.(KeyValidationError)
+			Expect(ok).To(BeTrue())
+			fields := []string{}
+			for _, f := range kve.ErroredFields {
+				fields = append(fields, f.Name)
+			}
+			Expect(fields).To(Equal(expectedFields))
+		},
+		Entry("empty key", WorkloadEndpointKey{}, "Hostname", "OrchestratorID", "WorkloadID", "EndpointID"),
+		Entry("missing hostname and endpoint",
+			WorkloadEndpointKey{OrchestratorID: "k8s", WorkloadID: "default.pod1"}, "Hostname", "EndpointID"),
+		Entry("Kubernetes workload without a namespace",
+			WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "pod1", EndpointID: "eth0"}, "WorkloadID"),
+		Entry("Kubernetes workload with an empty namespace",
+			WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: ".pod1", EndpointID: "eth0"}, "WorkloadID"),
+		Entry("Kubernetes workload with an empty pod name",
+			WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "default.", EndpointID: "eth0"}, "WorkloadID"),
+		Entry("OpenStack workload that is not a UUID",
+			WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "openstack", WorkloadID: "instance1", EndpointID: "tap1"}, "WorkloadID"),
+		Entry("OpenStack workload without an endpoint",
+			WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "openstack", WorkloadID: "instance1"}, "EndpointID", "WorkloadID"),
+	)
+
+	It("should include the key and the reasons in the error message", func() {
+		key := WorkloadEndpointKey{Hostname: "node1", OrchestratorID: "k8s", WorkloadID: "pod1"}
+		err := key.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(key.String()))
+		Expect(err.Error()).To(ContainSubstring("EndpointID (must be specified)"))
+		Expect(err.Error()).To(ContainSubstring("WorkloadID = 'pod1' (must be of the form <namespace>.<pod> for a Kubernetes workload)"))
+	})
+})
+
+var _ = Describe("WorkloadEndpoint Sanitize", func() {
+	It("should normalise the name, profiles and networks", func() {
+		wep := &WorkloadEndpoint{
+			Name:       "  cali0123\t",
+			ProfileIDs: []string{"prof-b", "prof-a", "prof-b"},
+			IPv4Nets: []cnet.IPNet{
+				cnet.MustParseNetwork("10.0.0.1/32"), cnet.MustParseNetwork("10.0.0.2/32"), cnet.MustParseNetwork("10.0.0.1/32"),
+			},
+			IPv6Nets: []cnet.IPNet{cnet.MustParseNetwork("fd00::1/128"), cnet.MustParseNetwork("fd00::1/128")},
+		}
+		Expect(wep.Sanitize()).To(Succeed())
+		Expect(wep.Name).To(Equal("cali0123"))
+		Expect(wep.ProfileIDs).To(Equal([]string{"prof-b", "prof-a"}))
+		Expect(wep.IPv4Nets).To(Equal([]cnet.IPNet{cnet.MustParseNetwork("10.0.0.1/32"), cnet.MustParseNetwork("10.0.0.2/32")}))
+		Expect(wep.IPv6Nets).To(Equal([]cnet.IPNet{cnet.MustParseNetwork("fd00::1/128")}))
+	})
+
+	It("should make endpoints that differ only in whitespace equal", func() {
+		wep1 := &WorkloadEndpoint{Name: "cali0123", ProfileIDs: []string{"prof-a"}}
+		wep2 := &WorkloadEndpoint{Name: " cali0123 ", ProfileIDs: []string{"prof-a", "prof-a"}}
+		Expect(wep1.Sanitize()).To(Succeed())
+		Expect(wep2.Sanitize()).To(Succeed())
+		Expect(wep2).To(Equal(wep1))
+	})
+
+	It("should leave unset fields unset", func() {
+		wep := &WorkloadEndpoint{}
+		Expect(wep.Sanitize()).To(Succeed())
+		Expect(wep).To(Equal(&WorkloadEndpoint{}))
+	})
+
+	It("should reject an all-zeros MAC address", func() {
+		mac, err := net.ParseMAC("00:00:00:00:00:00")
+		Expect(err).NotTo(HaveOccurred())
+		wep := &WorkloadEndpoint{Mac: &cnet.MAC{HardwareAddr: mac}}
+		err = wep.Sanitize()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("must not be all zeros"))
+
+		mac, err = net.ParseMAC("ee:ee:ee:ee:ee:ee")
+		Expect(err).NotTo(HaveOccurred())
+		wep.Mac = &cnet.MAC{HardwareAddr: mac}
+		Expect(wep.Sanitize()).To(Succeed())
+	})
+})
+
+var _ = Describe("WorkloadEndpoint MigrateProfileRefs", func() {
+	rules := map[string]string{"k8s_ns.": "kns.", "k8s_ns.kube-": "kns.system-"}
+
+	DescribeTable("should rewrite the profile IDs in place",
+		func(profileIDs, expected []string) {
+			wep := &WorkloadEndpoint{ProfileIDs: profileIDs}
+			Expect(wep.MigrateProfileRefs(rules)).To(Succeed())
+			Expect(wep.ProfileIDs).To(Equal(expected))
+		},
+		Entry("no profiles", nil, nil),
+		Entry("no matching rule", []string{"prof-a"}, []string{"prof-a"}),
+		Entry("matching rule", []string{"k8s_ns.default", "prof-a"}, []string{"kns.default", "prof-a"}),
+		Entry("longest matching rule", []string{"k8s_ns.kube-system"}, []string{"kns.system-system"}),
+		Entry("existing duplicates", []string{"k8s_ns.default", "k8s_ns.default"}, []string{"kns.default", "kns.default"}),
+	)
+
+	It("should reject a rewrite that produces a duplicate", func() {
+		wep := &WorkloadEndpoint{ProfileIDs: []string{"k8s_ns.default", "kns.default"}}
+		err := wep.MigrateProfileRefs(rules)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`duplicates the profile "k8s_ns.default"`))
+		Expect(wep.ProfileIDs).To(Equal([]string{"k8s_ns.default", "kns.default"}))
+	})
+})
@@ -16,7 +16,6 @@ package updateprocessors
 
 import (
 	"errors"
-	"net"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -112,11 +111,10 @@ func convertWorkloadEndpointV2ToV1Value(val interface{}) (interface{}, error) {
 
 	var cmac *cnet.MAC
 	if v3res.Spec.MAC != "" {
-		mac, err := net.ParseMAC(v3res.Spec.MAC)
+		cmac, err = cnet.ParseMAC(v3res.Spec.MAC)
 		if err != nil {
 			return nil, err
 		}
-		cmac = &cnet.MAC{HardwareAddr: mac}
 	}
 
 	// Convert the EndpointPort type from the API pkg to the v1 model equivalent type
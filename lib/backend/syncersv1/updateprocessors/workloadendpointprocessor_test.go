@@ -250,6 +250,33 @@ var _ = Describe("Test the WorkloadEndpoint update processor", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("should normalise the MAC address of a WorkloadEndpoint", func() {
+		up := updateprocessors.NewWorkloadEndpointUpdateProcessor()
+
+		res := libapiv3.NewWorkloadEndpoint()
+		res.Namespace = ns1
+		res.Labels = map[string]string{
+			"projectcalico.org/namespace":    ns1,
+			"projectcalico.org/orchestrator": oid1,
+		}
+		res.Spec.Node = hn1
+		res.Spec.Orchestrator = oid1
+		res.Spec.Workload = wid1
+		res.Spec.Endpoint = eid1
+		res.Spec.InterfaceName = iface1
+		res.Spec.IPNetworks = []string{"10.100.10.1"}
+		res.Spec.MAC = "02-42-7D-C6-F0-80"
+
+		kvps, err := up.Process(&model.KVPair{
+			Key:      v3WorkloadEndpointKey1,
+			Value:    res,
+			Revision: "abcde",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvps).To(HaveLen(1))
+		Expect(kvps[0].Value.(*model.WorkloadEndpoint).Mac.String()).To(Equal("02:42:7d:c6:f0:80"))
+	})
+
 	It("should filter out a WEP with no IPNetworks", func() {
 		up := updateprocessors.NewWorkloadEndpointUpdateProcessor()
 
@@ -16,9 +16,12 @@ package clientv3
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 
 	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/options"
 	validator "github.com/projectcalico/libcalico-go/lib/validator/v3"
 	"github.com/projectcalico/libcalico-go/lib/watch"
@@ -32,6 +35,21 @@ type GlobalNetworkPolicyInterface interface {
 	Get(ctx context.Context, name string, opts options.GetOptions) (*apiv3.GlobalNetworkPolicy, error)
 	List(ctx context.Context, opts options.ListOptions) (*apiv3.GlobalNetworkPolicyList, error)
 	Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error)
+	ValidateOrder(ctx context.Context, tier string) error
+}
+
+// defaultTier is the tier that contains all of the GlobalNetworkPolicies.
+const defaultTier = "default"
+
+// ErrDuplicateOrder is returned by ValidateOrder for each set of GlobalNetworkPolicies in a
+// tier that have the same order.
+type ErrDuplicateOrder struct {
+	Order    float64
+	Policies []string
+}
+
+func (e ErrDuplicateOrder) Error() string {
+	return fmt.Sprintf("policies %s have the same order %v", strings.Join(e.Policies, ", "), e.Order)
 }
 
 // globalNetworkPolicies implements GlobalNetworkPolicyInterface
@@ -152,6 +170,49 @@ func (r globalNetworkPolicies) Watch(ctx context.Context, opts options.ListOptio
 	return r.client.resources.Watch(ctx, opts, apiv3.KindGlobalNetworkPolicy, &policyConverter{})
 }
 
+// ValidateOrder checks that no two GlobalNetworkPolicies in the tier have the same order,
+// since the relative order in which such policies are applied is not defined by their
+// order.  Policies with no order are not checked.  If there are duplicates, a
+// cerrors.MultiError is returned containing an ErrDuplicateOrder for each duplicated
+// order, sorted by order.
+//
+// All GlobalNetworkPolicies are in the default tier; an empty tier is treated as the
+// default tier, and any other tier contains no policies.
+func (r globalNetworkPolicies) ValidateOrder(ctx context.Context, tier string) error {
+	if tier != "" && tier != defaultTier {
+		return nil
+	}
+
+	policies, err := r.List(ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	byOrder := map[float64][]string{}
+	for _, p := range policies.Items {
+		if p.Spec.Order != nil {
+			byOrder[*p.Spec.Order] = append(byOrder[*p.Spec.Order], p.Name)
+		}
+	}
+
+	var dups []ErrDuplicateOrder
+	for order, names := range byOrder {
+		if len(names) > 1 {
+			sort.Strings(names)
+			dups = append(dups, ErrDuplicateOrder{Order: order, Policies: names})
+		}
+	}
+	if len(dups) == 0 {
+		return nil
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i].Order < dups[j].Order })
+	errs := make([]error, len(dups))
+	for i, d := range dups {
+		errs[i] = d
+	}
+	return cerrors.MultiError{Errors: errs}
+}
+
 func defaultPolicyTypesField(ingressRules, egressRules []apiv3.Rule, types *[]apiv3.PolicyType) {
 	if len(*types) == 0 {
 		// Default the Types field according to what inbound and outbound rules are present
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeGNPListResources implements the List method of resourceInterface for a set of
+// GlobalNetworkPolicies, which are named as they are in the datastore.
+type fakeGNPListResources struct {
+	resourceInterface
+	policies []*apiv3.GlobalNetworkPolicy
+}
+
+func (r *fakeGNPListResources) List(ctx context.Context, opts options.ListOptions, kind, listKind string, inout resourceList) error {
+	objs := []runtime.Object{}
+	for _, p := range r.policies {
+		objs = append(objs, p.DeepCopy())
+	}
+	return meta.SetList(inout, objs)
+}
+
+var _ = Describe("GlobalNetworkPolicies ValidateOrder", func() {
+	var res *fakeGNPListResources
+	var gnps globalNetworkPolicies
+
+	addPolicy := func(name string, order *float64) {
+		p := apiv3.NewGlobalNetworkPolicy()
+		p.Name = convertPolicyNameForStorage(name)
+		p.Spec.Order = order
+		res.policies = append(res.policies, p)
+	}
+	order := func(o float64) *float64 {
+		return &o
+	}
+
+	BeforeEach(func() {
+		res = &fakeGNPListResources{}
+		gnps = globalNetworkPolicies{client: client{resources: res}}
+		addPolicy("a", order(10))
+		addPolicy("b", order(20))
+		addPolicy("c", nil)
+		addPolicy("d", nil)
+	})
+
+	It("should succeed if the orders are unique", func() {
+		Expect(gnps.ValidateOrder(context.Background(), "default")).To(Succeed())
+		Expect(gnps.ValidateOrder(context.Background(), "")).To(Succeed())
+	})
+
+	It("should return an error for each duplicated order", func() {
+		addPolicy("f", order(20))
+		addPolicy("e", order(20))
+		addPolicy("g", order(5))
+		addPolicy("h", order(5))
+
+		err := gnps.ValidateOrder(context.Background(), "default")
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(BeAssignableToTypeOf(cerrors.MultiError{}))
+		Expect(err.(cerrors.MultiError).Errors).To(Equal([]error{
+			ErrDuplicateOrder{Order: 5, Policies: []string{"g", "h"}},
+			ErrDuplicateOrder{Order: 20, Policies: []string{"b", "e", "f"}},
+		}))
+		Expect(err.Error()).To(ContainSubstring("policies b, e, f have the same order 20"))
+	})
+
+	It("should find no policies in another tier", func() {
+		addPolicy("e", order(10))
+		Expect(gnps.ValidateOrder(context.Background(), "other")).To(Succeed())
+	})
+})
@@ -39,6 +39,8 @@ type IPPoolInterface interface {
 	Get(ctx context.Context, name string, opts options.GetOptions) (*apiv3.IPPool, error)
 	List(ctx context.Context, opts options.ListOptions) (*apiv3.IPPoolList, error)
 	Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error)
+	WatchIPPools(ctx context.Context, opts options.ListOptions) (*IPPoolWatcher, error)
+	GetByAddress(ctx context.Context, ip cnet.IP) (*apiv3.IPPool, error)
 }
 
 // ipPools implements IPPoolInterface
@@ -260,6 +262,37 @@ func (r ipPools) List(ctx context.Context, opts options.ListOptions) (*apiv3.IPP
 	return res, nil
 }
 
+// GetByAddress returns the IPPool that contains the supplied IP address.  If the address is
+// in multiple (nested) pools, the most specific pool is returned.  Returns an
+// ErrorResourceDoesNotExist if the address is not in any pool.
+func (r ipPools) GetByAddress(ctx context.Context, ip cnet.IP) (*apiv3.IPPool, error) {
+	pools, err := r.List(ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var longestMatch *apiv3.IPPool
+	longestPrefix := -1
+	for i := range pools.Items {
+		_, cidr, err := cnet.ParseCIDR(pools.Items[i].Spec.CIDR)
+		if err != nil || !cidr.Contains(ip.IP) {
+			continue
+		}
+		if ones, _ := cidr.Mask.Size(); ones > longestPrefix {
+			longestMatch = &pools.Items[i]
+			longestPrefix = ones
+		}
+	}
+
+	if longestMatch == nil {
+		return nil, cerrors.ErrorResourceDoesNotExist{
+			Identifier: ip.String(),
+			Err:        fmt.Errorf("IP address %s is not in any IP pool", ip),
+		}
+	}
+	return longestMatch, nil
+}
+
 // Default pool values when reading from storage
 func convertIpPoolFromStorage(pool *apiv3.IPPool) error {
 	// Default the blockSize if it wasn't previously set
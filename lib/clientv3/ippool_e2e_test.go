@@ -30,6 +30,8 @@ import (
 	"github.com/projectcalico/libcalico-go/lib/apiconfig"
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
 	"github.com/projectcalico/libcalico-go/lib/backend"
+	bapi "github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/clientv3"
 	"github.com/projectcalico/libcalico-go/lib/errors"
 	cnet "github.com/projectcalico/libcalico-go/lib/net"
@@ -958,6 +960,68 @@ var _ = testutils.E2eDatastoreDescribe("IPPool tests", testutils.DatastoreAll, f
 			Expect(err.Error()).To(ContainSubstring("IPPool(ippool3) CIDR overlaps with IPPool(ippool1) CIDR 1.2.3.0/24"))
 		})
 	})
+
+	Describe("Verify lookup of the pool containing an address", func() {
+		var c clientv3.Interface
+		var be bapi.Client
+
+		BeforeEach(func() {
+			var err error
+			c, err = clientv3.New(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			be, err = backend.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+			be.Clean()
+
+			By("Creating an IPv4 and an IPv6 pool")
+			_, err = c.IPPools().Create(ctx, &apiv3.IPPool{
+				ObjectMeta: metav1.ObjectMeta{Name: "ippool-v4"},
+				Spec:       apiv3.IPPoolSpec{CIDR: "10.0.0.0/16"},
+			}, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = c.IPPools().Create(ctx, &apiv3.IPPool{
+				ObjectMeta: metav1.ObjectMeta{Name: "ippool-v6"},
+				Spec:       apiv3.IPPoolSpec{CIDR: "fd00::/64"},
+			}, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating pools nested within them directly in the backend, bypassing the overlap validation")
+			for name, cidr := range map[string]string{"ippool-v4-nested": "10.0.1.0/24", "ippool-v6-nested": "fd00::/120"} {
+				_, err = be.Create(ctx, &model.KVPair{
+					Key: model.ResourceKey{Kind: apiv3.KindIPPool, Name: name},
+					Value: &apiv3.IPPool{
+						TypeMeta:   metav1.TypeMeta{Kind: apiv3.KindIPPool, APIVersion: apiv3.GroupVersionCurrent},
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec:       apiv3.IPPoolSpec{CIDR: cidr, BlockSize: 26, NodeSelector: "all()"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		DescribeTable("should return the most specific pool containing the address",
+			func(ip, expectedPool string) {
+				pool, err := c.IPPools().GetByAddress(ctx, cnet.MustParseIP(ip))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pool.Name).To(Equal(expectedPool))
+			},
+			Entry("IPv4 address in the outer pool", "10.0.2.1", "ippool-v4"),
+			Entry("IPv4 address in the nested pool", "10.0.1.1", "ippool-v4-nested"),
+			Entry("IPv6 address in the outer pool", "fd00::1:1", "ippool-v6"),
+			Entry("IPv6 address in the nested pool", "fd00::1", "ippool-v6-nested"),
+		)
+
+		DescribeTable("should return an error for an address that is not in a pool",
+			func(ip string) {
+				_, err := c.IPPools().GetByAddress(ctx, cnet.MustParseIP(ip))
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(errors.ErrorResourceDoesNotExist{}))
+			},
+			Entry("IPv4", "192.168.0.1"),
+			Entry("IPv6", "fd01::1"),
+		)
+	})
 })
 
 var _ = testutils.E2eDatastoreDescribe("IPPool tests (etcd only)", testutils.DatastoreEtcdV3, func(config apiconfig.CalicoAPIConfig) {
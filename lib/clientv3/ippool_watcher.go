@@ -0,0 +1,166 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/options"
+	"github.com/projectcalico/libcalico-go/lib/watch"
+)
+
+// The delays between attempts to re-establish a failed IPPool watch.  The delay doubles
+// after each failed attempt, up to the maximum.
+var (
+	ipPoolWatchMinBackoff = 100 * time.Millisecond
+	ipPoolWatchMaxBackoff = 30 * time.Second
+)
+
+// IPPoolEvent is a change to an IPPool delivered by an IPPoolWatcher.
+type IPPoolEvent struct {
+	// The type of change: watch.Added, watch.Modified or watch.Deleted.
+	Type watch.EventType
+
+	// The IPPool.  For a Deleted event this is the last known state of the pool.
+	Object *apiv3.IPPool
+}
+
+// IPPoolWatcher watches the IPPools, re-establishing the watch if it fails.
+type IPPoolWatcher struct {
+	events chan IPPoolEvent
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// Events returns the channel on which the IPPool events are delivered.  The channel is
+// closed when the watcher stops.
+func (w *IPPoolWatcher) Events() <-chan IPPoolEvent {
+	return w.events
+}
+
+// Done returns a channel that is closed once the watcher has stopped and released its
+// resources, either because Stop was called or the watch context was cancelled.
+func (w *IPPoolWatcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Stop stops the watcher.
+func (w *IPPoolWatcher) Stop() {
+	w.cancel()
+}
+
+// WatchIPPools returns an IPPoolWatcher that delivers the changes to the IPPools that
+// match the supplied options.  Unlike Watch, the watch is re-established if it fails,
+// with an exponential backoff between attempts.  After an error the watch restarts from
+// the current state of the datastore, so every pool is delivered again as an Added
+// event; a consumer using the events to invalidate a cache should treat this as a
+// resync.  An error is only returned if the initial watch cannot be established.
+func (r ipPools) WatchIPPools(ctx context.Context, opts options.ListOptions) (*IPPoolWatcher, error) {
+	wi, err := r.Watch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &IPPoolWatcher{
+		events: make(chan IPPoolEvent, watch.DefaultChanSize),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	go w.run(ctx, r, opts, wi)
+	return w, nil
+}
+
+// run delivers the events from the supplied watch, re-establishing it until the context
+// is cancelled.
+func (w *IPPoolWatcher) run(ctx context.Context, r ipPools, opts options.ListOptions, wi watch.Interface) {
+	defer close(w.done)
+	defer close(w.events)
+
+	backoff := ipPoolWatchMinBackoff
+	for {
+		if wi != nil {
+			if !w.deliver(ctx, wi, &opts) {
+				return
+			}
+			backoff = ipPoolWatchMinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		var err error
+		if wi, err = r.Watch(ctx, opts); err != nil {
+			log.WithError(err).Warning("Failed to re-establish IPPool watch, will retry")
+			wi = nil
+			if backoff *= 2; backoff > ipPoolWatchMaxBackoff {
+				backoff = ipPoolWatchMaxBackoff
+			}
+		}
+	}
+}
+
+// deliver delivers the events from the supplied watch until it fails or is closed.  The
+// revision to resume from is updated in opts.  Returns false if the context is cancelled.
+func (w *IPPoolWatcher) deliver(ctx context.Context, wi watch.Interface, opts *options.ListOptions) bool {
+	defer wi.Stop()
+	for {
+		var e watch.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return false
+		case e, ok = <-wi.ResultChan():
+		}
+		if !ok {
+			log.Info("IPPool watch closed, re-establishing")
+			return true
+		}
+
+		var pool *apiv3.IPPool
+		switch e.Type {
+		case watch.Error:
+			log.WithError(e.Error).Warning("IPPool watch failed, re-establishing")
+			opts.ResourceVersion = ""
+			return true
+		case watch.Deleted:
+			// The revision of a deletion is not known, so the watch resumes from the
+			// revision of the last update.  The deletion may be delivered again.
+			pool, _ = e.Previous.(*apiv3.IPPool)
+		default:
+			if pool, _ = e.Object.(*apiv3.IPPool); pool != nil {
+				opts.ResourceVersion = pool.ResourceVersion
+			}
+		}
+		if pool == nil {
+			log.WithField("Event", e).Warning("Ignoring IPPool watch event with no IPPool")
+			continue
+		}
+		event := IPPoolEvent{Type: e.Type, Object: pool}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case w.events <- event:
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/options"
+	"github.com/projectcalico/libcalico-go/lib/watch"
+)
+
+// fakeWatch is a watch.Interface whose events are supplied by the test.
+type fakeWatch struct {
+	results chan watch.Event
+	once    sync.Once
+}
+
+func (w *fakeWatch) Stop() {
+	w.once.Do(func() { close(w.results) })
+}
+
+func (w *fakeWatch) ResultChan() <-chan watch.Event {
+	return w.results
+}
+
+// fakeWatchResources implements the Watch method of resourceInterface.  Each call fails
+// with the next of the configured errors, then returns a new fakeWatch.
+type fakeWatchResources struct {
+	resourceInterface
+	lock     sync.Mutex
+	errs     []error
+	watches  chan *fakeWatch
+	versions []string
+}
+
+func (r *fakeWatchResources) Watch(ctx context.Context, opts options.ListOptions, kind string, converter watcherConverter) (watch.Interface, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.versions = append(r.versions, opts.ResourceVersion)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	w := &fakeWatch{results: make(chan watch.Event, 10)}
+	r.watches <- w
+	return w, nil
+}
+
+func (r *fakeWatchResources) watchVersions() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string{}, r.versions...)
+}
+
+func newTestPool(name, revision string) *apiv3.IPPool {
+	p := apiv3.NewIPPool()
+	p.Name = name
+	p.ResourceVersion = revision
+	return p
+}
+
+var _ = Describe("IPPoolWatcher", func() {
+	var res *fakeWatchResources
+	var pools ipPools
+	var minBackoff, maxBackoff time.Duration
+
+	BeforeEach(func() {
+		minBackoff, maxBackoff = ipPoolWatchMinBackoff, ipPoolWatchMaxBackoff
+		ipPoolWatchMinBackoff, ipPoolWatchMaxBackoff = time.Millisecond, 4*time.Millisecond
+		res = &fakeWatchResources{watches: make(chan *fakeWatch, 10)}
+		pools = ipPools{client: client{resources: res}}
+	})
+
+	AfterEach(func() {
+		ipPoolWatchMinBackoff, ipPoolWatchMaxBackoff = minBackoff, maxBackoff
+	})
+
+	It("should return an error if the watch cannot be established", func() {
+		res.errs = []error{errors.New("no datastore")}
+		_, err := pools.WatchIPPools(context.Background(), options.ListOptions{})
+		Expect(err).To(MatchError("no datastore"))
+	})
+
+	It("should deliver typed events and reconnect after a failure", func() {
+		w, err := pools.WatchIPPools(context.Background(), options.ListOptions{ResourceVersion: "10"})
+		Expect(err).NotTo(HaveOccurred())
+		defer w.Stop()
+
+		fw := <-res.watches
+		fw.results <- watch.Event{Type: watch.Added, Object: newTestPool("pool1", "11")}
+		fw.results <- watch.Event{Type: watch.Deleted, Previous: newTestPool("pool2", "5")}
+		Eventually(w.Events()).Should(Receive(Equal(IPPoolEvent{Type: watch.Added, Object: newTestPool("pool1", "11")})))
+		Eventually(w.Events()).Should(Receive(Equal(IPPoolEvent{Type: watch.Deleted, Object: newTestPool("pool2", "5")})))
+
+		// Closing the watch resumes from the last revision.  The first attempt to
+		// re-establish the watch fails and is retried.
+		res.lock.Lock()
+		res.errs = []error{errors.New("datastore unavailable")}
+		res.lock.Unlock()
+		fw.Stop()
+		fw = <-res.watches
+		fw.results <- watch.Event{Type: watch.Modified, Object: newTestPool("pool1", "12")}
+		Eventually(w.Events()).Should(Receive(Equal(IPPoolEvent{Type: watch.Modified, Object: newTestPool("pool1", "12")})))
+
+		// An error event restarts the watch from the current state.
+		fw.results <- watch.Event{Type: watch.Error, Error: errors.New("compacted")}
+		<-res.watches
+		Expect(res.watchVersions()).To(Equal([]string{"10", "11", "11", ""}))
+	})
+
+	It("should close the channels when stopped", func() {
+		w, err := pools.WatchIPPools(context.Background(), options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		w.Stop()
+		Eventually(w.Done()).Should(BeClosed())
+		Expect(w.Events()).To(BeClosed())
+	})
+
+	It("should stop when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := pools.WatchIPPools(ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		cancel()
+		Eventually(w.Done()).Should(BeClosed())
+	})
+})
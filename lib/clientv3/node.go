@@ -22,6 +22,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/names"
 	"github.com/projectcalico/libcalico-go/lib/net"
@@ -35,6 +36,7 @@ import (
 type NodeInterface interface {
 	Create(ctx context.Context, res *libapiv3.Node, opts options.SetOptions) (*libapiv3.Node, error)
 	Update(ctx context.Context, res *libapiv3.Node, opts options.SetOptions) (*libapiv3.Node, error)
+	UpdateStatus(ctx context.Context, res *libapiv3.Node) (*libapiv3.Node, error)
 	Delete(ctx context.Context, name string, opts options.DeleteOptions) (*libapiv3.Node, error)
 	Get(ctx context.Context, name string, opts options.GetOptions) (*libapiv3.Node, error)
 	List(ctx context.Context, opts options.ListOptions) (*libapiv3.NodeList, error)
@@ -81,6 +83,71 @@ func (r nodes) Update(ctx context.Context, res *libapiv3.Node, opts options.SetO
 	return nil, err
 }
 
+// statusUpdater is implemented by the backend clients that can update the Status of a
+// resource without modifying the rest of the stored resource.
+type statusUpdater interface {
+	UpdateStatus(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error)
+}
+
+// UpdateStatus takes the representation of a Node and updates the Status of the stored Node
+// with it.  The Spec and Metadata of the stored Node are not modified, so this may be used by
+// components that only own the Status without overwriting concurrent changes to the Spec.
+// Where the datastore supports it only the Status is written.  Otherwise the Status is
+// copied onto the stored Node, and the copy is retried if the Node is modified concurrently.
+// The ResourceVersion is used (if specified) to detect update conflicts, in which case the
+// conflict is returned.  Returns the stored representation of the Node, and an error, if
+// there is any.
+func (r nodes) UpdateStatus(ctx context.Context, res *libapiv3.Node) (*libapiv3.Node, error) {
+	if res == nil {
+		return nil, errors.ErrorValidation{
+			ErroredFields: []errors.ErroredField{{
+				Name:   "Node",
+				Reason: "no Node supplied",
+			}},
+		}
+	}
+	if err := validator.Validate(&libapiv3.Node{ObjectMeta: res.ObjectMeta, Status: res.Status}); err != nil {
+		return nil, err
+	}
+
+	if su, ok := r.client.backend.(statusUpdater); ok {
+		kvp, err := su.UpdateStatus(ctx, &model.KVPair{
+			Key:      model.ResourceKey{Kind: libapiv3.KindNode, Name: res.Name},
+			Value:    res,
+			Revision: res.ResourceVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := kvp.Value.(*libapiv3.Node)
+		out.ResourceVersion = kvp.Revision
+		return out, nil
+	}
+
+	for i := 0; i < maxApplyRetries; i++ {
+		current, err := r.Get(ctx, res.Name, options.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if res.ResourceVersion != "" {
+			current.ResourceVersion = res.ResourceVersion
+		}
+		current.Status = res.Status
+		out, err := r.client.resources.Update(ctx, options.SetOptions{}, libapiv3.KindNode, current)
+		if _, ok := err.(errors.ErrorResourceUpdateConflict); ok && res.ResourceVersion == "" {
+			log.WithField("name", res.Name).Debug("Conflict updating Node status - retry")
+			continue
+		}
+		if out != nil {
+			return out.(*libapiv3.Node), err
+		}
+		return nil, err
+	}
+	return nil, errors.ErrorResourceUpdateConflict{
+		Identifier: fmt.Sprintf("Node(%s)", res.Name),
+	}
+}
+
 // Delete takes name of the Node and deletes it. Returns an error if one occurs.
 func (r nodes) Delete(ctx context.Context, name string, opts options.DeleteOptions) (*libapiv3.Node, error) {
 	pname, err := names.WorkloadEndpointIdentifiers{Node: name}.CalculateWorkloadEndpointName(true)
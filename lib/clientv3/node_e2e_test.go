@@ -33,6 +33,7 @@ import (
 	"github.com/projectcalico/libcalico-go/lib/backend"
 	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/ipam"
 	cnet "github.com/projectcalico/libcalico-go/lib/net"
 	"github.com/projectcalico/libcalico-go/lib/options"
@@ -656,6 +657,48 @@ var _ = testutils.E2eDatastoreDescribe("Node tests (etcdv3)", testutils.Datastor
 		Entry("Two fully populated NodeSpecs", name1, name2, spec1, spec2, status),
 	)
 
+	Describe("Node status updates", func() {
+		It("should update the status without modifying the spec", func() {
+			c, err := clientv3.New(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			be, err := backend.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+			be.Clean()
+
+			By("Creating a node")
+			node, err := c.Nodes().Create(ctx, &libapiv3.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name1},
+				Spec:       spec1,
+			}, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Updating the status with a modified spec")
+			update := node.DeepCopy()
+			update.Spec = spec2
+			update.Status = status
+			updated, err := c.Nodes().UpdateStatus(ctx, update)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(MatchResourceWithStatus(libapiv3.KindNode, testutils.ExpectNoNamespace, name1, spec1, status))
+
+			By("Getting the node and verifying only the status changed")
+			res, err := c.Nodes().Get(ctx, name1, options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(MatchResourceWithStatus(libapiv3.KindNode, testutils.ExpectNoNamespace, name1, spec1, status))
+
+			By("Updating the status using the previous resource version")
+			_, err = c.Nodes().UpdateStatus(ctx, node)
+			Expect(err).To(HaveOccurred())
+
+			By("Updating the status of a node that does not exist")
+			missing := node.DeepCopy()
+			missing.Name = name2
+			missing.ResourceVersion = ""
+			_, err = c.Nodes().UpdateStatus(ctx, missing)
+			Expect(err).To(BeAssignableToTypeOf(errors.ErrorResourceDoesNotExist{}))
+		})
+	})
+
 	Describe("Node watch functionality", func() {
 		It("should handle watch events for different resource versions and event types", func() {
 			c, err := clientv3.New(config)
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	bapi "github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeNodeResources stores a single Node, and fails an Update with a conflict if the
+// ResourceVersion does not match the stored Node.  If concurrent is set it is called to
+// modify the stored Node before the first Update, as another client would.
+type fakeNodeResources struct {
+	resourceInterface
+	node       *libapiv3.Node
+	revision   int
+	updates    int
+	concurrent func(node *libapiv3.Node)
+}
+
+func (r *fakeNodeResources) Get(ctx context.Context, opts options.GetOptions, kind, ns, name string) (resource, error) {
+	node := r.node.DeepCopy()
+	node.ResourceVersion = strconv.Itoa(r.revision)
+	return node, nil
+}
+
+func (r *fakeNodeResources) Update(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
+	r.updates++
+	if r.concurrent != nil {
+		r.concurrent(r.node)
+		r.concurrent = nil
+		r.revision++
+	}
+	if in.GetObjectMeta().GetResourceVersion() != strconv.Itoa(r.revision) {
+		return nil, cerrors.ErrorResourceUpdateConflict{Identifier: in.GetObjectMeta().GetName()}
+	}
+	r.node = in.(*libapiv3.Node).DeepCopy()
+	r.revision++
+	return r.Get(ctx, options.GetOptions{}, kind, "", r.node.Name)
+}
+
+// fakeStatusUpdaterBackend implements the UpdateStatus method of a backend client, and
+// records the KVPairs it was called with.
+type fakeStatusUpdaterBackend struct {
+	bapi.Client
+	kvps []*model.KVPair
+}
+
+func (b *fakeStatusUpdaterBackend) UpdateStatus(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	b.kvps = append(b.kvps, kvp)
+	return &model.KVPair{Key: kvp.Key, Value: kvp.Value.(*libapiv3.Node).DeepCopy(), Revision: "5"}, nil
+}
+
+var _ = Describe("Nodes UpdateStatus", func() {
+	var res *fakeNodeResources
+	BeforeEach(func() {
+		node := libapiv3.NewNode()
+		node.Name = "node1"
+		node.Spec.IPv4VXLANTunnelAddr = "10.0.0.1"
+		res = &fakeNodeResources{node: node, revision: 1}
+	})
+
+	It("should not overwrite a concurrent change to the spec", func() {
+		res.concurrent = func(node *libapiv3.Node) {
+			node.Spec.IPv4VXLANTunnelAddr = "10.0.0.2"
+		}
+		update := res.node.DeepCopy()
+		update.Status.WireguardPublicKey = "jlkVyQYooZYzI2wFfNhSZez5eWh44yfq1wKVjLvSXgY="
+
+		out, err := nodes{client: client{resources: res}}.UpdateStatus(context.Background(), update)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.updates).To(Equal(2))
+		Expect(out.Spec.IPv4VXLANTunnelAddr).To(Equal("10.0.0.2"))
+		Expect(out.Status.WireguardPublicKey).To(Equal(update.Status.WireguardPublicKey))
+	})
+
+	It("should return the conflict if the resource version is specified", func() {
+		res.concurrent = func(node *libapiv3.Node) {
+			node.Spec.IPv4VXLANTunnelAddr = "10.0.0.2"
+		}
+		update := res.node.DeepCopy()
+		update.ResourceVersion = "1"
+
+		_, err := nodes{client: client{resources: res}}.UpdateStatus(context.Background(), update)
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+		Expect(res.updates).To(Equal(1))
+		Expect(res.node.Spec.IPv4VXLANTunnelAddr).To(Equal("10.0.0.2"))
+	})
+
+	It("should only write the status where the datastore supports it", func() {
+		be := &fakeStatusUpdaterBackend{}
+		update := res.node.DeepCopy()
+		update.Status.WireguardPublicKey = "jlkVyQYooZYzI2wFfNhSZez5eWh44yfq1wKVjLvSXgY="
+
+		out, err := nodes{client: client{resources: res, backend: be}}.UpdateStatus(context.Background(), update)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.updates).To(Equal(0))
+		Expect(be.kvps).To(HaveLen(1))
+		Expect(be.kvps[0].Key).To(Equal(model.ResourceKey{Kind: libapiv3.KindNode, Name: "node1"}))
+		Expect(out.ResourceVersion).To(Equal("5"))
+		Expect(out.Status.WireguardPublicKey).To(Equal(update.Status.WireguardPublicKey))
+	})
+})
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 
+	"golang.org/x/sync/semaphore"
+
 	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/options"
@@ -32,10 +34,15 @@ type ProfileInterface interface {
 	Update(ctx context.Context, res *apiv3.Profile, opts options.SetOptions) (*apiv3.Profile, error)
 	Delete(ctx context.Context, name string, opts options.DeleteOptions) (*apiv3.Profile, error)
 	Get(ctx context.Context, name string, opts options.GetOptions) (*apiv3.Profile, error)
+	GetForEndpoint(ctx context.Context, profileNames []string) ([]*apiv3.Profile, error)
 	List(ctx context.Context, opts options.ListOptions) (*apiv3.ProfileList, error)
 	Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error)
 }
 
+// MaxProfileGetConcurrency is the maximum number of Profiles that GetForEndpoint gets from
+// the datastore in parallel.
+var MaxProfileGetConcurrency = 10
+
 // profiles implements ProfileInterface
 type profiles struct {
 	client client
@@ -110,6 +117,59 @@ func (r profiles) Get(ctx context.Context, name string, opts options.GetOptions)
 	return nil, err
 }
 
+// GetForEndpoint takes the names of the Profiles of an endpoint (for example a
+// WorkloadEndpoint's Spec.Profiles), and returns the corresponding Profile objects in the
+// same order.  The Profiles are fetched in parallel, at most MaxProfileGetConcurrency at a
+// time.  If any of the Profiles cannot be fetched, the Profiles that were fetched are
+// returned along with a MultiError containing the error for each of the others.
+func (r profiles) GetForEndpoint(ctx context.Context, profileNames []string) ([]*apiv3.Profile, error) {
+	concurrency := MaxProfileGetConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type result struct {
+		profile *apiv3.Profile
+		err     error
+	}
+	results := make([]result, len(profileNames))
+	sem := semaphore.NewWeighted(int64(concurrency))
+	for i, name := range profileNames {
+		err := ctx.Err()
+		if err == nil {
+			err = sem.Acquire(ctx, 1)
+		}
+		if err != nil {
+			// The context is finished, so there is no point in starting the other lookups.
+			for j := i; j < len(profileNames); j++ {
+				results[j].err = err
+			}
+			break
+		}
+		go func(i int, name string) {
+			defer sem.Release(1)
+			results[i].profile, results[i].err = r.Get(ctx, name, options.GetOptions{})
+		}(i, name)
+	}
+
+	// Wait for the lookups that were started to finish.
+	_ = sem.Acquire(context.Background(), int64(concurrency))
+
+	profiles := make([]*apiv3.Profile, 0, len(profileNames))
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		profiles = append(profiles, r.profile)
+	}
+	if len(errs) > 0 {
+		return profiles, cerrors.MultiError{Errors: errs}
+	}
+	return profiles, nil
+}
+
 // List returns the list of Profile objects that match the supplied options.
 func (r profiles) List(ctx context.Context, opts options.ListOptions) (*apiv3.ProfileList, error) {
 	res := &apiv3.ProfileList{}
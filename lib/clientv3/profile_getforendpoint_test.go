@@ -0,0 +1,127 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeProfileResources implements the Get method of resourceInterface for a set of Profiles,
+// and records the maximum number of concurrent calls.
+type fakeProfileResources struct {
+	resourceInterface
+	profiles map[string]*apiv3.Profile
+
+	lock          sync.Mutex
+	inFlight      int
+	maxConcurrent int
+}
+
+func (r *fakeProfileResources) Get(ctx context.Context, opts options.GetOptions, kind, ns, name string) (resource, error) {
+	r.lock.Lock()
+	r.inFlight++
+	if r.inFlight > r.maxConcurrent {
+		r.maxConcurrent = r.inFlight
+	}
+	r.lock.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.inFlight--
+	p, ok := r.profiles[name]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	return p.DeepCopy(), nil
+}
+
+var _ = Describe("Profiles GetForEndpoint", func() {
+	var res *fakeProfileResources
+	var profs profiles
+	var savedConcurrency int
+
+	BeforeEach(func() {
+		res = &fakeProfileResources{profiles: map[string]*apiv3.Profile{}}
+		for _, name := range []string{"p1", "p2", "p3", "p4", "p5", "p6"} {
+			p := apiv3.NewProfile()
+			p.Name = name
+			res.profiles[name] = p
+		}
+		profs = profiles{client: client{resources: res}}
+		savedConcurrency = MaxProfileGetConcurrency
+	})
+
+	AfterEach(func() {
+		MaxProfileGetConcurrency = savedConcurrency
+	})
+
+	names := func(ps []*apiv3.Profile) []string {
+		n := []string{}
+		for _, p := range ps {
+			n = append(n, p.Name)
+		}
+		return n
+	}
+
+	It("should return the Profiles in the order requested", func() {
+		ps, err := profs.GetForEndpoint(context.Background(), []string{"p3", "p1", "p2"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(ps)).To(Equal([]string{"p3", "p1", "p2"}))
+	})
+
+	It("should return nothing for an endpoint without Profiles", func() {
+		ps, err := profs.GetForEndpoint(context.Background(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ps).To(BeEmpty())
+	})
+
+	It("should limit the number of concurrent lookups", func() {
+		MaxProfileGetConcurrency = 2
+		ps, err := profs.GetForEndpoint(context.Background(), []string{"p1", "p2", "p3", "p4", "p5", "p6"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ps).To(HaveLen(6))
+		Expect(res.maxConcurrent).To(BeNumerically("<=", 2))
+		Expect(res.maxConcurrent).To(BeNumerically(">", 1))
+	})
+
+	It("should return the fetched Profiles and an error for each missing Profile", func() {
+		ps, err := profs.GetForEndpoint(context.Background(), []string{"p1", "missing1", "p2", "missing2"})
+		Expect(names(ps)).To(Equal([]string{"p1", "p2"}))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.MultiError{}))
+		Expect(err.(cerrors.MultiError).Errors).To(Equal([]error{
+			cerrors.ErrorResourceDoesNotExist{Identifier: "missing1"},
+			cerrors.ErrorResourceDoesNotExist{Identifier: "missing2"},
+		}))
+	})
+
+	It("should return an error for each Profile when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ps, err := profs.GetForEndpoint(ctx, []string{"p1", "p2"})
+		Expect(ps).To(BeEmpty())
+		Expect(err.(cerrors.MultiError).Errors).To(Equal([]error{context.Canceled, context.Canceled}))
+	})
+})
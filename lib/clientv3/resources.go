@@ -211,9 +211,15 @@ func (c *resources) List(ctx context.Context, opts options.ListOptions, kind, li
 		Name:      opts.Name,
 		Namespace: opts.Namespace,
 		Prefix:    opts.Prefix,
+		Node:      opts.Node,
 	}
 
-	// Query the backend.
+	// Query the backend, limiting the time allowed if a timeout was requested.
+	if opts.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *opts.Timeout)
+		defer cancel()
+	}
 	kvps, err := c.backend.List(ctx, list, opts.ResourceVersion)
 	if err != nil {
 		return err
@@ -249,9 +255,16 @@ func (c *resources) Watch(ctx context.Context, opts options.ListOptions, kind st
 	}
 
 	// Create the backend watcher.  We need to process the results to add revision data etc.
-	ctx, cancel := context.WithCancel(ctx)
+	// If a timeout was requested, the watch is terminated when it expires.
+	var cancel context.CancelFunc
+	if opts.Timeout != nil {
+		ctx, cancel = context.WithTimeout(ctx, *opts.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	backend, err := c.backend.Watch(ctx, list, opts.ResourceVersion)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 	w := &watcher{
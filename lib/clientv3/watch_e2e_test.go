@@ -187,3 +187,54 @@ var _ = testutils.E2eDatastoreDescribe("Additional watch tests", testutils.Datas
 		})
 	})
 })
+
+var _ = testutils.E2eDatastoreDescribe("List and watch timeouts", testutils.DatastoreEtcdV3, func(config apiconfig.CalicoAPIConfig) {
+
+	ctx := context.Background()
+
+	It("should time out a large list and terminate a watch when the timeout expires", func() {
+		c, err := New(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		be, err := backend.NewClient(config)
+		Expect(err).NotTo(HaveOccurred())
+		be.Clean()
+
+		By("Creating 1000 resources")
+		for ii := 1; ii <= 1000; ii++ {
+			_, err := c.BGPPeers().Create(ctx, &apiv3.BGPPeer{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("peer-%08d", ii)},
+				Spec: apiv3.BGPPeerSpec{
+					PeerIP:   "1.2.3.4",
+					ASNumber: numorstring.ASNumber(ii),
+				},
+			}, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		By("Listing with a timeout that is too short")
+		timeout := time.Nanosecond
+		_, err = c.BGPPeers().List(ctx, options.ListOptions{Timeout: &timeout})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("context deadline exceeded"))
+
+		By("Listing with a generous timeout")
+		timeout = time.Minute
+		outList, err := c.BGPPeers().List(ctx, options.ListOptions{Timeout: &timeout})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(outList.Items).To(HaveLen(1000))
+
+		By("Watching with a timeout")
+		timeout = 500 * time.Millisecond
+		w, err := c.BGPPeers().Watch(ctx, options.ListOptions{ResourceVersion: outList.ResourceVersion, Timeout: &timeout})
+		Expect(err).NotTo(HaveOccurred())
+		defer w.Stop()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range w.ResultChan() {
+			}
+		}()
+		Eventually(done, "5s").Should(BeClosed())
+	})
+})
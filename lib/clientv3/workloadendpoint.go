@@ -16,10 +16,18 @@ package clientv3
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	jsonpatch "github.com/evanphx/json-patch"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
 	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/apiconfig"
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/names"
 	"github.com/projectcalico/libcalico-go/lib/options"
@@ -33,8 +41,39 @@ type WorkloadEndpointInterface interface {
 	Update(ctx context.Context, res *libapiv3.WorkloadEndpoint, opts options.SetOptions) (*libapiv3.WorkloadEndpoint, error)
 	Delete(ctx context.Context, namespace, name string, opts options.DeleteOptions) (*libapiv3.WorkloadEndpoint, error)
 	Get(ctx context.Context, namespace, name string, opts options.GetOptions) (*libapiv3.WorkloadEndpoint, error)
+	Patch(ctx context.Context, namespace, name string, pt types.PatchType, data []byte) (*libapiv3.WorkloadEndpoint, error)
+	GetByPod(ctx context.Context, namespace, podName string) (*libapiv3.WorkloadEndpoint, error)
 	List(ctx context.Context, opts options.ListOptions) (*libapiv3.WorkloadEndpointList, error)
+	ListByNode(ctx context.Context, nodeName string) (*libapiv3.WorkloadEndpointList, error)
+	DeleteAllForNode(ctx context.Context, nodeName string) (int, error)
 	Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error)
+	WatchWorkloadEndpoints(ctx context.Context, opts options.ListOptions) (<-chan WorkloadEndpointEvent, error)
+	StatusClient
+}
+
+// WorkloadEndpointEvent is a single event returned by WatchWorkloadEndpoints.
+type WorkloadEndpointEvent struct {
+	Type watch.EventType
+
+	// Object is:
+	// * If Type is Added or Modified: the new state of the WorkloadEndpoint.
+	// * If Type is Deleted: the state of the WorkloadEndpoint before it was deleted.
+	// * If Type is Error: nil
+	Object *libapiv3.WorkloadEndpoint
+
+	// The error, if Type is Error.
+	Error error
+}
+
+// StatusClient has methods to read and write the Status of WorkloadEndpoint resources
+// independently of the Spec.
+//
+// In the Kubernetes datastore the WorkloadEndpoints are derived from the pods, which have
+// nowhere to store the Status.  GetStatus always returns an empty Status there, and
+// UpdateStatus returns an ErrorOperationNotSupported.
+type StatusClient interface {
+	GetStatus(ctx context.Context, namespace, name string, opts options.GetOptions) (*libapiv3.WorkloadEndpointStatus, error)
+	UpdateStatus(ctx context.Context, res *libapiv3.WorkloadEndpoint, opts options.SetOptions) (*libapiv3.WorkloadEndpoint, error)
 }
 
 // workloadEndpoints implements WorkloadEndpointInterface
@@ -105,21 +144,365 @@ func (r workloadEndpoints) Get(ctx context.Context, namespace, name string, opts
 	return nil, err
 }
 
+// Patch applies a JSON merge patch or a strategic merge patch to the WorkloadEndpoint with
+// the supplied namespace and name, and returns the stored representation of the patched
+// WorkloadEndpoint.  The patch is applied to the current WorkloadEndpoint and the result
+// is written with an update that is conditional on the WorkloadEndpoint not having changed
+// in the meantime; if it has, the patch is reapplied to the new data.  If the patch sets
+// the resource version then that version is used as the precondition instead, and a
+// conflict is returned rather than retried.
+func (r workloadEndpoints) Patch(ctx context.Context, namespace, name string, pt types.PatchType, data []byte) (*libapiv3.WorkloadEndpoint, error) {
+	id := fmt.Sprintf("WorkloadEndpoint(%s/%s)", namespace, name)
+	if pt != types.MergePatchType && pt != types.StrategicMergePatchType {
+		return nil, errors.ErrorOperationNotSupported{
+			Operation:  "Patch",
+			Identifier: id,
+			Reason:     fmt.Sprintf("unsupported patch type %s", pt),
+		}
+	}
+
+	var err error
+	for i := 0; i < maxApplyRetries; i++ {
+		var current, patched *libapiv3.WorkloadEndpoint
+		if current, err = r.Get(ctx, namespace, name, options.GetOptions{}); err != nil {
+			return nil, err
+		}
+		if patched, err = patchWorkloadEndpoint(current, pt, data); err != nil {
+			return nil, err
+		}
+		if patched.Namespace != namespace || patched.Name != name {
+			return nil, errors.ErrorValidation{
+				ErroredFields: []errors.ErroredField{{
+					Name:   "Metadata",
+					Value:  patched.Namespace + "/" + patched.Name,
+					Reason: "the patch must not change the namespace or name of the WorkloadEndpoint",
+				}},
+			}
+		}
+
+		var out *libapiv3.WorkloadEndpoint
+		out, err = r.Update(ctx, patched, options.SetOptions{})
+		if _, ok := err.(errors.ErrorResourceUpdateConflict); ok && patched.ResourceVersion == current.ResourceVersion {
+			log.WithField("WorkloadEndpoint", id).Debug("Update conflict patching WorkloadEndpoint - retry")
+			continue
+		}
+		return out, err
+	}
+
+	log.WithError(err).WithField("WorkloadEndpoint", id).Info("Too many conflict failures attempting to patch WorkloadEndpoint")
+	return nil, err
+}
+
+// patchWorkloadEndpoint returns a copy of the WorkloadEndpoint with the patch applied.
+func patchWorkloadEndpoint(wep *libapiv3.WorkloadEndpoint, pt types.PatchType, data []byte) (*libapiv3.WorkloadEndpoint, error) {
+	original, err := json.Marshal(wep)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch pt {
+	case types.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, data)
+	case types.StrategicMergePatchType:
+		patched, err = strategicpatch.StrategicMergePatch(original, data, libapiv3.WorkloadEndpoint{})
+	}
+	if err != nil {
+		return nil, errors.ErrorValidation{
+			ErroredFields: []errors.ErroredField{{Name: "Patch", Reason: fmt.Sprintf("unable to apply %s: %v", pt, err)}},
+		}
+	}
+
+	result := &libapiv3.WorkloadEndpoint{}
+	if err := json.Unmarshal(patched, result); err != nil {
+		return nil, errors.ErrorValidation{
+			ErroredFields: []errors.ErroredField{{Name: "Patch", Reason: fmt.Sprintf("patched WorkloadEndpoint is invalid: %v", err)}},
+		}
+	}
+	return result, nil
+}
+
+// GetByPod returns the WorkloadEndpoint of the Kubernetes pod with the supplied namespace and
+// name.  This may be used when the node or endpoint name needed to calculate the name of the
+// WorkloadEndpoint is not known.  Returns an ErrorResourceDoesNotExist if the pod has no
+// WorkloadEndpoint, and an ErrorMultipleResources if it has more than one (e.g. because it has
+// multiple network interfaces).
+func (r workloadEndpoints) GetByPod(ctx context.Context, namespace, podName string) (*libapiv3.WorkloadEndpoint, error) {
+	list, err := r.List(ctx, options.ListOptions{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprintf("WorkloadEndpoint(%s/pod=%s)", namespace, podName)
+	var matches []*libapiv3.WorkloadEndpoint
+	for i := range list.Items {
+		wep := &list.Items[i]
+		if wep.Spec.Orchestrator == apiv3.OrchestratorKubernetes && wep.Spec.Pod == podName {
+			matches = append(matches, wep)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, errors.ErrorResourceDoesNotExist{
+			Identifier: id,
+			Err:        fmt.Errorf("no WorkloadEndpoint found for pod"),
+		}
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, wep := range matches {
+		names[i] = wep.Name
+	}
+	return nil, errors.ErrorMultipleResources{Identifier: id, Names: names}
+}
+
 // List returns the list of WorkloadEndpoint objects that match the supplied options.
 func (r workloadEndpoints) List(ctx context.Context, opts options.ListOptions) (*libapiv3.WorkloadEndpointList, error) {
 	res := &libapiv3.WorkloadEndpointList{}
 	if err := r.client.resources.List(ctx, opts, libapiv3.KindWorkloadEndpoint, libapiv3.KindWorkloadEndpointList, res); err != nil {
 		return nil, err
 	}
+
+	// Only the Kubernetes datastore filters by node, so filter the results here.
+	if opts.Node != "" {
+		items := res.Items[:0]
+		for _, wep := range res.Items {
+			if wep.Spec.Node == opts.Node {
+				items = append(items, wep)
+			}
+		}
+		res.Items = items
+	}
 	return res, nil
 }
 
+// ListByNode returns the list of WorkloadEndpoint objects on the supplied node, in all
+// namespaces.  In the Kubernetes datastore only the pods scheduled to the node are queried.
+func (r workloadEndpoints) ListByNode(ctx context.Context, nodeName string) (*libapiv3.WorkloadEndpointList, error) {
+	return r.List(ctx, options.ListOptions{Node: nodeName})
+}
+
+// deleteBatchSize is the maximum number of WorkloadEndpoints deleted in a single datastore
+// transaction by DeleteAllForNode, to limit the size of each transaction.
+const deleteBatchSize = 100
+
+// batchDeleter is implemented by the backend clients that can delete multiple objects in
+// a single transaction.
+type batchDeleter interface {
+	DeleteKVPs(ctx context.Context, kvps []*model.KVPair) error
+}
+
+// DeleteAllForNode deletes all of the WorkloadEndpoints on the supplied node, e.g. when the
+// node is removed from the cluster, and returns the number deleted.  Where the datastore
+// supports it the WorkloadEndpoints are deleted in transactions of up to deleteBatchSize
+// WorkloadEndpoints.  If a transaction fails, or the datastore does not support them, each
+// WorkloadEndpoint is deleted individually: a conflict is retried with the current
+// WorkloadEndpoint, and any other failure does not stop the remaining deletes.  The errors
+// are returned in a MultiError, with the number that were deleted.
+func (r workloadEndpoints) DeleteAllForNode(ctx context.Context, nodeName string) (int, error) {
+	list, err := r.ListByNode(ctx, nodeName)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	var errs []error
+	bd, batched := r.client.backend.(batchDeleter)
+	for start := 0; start < len(list.Items); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(list.Items) {
+			end = len(list.Items)
+		}
+		batch := list.Items[start:end]
+
+		if batched {
+			kvps := make([]*model.KVPair, 0, len(batch))
+			for _, wep := range batch {
+				kvps = append(kvps, &model.KVPair{
+					Key: model.ResourceKey{
+						Kind:      libapiv3.KindWorkloadEndpoint,
+						Name:      wep.Name,
+						Namespace: wep.Namespace,
+					},
+					Revision: wep.ResourceVersion,
+				})
+			}
+			err := bd.DeleteKVPs(ctx, kvps)
+			if err == nil {
+				deleted += len(kvps)
+				continue
+			}
+			log.WithError(err).Info("Failed to delete a batch of WorkloadEndpoints, deleting them individually")
+		}
+
+		for i := range batch {
+			if ok, err := r.deleteFromNode(ctx, nodeName, &batch[i]); err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				deleted++
+			}
+		}
+	}
+	log.WithFields(log.Fields{"node": nodeName, "count": deleted}).Info("Deleted the WorkloadEndpoints on the node")
+	if len(errs) > 0 {
+		return deleted, errors.MultiError{Errors: errs}
+	}
+	return deleted, nil
+}
+
+// deleteFromNode deletes the supplied WorkloadEndpoint if it is still on the node, and
+// returns whether it was deleted.  If the WorkloadEndpoint has been modified the delete is
+// retried with the current WorkloadEndpoint.
+func (r workloadEndpoints) deleteFromNode(ctx context.Context, nodeName string, wep *libapiv3.WorkloadEndpoint) (bool, error) {
+	for i := 0; i < maxApplyRetries; i++ {
+		_, err := r.Delete(ctx, wep.Namespace, wep.Name, options.DeleteOptions{ResourceVersion: wep.ResourceVersion})
+		switch err.(type) {
+		case nil:
+			return true, nil
+		case errors.ErrorResourceDoesNotExist:
+			// The WorkloadEndpoint has already been deleted.
+			return false, nil
+		case errors.ErrorResourceUpdateConflict:
+			log.WithField("name", wep.Name).Debug("Conflict deleting WorkloadEndpoint - retry")
+		default:
+			return false, err
+		}
+
+		if wep, err = r.Get(ctx, wep.Namespace, wep.Name, options.GetOptions{}); err != nil {
+			if _, ok := err.(errors.ErrorResourceDoesNotExist); ok {
+				return false, nil
+			}
+			return false, err
+		}
+		if wep.Spec.Node != nodeName {
+			// The WorkloadEndpoint is no longer on the node.
+			return false, nil
+		}
+	}
+	return false, errors.ErrorResourceUpdateConflict{
+		Identifier: fmt.Sprintf("WorkloadEndpoint(%s/%s)", wep.Namespace, wep.Name),
+	}
+}
+
 // Watch returns a watch.Interface that watches the NetworkPolicies that match the
 // supplied options.
 func (r workloadEndpoints) Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error) {
 	return r.client.resources.Watch(ctx, opts, libapiv3.KindWorkloadEndpoint, nil)
 }
 
+// WatchWorkloadEndpoints watches the WorkloadEndpoints that match the supplied options, and
+// returns a channel of WorkloadEndpointEvents.  The channel is closed, and the underlying
+// watch stopped, when the context is cancelled or the watch terminates.
+func (r workloadEndpoints) WatchWorkloadEndpoints(ctx context.Context, opts options.ListOptions) (<-chan WorkloadEndpointEvent, error) {
+	w, err := r.Watch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan WorkloadEndpointEvent, watch.DefaultChanSize)
+	go func() {
+		defer close(events)
+		defer w.Stop()
+		for {
+			var e watch.Event
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok = <-w.ResultChan():
+				if !ok {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case events <- toWorkloadEndpointEvent(e):
+			}
+		}
+	}()
+	return events, nil
+}
+
+// toWorkloadEndpointEvent converts a watch.Event into a WorkloadEndpointEvent.  An event
+// that does not contain a WorkloadEndpoint is converted to an Error event.
+func toWorkloadEndpointEvent(e watch.Event) WorkloadEndpointEvent {
+	obj := e.Object
+	if e.Type == watch.Deleted {
+		obj = e.Previous
+	}
+	if obj == nil {
+		return WorkloadEndpointEvent{Type: e.Type, Error: e.Error}
+	}
+	wep, ok := obj.(*libapiv3.WorkloadEndpoint)
+	if !ok {
+		return WorkloadEndpointEvent{
+			Type:  watch.Error,
+			Error: fmt.Errorf("unexpected object type in WorkloadEndpoint watch event: %T", obj),
+		}
+	}
+	return WorkloadEndpointEvent{Type: e.Type, Object: wep, Error: e.Error}
+}
+
+// GetStatus takes name of the WorkloadEndpoint, and returns the Status of the corresponding
+// WorkloadEndpoint object, and an error if there is any.  An empty Status is returned if no
+// status has been reported for the WorkloadEndpoint.
+func (r workloadEndpoints) GetStatus(ctx context.Context, namespace, name string, opts options.GetOptions) (*libapiv3.WorkloadEndpointStatus, error) {
+	wep, err := r.Get(ctx, namespace, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if wep.Status == nil {
+		return &libapiv3.WorkloadEndpointStatus{}, nil
+	}
+	return wep.Status, nil
+}
+
+// UpdateStatus takes the representation of a WorkloadEndpoint and updates the Status of the
+// stored WorkloadEndpoint with it.  The Spec and Metadata of the stored WorkloadEndpoint are not
+// modified, although the ResourceVersion is used (if specified) to detect update conflicts.
+// Returns the stored representation of the WorkloadEndpoint, and an error, if there is any.
+//
+// In the Kubernetes datastore the WorkloadEndpoints are derived from the pods and the status
+// cannot be stored, so an ErrorOperationNotSupported is returned.
+func (r workloadEndpoints) UpdateStatus(ctx context.Context, res *libapiv3.WorkloadEndpoint, opts options.SetOptions) (*libapiv3.WorkloadEndpoint, error) {
+	if r.client.config.Spec.DatastoreType == apiconfig.Kubernetes {
+		return nil, errors.ErrorOperationNotSupported{
+			Operation:  "UpdateStatus",
+			Identifier: "WorkloadEndpoint",
+			Reason:     "the status of a WorkloadEndpoint cannot be stored in the Kubernetes datastore",
+		}
+	}
+	if res == nil {
+		return nil, errors.ErrorValidation{
+			ErroredFields: []errors.ErroredField{{
+				Name:   "WorkloadEndpoint",
+				Reason: "no WorkloadEndpoint supplied",
+			}},
+		}
+	}
+	current, err := r.Get(ctx, res.Namespace, res.Name, options.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if res.ResourceVersion != "" {
+		current.ResourceVersion = res.ResourceVersion
+	}
+	current.Status = res.Status
+	if err := validator.Validate(current); err != nil {
+		return nil, err
+	}
+	r.updateLabelsForStorage(current)
+	out, err := r.client.resources.Update(ctx, opts, libapiv3.KindWorkloadEndpoint, current)
+	if out != nil {
+		return out.(*libapiv3.WorkloadEndpoint), err
+	}
+	return nil, err
+}
+
 // assignOrValidateName either assigns the name calculated from the Spec fields, or validates
 // the name against the spec fields.
 func (r workloadEndpoints) assignOrValidateName(res *libapiv3.WorkloadEndpoint) error {
@@ -0,0 +1,195 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	bapi "github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeWEPDeleteResources extends fakeWEPListResources to record the WorkloadEndpoints
+// deleted individually.  The delete of a WorkloadEndpoint in conflicts returns that many
+// conflicts before it succeeds, and the delete of one in failures always fails.
+type fakeWEPDeleteResources struct {
+	fakeWEPListResources
+	deleted   []string
+	conflicts map[string]int
+	failures  map[string]error
+	moved     map[string]string
+}
+
+func (r *fakeWEPDeleteResources) Delete(ctx context.Context, opts options.DeleteOptions, kind, ns, name string) (resource, error) {
+	if name == "missing" {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	if err := r.failures[name]; err != nil {
+		return nil, err
+	}
+	if r.conflicts[name] > 0 {
+		r.conflicts[name]--
+		return nil, cerrors.ErrorResourceUpdateConflict{Identifier: name}
+	}
+	r.deleted = append(r.deleted, ns+"/"+name)
+	return nil, nil
+}
+
+func (r *fakeWEPDeleteResources) Get(ctx context.Context, opts options.GetOptions, kind, ns, name string) (resource, error) {
+	for _, wep := range r.weps {
+		if wep.Namespace == ns && wep.Name == name {
+			wep = wep.DeepCopy()
+			wep.ResourceVersion = "2"
+			if node, ok := r.moved[name]; ok {
+				wep.Spec.Node = node
+			}
+			return wep, nil
+		}
+	}
+	return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+}
+
+// fakeBatchDeleterBackend implements the DeleteKVPs method of a backend client, and records
+// each batch of keys deleted.  If err is set, the batch with index failBatch fails with it.
+type fakeBatchDeleterBackend struct {
+	bapi.Client
+	batches   [][]model.Key
+	calls     int
+	err       error
+	failBatch int
+}
+
+func (b *fakeBatchDeleterBackend) DeleteKVPs(ctx context.Context, kvps []*model.KVPair) error {
+	b.calls++
+	if b.err != nil && b.calls-1 == b.failBatch {
+		return b.err
+	}
+	keys := []model.Key{}
+	for _, kvp := range kvps {
+		keys = append(keys, kvp.Key)
+	}
+	b.batches = append(b.batches, keys)
+	return nil
+}
+
+var _ = Describe("WorkloadEndpoints DeleteAllForNode", func() {
+	newWEPs := func(node string, count int) []*libapiv3.WorkloadEndpoint {
+		weps := []*libapiv3.WorkloadEndpoint{}
+		for i := 0; i < count; i++ {
+			wep := libapiv3.NewWorkloadEndpoint()
+			wep.Namespace = "ns1"
+			wep.Name = fmt.Sprintf("%s-k8s-pod%d-eth0", node, i)
+			wep.Spec.Node = node
+			weps = append(weps, wep)
+		}
+		return weps
+	}
+
+	var res *fakeWEPDeleteResources
+	BeforeEach(func() {
+		res = &fakeWEPDeleteResources{}
+		res.weps = append(newWEPs("node1", 250), newWEPs("node2", 3)...)
+	})
+
+	It("should delete the WorkloadEndpoints on the node in batches", func() {
+		be := &fakeBatchDeleterBackend{}
+		weps := workloadEndpoints{client: client{resources: res, backend: be}}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(250))
+		Expect(be.batches).To(HaveLen(3))
+		Expect(be.batches[0]).To(HaveLen(100))
+		Expect(be.batches[1]).To(HaveLen(100))
+		Expect(be.batches[2]).To(HaveLen(50))
+		Expect(be.batches[2][49]).To(Equal(model.ResourceKey{
+			Kind:      libapiv3.KindWorkloadEndpoint,
+			Namespace: "ns1",
+			Name:      "node1-k8s-pod249-eth0",
+		}))
+		Expect(res.deleted).To(BeEmpty())
+	})
+
+	It("should delete the WorkloadEndpoints of a failed batch individually", func() {
+		be := &fakeBatchDeleterBackend{err: cerrors.ErrorResourceUpdateConflict{}}
+		weps := workloadEndpoints{client: client{resources: res, backend: be}}
+		res.weps = newWEPs("node1", 150)
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(150))
+		Expect(be.batches).To(HaveLen(1))
+		Expect(be.batches[0]).To(HaveLen(50))
+		Expect(res.deleted).To(HaveLen(100))
+	})
+
+	It("should return the number deleted and continue past the failures in a later batch", func() {
+		be := &fakeBatchDeleterBackend{err: cerrors.ErrorResourceUpdateConflict{}, failBatch: 1}
+		weps := workloadEndpoints{client: client{resources: res, backend: be}}
+		failure := cerrors.ErrorDatastoreError{Err: fmt.Errorf("connection refused")}
+		res.failures = map[string]error{"node1-k8s-pod150-eth0": failure}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node1")
+		Expect(err).To(Equal(cerrors.MultiError{Errors: []error{failure}}))
+		Expect(deleted).To(Equal(249))
+		Expect(be.batches).To(HaveLen(2))
+		Expect(res.deleted).To(HaveLen(99))
+		Expect(res.deleted).NotTo(ContainElement("ns1/node1-k8s-pod150-eth0"))
+	})
+
+	It("should retry a conflict with the current WorkloadEndpoint", func() {
+		res.weps = newWEPs("node1", 3)
+		res.conflicts = map[string]int{"node1-k8s-pod0-eth0": 2, "node1-k8s-pod1-eth0": 1}
+		res.moved = map[string]string{"node1-k8s-pod1-eth0": "node2"}
+		weps := workloadEndpoints{client: client{resources: res}}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(2))
+		Expect(res.deleted).To(Equal([]string{"ns1/node1-k8s-pod0-eth0", "ns1/node1-k8s-pod2-eth0"}))
+	})
+
+	It("should report a WorkloadEndpoint that conflicts on every retry", func() {
+		res.weps = newWEPs("node1", 2)
+		res.conflicts = map[string]int{"node1-k8s-pod0-eth0": maxApplyRetries}
+		weps := workloadEndpoints{client: client{resources: res}}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node1")
+		Expect(deleted).To(Equal(1))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.MultiError{}))
+		Expect(err.(cerrors.MultiError).Errors[0]).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+	})
+
+	It("should delete each WorkloadEndpoint if the datastore does not support batches", func() {
+		res.weps = append(newWEPs("node2", 2), res.weps[0])
+		res.weps[0].Name = "missing"
+		weps := workloadEndpoints{client: client{resources: res}}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(1))
+		Expect(res.deleted).To(Equal([]string{"ns1/node2-k8s-pod1-eth0"}))
+	})
+
+	It("should not delete anything if there are no WorkloadEndpoints on the node", func() {
+		be := &fakeBatchDeleterBackend{}
+		weps := workloadEndpoints{client: client{resources: res, backend: be}}
+		deleted, err := weps.DeleteAllForNode(context.Background(), "node3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted).To(Equal(0))
+		Expect(be.batches).To(BeEmpty())
+	})
+})
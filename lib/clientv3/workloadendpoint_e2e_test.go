@@ -15,6 +15,7 @@
 package clientv3_test
 
 import (
+	"fmt"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -30,6 +31,7 @@ import (
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
 	"github.com/projectcalico/libcalico-go/lib/backend"
 	"github.com/projectcalico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/options"
 	"github.com/projectcalico/libcalico-go/lib/testutils"
 	"github.com/projectcalico/libcalico-go/lib/watch"
@@ -480,6 +482,70 @@ var _ = testutils.E2eDatastoreDescribe("WorkloadEndpoint tests", testutils.Datas
 			})
 			testWatcher4.Stop()
 		})
+
+		It("should return typed events from WatchWorkloadEndpoints", func() {
+			c, err := clientv3.New(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			be, err := backend.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+			be.Clean()
+
+			By("Starting a typed watcher")
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			events, err := c.WorkloadEndpoints().WatchWorkloadEndpoints(watchCtx, options.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Configuring a WorkloadEndpoint namespace1/name1/spec1_1")
+			outRes1, err := c.WorkloadEndpoints().Create(
+				ctx,
+				&libapiv3.WorkloadEndpoint{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace1, Name: name1},
+					Spec:       spec1_1,
+				},
+				options.SetOptions{},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Modifying the WorkloadEndpoint")
+			outRes2, err := c.WorkloadEndpoints().Update(
+				ctx,
+				&libapiv3.WorkloadEndpoint{
+					ObjectMeta: outRes1.ObjectMeta,
+					Spec:       spec1_2,
+				},
+				options.SetOptions{},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Deleting the WorkloadEndpoint")
+			_, err = c.WorkloadEndpoints().Delete(ctx, namespace1, name1, options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking for the added, modified and deleted events")
+			expected := []struct {
+				eventType watch.EventType
+				object    *libapiv3.WorkloadEndpoint
+			}{
+				{watch.Added, outRes1},
+				{watch.Modified, outRes2},
+				{watch.Deleted, outRes2},
+			}
+			for _, e := range expected {
+				var event clientv3.WorkloadEndpointEvent
+				Eventually(events).Should(Receive(&event))
+				Expect(event.Error).NotTo(HaveOccurred())
+				Expect(event.Type).To(Equal(e.eventType))
+				Expect(event.Object.Namespace).To(Equal(e.object.Namespace))
+				Expect(event.Object.Name).To(Equal(e.object.Name))
+				Expect(event.Object.Spec).To(Equal(e.object.Spec))
+			}
+
+			By("Cancelling the context and checking the channel is closed")
+			cancel()
+			Eventually(events).Should(BeClosed())
+		})
 	})
 
 	Describe("WorkloadEndpoint prefix list", func() {
@@ -662,4 +728,105 @@ var _ = testutils.E2eDatastoreDescribe("WorkloadEndpoint tests", testutils.Datas
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("WorkloadEndpoint lookup by pod", func() {
+		podName := "abcdef"
+		weps := []*libapiv3.WorkloadEndpoint{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: namespace1, Name: name1}, Spec: spec1_1},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: namespace1, Name: "node--1-k8s-abcdef-net1"}, Spec: libapiv3.WorkloadEndpointSpec{
+				Node:          "node-1",
+				Orchestrator:  "k8s",
+				Pod:           podName,
+				ContainerID:   "a12345a",
+				Endpoint:      "net1",
+				InterfaceName: "cali09124",
+			}},
+		}
+
+		DescribeTable("should return the unique WorkloadEndpoint of the pod",
+			func(numWEPs int) {
+				c, err := clientv3.New(config)
+				Expect(err).NotTo(HaveOccurred())
+
+				be, err := backend.NewClient(config)
+				Expect(err).NotTo(HaveOccurred())
+				be.Clean()
+
+				By("Creating WorkloadEndpoints that do not belong to the pod")
+				_, err = c.WorkloadEndpoints().Create(ctx, &libapiv3.WorkloadEndpoint{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace1, Name: name2},
+					Spec:       spec2_1,
+				}, options.SetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = c.WorkloadEndpoints().Create(ctx, &libapiv3.WorkloadEndpoint{
+					ObjectMeta: metav1.ObjectMeta{Namespace: namespace2, Name: name1},
+					Spec:       spec1_1,
+				}, options.SetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				By(fmt.Sprintf("Creating %d WorkloadEndpoint(s) for the pod", numWEPs))
+				for _, wep := range weps[:numWEPs] {
+					_, err = c.WorkloadEndpoints().Create(ctx, wep.DeepCopy(), options.SetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				By("Getting the WorkloadEndpoint by pod")
+				wep, err := c.WorkloadEndpoints().GetByPod(ctx, namespace1, podName)
+				switch numWEPs {
+				case 0:
+					Expect(err).To(BeAssignableToTypeOf(errors.ErrorResourceDoesNotExist{}))
+				case 1:
+					Expect(err).NotTo(HaveOccurred())
+					Expect(wep).To(MatchResource(libapiv3.KindWorkloadEndpoint, namespace1, name1, spec1_1))
+				default:
+					Expect(err).To(BeAssignableToTypeOf(errors.ErrorMultipleResources{}))
+					Expect(err.(errors.ErrorMultipleResources).Names).To(ConsistOf(name1, "node--1-k8s-abcdef-net1"))
+				}
+			},
+			Entry("no matching WorkloadEndpoints", 0),
+			Entry("one matching WorkloadEndpoint", 1),
+			Entry("two matching WorkloadEndpoints", 2),
+		)
+	})
+
+	Describe("WorkloadEndpoint status functionality", func() {
+		It("should update the status independently of the spec", func() {
+			c, err := clientv3.New(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			be, err := backend.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+			be.Clean()
+
+			By("Creating a WorkloadEndpoint")
+			wep, err := c.WorkloadEndpoints().Create(ctx, &libapiv3.WorkloadEndpoint{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace1, Name: name1},
+				Spec:       spec1_1,
+			}, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Updating the status with a modified spec")
+			status := &libapiv3.WorkloadEndpointStatus{
+				ActiveInterfaceName: "cali09123",
+				ActiveIPNetworks:    []string{"10.0.0.1/32"},
+				LastUpdated:         metav1.Now().Rfc3339Copy(),
+			}
+			update := wep.DeepCopy()
+			update.Spec.InterfaceName = "caliabcde"
+			update.Status = status
+			updated, err := c.WorkloadEndpoints().UpdateStatus(ctx, update, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Spec).To(Equal(spec1_1))
+			Expect(updated.Status).To(Equal(status))
+
+			By("Getting the status")
+			outStatus, err := c.WorkloadEndpoints().GetStatus(ctx, namespace1, name1, options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outStatus).To(Equal(status))
+
+			By("Updating the status using the previous resource version")
+			_, err = c.WorkloadEndpoints().UpdateStatus(ctx, wep, options.SetOptions{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
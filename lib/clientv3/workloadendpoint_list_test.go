@@ -0,0 +1,74 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeWEPListResources implements the List method of resourceInterface, returning all of the
+// WorkloadEndpoints regardless of the node, as the etcdv3 datastore does.
+type fakeWEPListResources struct {
+	resourceInterface
+	weps []*libapiv3.WorkloadEndpoint
+	opts options.ListOptions
+}
+
+func (r *fakeWEPListResources) List(ctx context.Context, opts options.ListOptions, kind, listKind string, inout resourceList) error {
+	r.opts = opts
+	objs := []runtime.Object{}
+	for _, wep := range r.weps {
+		objs = append(objs, wep.DeepCopy())
+	}
+	return meta.SetList(inout, objs)
+}
+
+var _ = Describe("WorkloadEndpoints ListByNode", func() {
+	newWEP := func(namespace, name, node string) *libapiv3.WorkloadEndpoint {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Namespace = namespace
+		wep.Name = name
+		wep.Spec.Node = node
+		return wep
+	}
+
+	It("should only return the WorkloadEndpoints on the node", func() {
+		res := &fakeWEPListResources{weps: []*libapiv3.WorkloadEndpoint{
+			newWEP("ns1", "node1-k8s-pod1-eth0", "node1"),
+			newWEP("ns1", "node2-k8s-pod2-eth0", "node2"),
+			newWEP("ns2", "node1-k8s-pod3-eth0", "node1"),
+			newWEP("ns2", "node10-k8s-pod4-eth0", "node10"),
+		}}
+		weps := workloadEndpoints{client: client{resources: res}}
+
+		list, err := weps.ListByNode(context.Background(), "node1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.opts).To(Equal(options.ListOptions{Node: "node1"}))
+		names := []string{}
+		for _, wep := range list.Items {
+			names = append(names, wep.Namespace+"/"+wep.Name)
+		}
+		Expect(names).To(Equal([]string{"ns1/node1-k8s-pod1-eth0", "ns2/node1-k8s-pod3-eth0"}))
+	})
+})
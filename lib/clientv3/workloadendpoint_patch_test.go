@@ -0,0 +1,146 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+// fakeWEPResources implements the Get and Update methods of resourceInterface for a single
+// WorkloadEndpoint.  Updates are conditional on the resource version, and the configured
+// number of updates fail with a conflict as if the WorkloadEndpoint had been modified.
+type fakeWEPResources struct {
+	resourceInterface
+	wep       *libapiv3.WorkloadEndpoint
+	conflicts int
+	updates   int
+}
+
+func (r *fakeWEPResources) Get(ctx context.Context, opts options.GetOptions, kind, ns, name string) (resource, error) {
+	if r.wep.Namespace != ns || r.wep.Name != name {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	return r.wep.DeepCopy(), nil
+}
+
+func (r *fakeWEPResources) Update(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
+	r.updates++
+	wep := in.(*libapiv3.WorkloadEndpoint)
+	if r.conflicts > 0 {
+		r.conflicts--
+		r.bumpRevision()
+	}
+	if wep.ResourceVersion != r.wep.ResourceVersion {
+		return nil, cerrors.ErrorResourceUpdateConflict{Identifier: wep.Name}
+	}
+	r.wep = wep.DeepCopy()
+	r.bumpRevision()
+	return r.wep.DeepCopy(), nil
+}
+
+func (r *fakeWEPResources) bumpRevision() {
+	rev, _ := strconv.Atoi(r.wep.ResourceVersion)
+	r.wep.ResourceVersion = strconv.Itoa(rev + 1)
+}
+
+var _ = Describe("WorkloadEndpoints Patch", func() {
+	var res *fakeWEPResources
+	var weps workloadEndpoints
+
+	BeforeEach(func() {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Namespace = "default"
+		wep.Name = "node1-k8s-pod1-eth0"
+		wep.ResourceVersion = "10"
+		wep.Labels = map[string]string{"app": "web", apiv3.LabelNamespace: "default", apiv3.LabelOrchestrator: "k8s"}
+		wep.Spec = libapiv3.WorkloadEndpointSpec{
+			Node:          "node1",
+			Orchestrator:  "k8s",
+			Pod:           "pod1",
+			Endpoint:      "eth0",
+			InterfaceName: "cali0123",
+			Profiles:      []string{"kns.default"},
+			IPNetworks:    []string{"10.0.0.1/32"},
+		}
+		res = &fakeWEPResources{wep: wep}
+		weps = workloadEndpoints{client: client{resources: res}}
+	})
+
+	It("should apply a labels-only merge patch", func() {
+		out, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"app":"db","tier":"backend"}}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Labels).To(HaveKeyWithValue("app", "db"))
+		Expect(out.Labels).To(HaveKeyWithValue("tier", "backend"))
+		Expect(out.Labels).To(HaveKeyWithValue(apiv3.LabelOrchestrator, "k8s"))
+		Expect(out.Spec.Profiles).To(Equal([]string{"kns.default"}))
+		Expect(out.Spec.IPNetworks).To(Equal([]string{"10.0.0.1/32"}))
+	})
+
+	It("should apply a profiles-only strategic merge patch", func() {
+		out, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.StrategicMergePatchType,
+			[]byte(`{"spec":{"profiles":["kns.default","ksa.default.default"]}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Spec.Profiles).To(Equal([]string{"kns.default", "ksa.default.default"}))
+		Expect(out.Labels).To(HaveKeyWithValue("app", "web"))
+		Expect(out.Spec.InterfaceName).To(Equal("cali0123"))
+	})
+
+	It("should reapply the patch after an update conflict", func() {
+		res.conflicts = 2
+		out, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"app":"db"}}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Labels).To(HaveKeyWithValue("app", "db"))
+		Expect(res.updates).To(Equal(3))
+	})
+
+	It("should not retry if the patch sets the resource version", func() {
+		_, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.MergePatchType,
+			[]byte(`{"metadata":{"resourceVersion":"9","labels":{"app":"db"}}}`))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+		Expect(res.updates).To(Equal(1))
+	})
+
+	It("should reject a patch that renames the WorkloadEndpoint", func() {
+		_, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.MergePatchType,
+			[]byte(`{"metadata":{"namespace":"other"}}`))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorValidation{}))
+		Expect(res.updates).To(BeZero())
+	})
+
+	It("should reject an invalid patched WorkloadEndpoint", func() {
+		_, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.MergePatchType,
+			[]byte(`{"spec":{"ipNetworks":["10.0.0.300/32"]}}`))
+		Expect(err).To(HaveOccurred())
+		Expect(res.updates).To(BeZero())
+	})
+
+	It("should reject unsupported patch types", func() {
+		_, err := weps.Patch(context.Background(), "default", "node1-k8s-pod1-eth0", types.JSONPatchType, []byte(`[]`))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorOperationNotSupported{}))
+	})
+})
@@ -0,0 +1,38 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/apiconfig"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/options"
+)
+
+var _ = Describe("WorkloadEndpoints UpdateStatus", func() {
+	It("should not be supported in the Kubernetes datastore", func() {
+		c := client{}
+		c.config.Spec.DatastoreType = apiconfig.Kubernetes
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Status = &libapiv3.WorkloadEndpointStatus{ActiveInterfaceName: "cali0123"}
+		_, err := workloadEndpoints{client: c}.UpdateStatus(context.Background(), wep, options.SetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorOperationNotSupported{}))
+	})
+})
@@ -38,7 +38,8 @@ func (w *WorkloadEndpointConverter) ConvertMetadataToKey(m unversioned.ResourceM
 }
 
 // ConvertAPIToKVPair converts an API WorkloadEndpoint structure to a KVPair containing a
-// backend WorkloadEndpoint and WorkloadEndpointKey.
+// backend WorkloadEndpoint and WorkloadEndpointKey.  The backend WorkloadEndpoint is
+// sanitized, and an error is returned if it fails to sanitize.
 func (w *WorkloadEndpointConverter) ConvertAPIToKVPair(a unversioned.Resource) (*model.KVPair, error) {
 	ah := a.(api.WorkloadEndpoint)
 	k, err := w.ConvertMetadataToKey(ah.Metadata)
@@ -99,6 +100,9 @@ func (w *WorkloadEndpointConverter) ConvertAPIToKVPair(a unversioned.Resource) (
 		},
 		Revision: ah.Metadata.Revision,
 	}
+	if err := d.Value.(*model.WorkloadEndpoint).Sanitize(); err != nil {
+		return nil, err
+	}
 
 	return &d, nil
 }
@@ -109,13 +113,10 @@ func (w *WorkloadEndpointConverter) ConvertKVPairToAPI(d *model.KVPair) (unversi
 	bh := d.Value.(*model.WorkloadEndpoint)
 	bk := d.Key.(model.WorkloadEndpointKey)
 
-	nets := bh.IPv4Nets
-	nets = append(nets, bh.IPv6Nets...)
+	nets := bh.AllNets()
 
 	nats := []api.IPNAT{}
-	mnats := bh.IPv4NAT
-	mnats = append(mnats, bh.IPv6NAT...)
-	for _, mnat := range mnats {
+	for _, mnat := range bh.AllNATs() {
 		nat := api.IPNAT{InternalIP: mnat.IntIP, ExternalIP: mnat.ExtIP}
 		nats = append(nats, nat)
 	}
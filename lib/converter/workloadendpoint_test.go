@@ -0,0 +1,60 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/libcalico-go/lib/apis/v1"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	. "github.com/projectcalico/libcalico-go/lib/converter"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("WorkloadEndpointConverter", func() {
+	newWEP := func() api.WorkloadEndpoint {
+		wep := *api.NewWorkloadEndpoint()
+		wep.Metadata.Node = "node1"
+		wep.Metadata.Orchestrator = "k8s"
+		wep.Metadata.Workload = "default.pod1"
+		wep.Metadata.Name = "eth0"
+		wep.Spec.InterfaceName = " cali0123 "
+		wep.Spec.Profiles = []string{"prof-a", "prof-a"}
+		wep.Spec.IPNetworks = []net.IPNet{net.MustParseCIDR("10.0.0.1/32"), net.MustParseCIDR("10.0.0.1/32")}
+		return wep
+	}
+
+	It("should sanitize the backend WorkloadEndpoint", func() {
+		wep := newWEP()
+		kvp, err := (&WorkloadEndpointConverter{}).ConvertAPIToKVPair(wep)
+		Expect(err).NotTo(HaveOccurred())
+		v := kvp.Value.(*model.WorkloadEndpoint)
+		Expect(v.Name).To(Equal("cali0123"))
+		Expect(v.ProfileIDs).To(Equal([]string{"prof-a"}))
+		Expect(v.IPv4Nets).To(Equal([]net.IPNet{net.MustParseNetwork("10.0.0.1/32")}))
+
+		// The API resource is not modified.
+		Expect(wep.Spec.Profiles).To(Equal([]string{"prof-a", "prof-a"}))
+	})
+
+	It("should reject an all-zeros MAC address", func() {
+		wep := newWEP()
+		mac := net.MAC{HardwareAddr: make([]byte, 6)}
+		wep.Spec.MAC = &mac
+		_, err := (&WorkloadEndpointConverter{}).ConvertAPIToKVPair(wep)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -17,6 +17,7 @@ package errors
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -60,6 +61,12 @@ func (e ErrorResourceDoesNotExist) Error() string {
 	return fmt.Sprintf("resource does not exist: %v with error: %v", e.Identifier, e.Err)
 }
 
+// Unwrap returns the underlying error, so that errors.Is and errors.As can be used to check
+// for a more specific cause.
+func (e ErrorResourceDoesNotExist) Unwrap() error {
+	return e.Err
+}
+
 // Error indicating an operation is not supported.
 type ErrorOperationNotSupported struct {
 	Operation  string
@@ -86,6 +93,17 @@ func (e ErrorResourceAlreadyExists) Error() string {
 	return fmt.Sprintf("resource already exists: %v", e.Identifier)
 }
 
+// Error indicating that a lookup that should identify a single resource matched more
+// than one resource.
+type ErrorMultipleResources struct {
+	Identifier interface{}
+	Names      []string
+}
+
+func (e ErrorMultipleResources) Error() string {
+	return fmt.Sprintf("multiple resources match %v: %s", e.Identifier, strings.Join(e.Names, ", "))
+}
+
 // Error indicating a problem connecting to the backend.
 type ErrorConnectionUnauthorized struct {
 	Err error
@@ -165,6 +183,20 @@ func (e ErrorPartialFailure) Error() string {
 	return fmt.Sprintf("operation partially failed: %v", e.Err)
 }
 
+// Error indicating that several independent operations failed.  Errors contains the error
+// returned by each failed operation.
+type MultiError struct {
+	Errors []error
+}
+
+func (e MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d operation(s) failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
 // UpdateErrorIdentifier modifies the supplied error to use the new resource
 // identifier.
 func UpdateErrorIdentifier(err error, id interface{}) error {
@@ -188,6 +220,9 @@ func UpdateErrorIdentifier(err error, id interface{}) error {
 	case ErrorResourceUpdateConflict:
 		e.Identifier = id
 		err = e
+	case ErrorMultipleResources:
+		e.Identifier = id
+		err = e
 	}
 	return err
 }
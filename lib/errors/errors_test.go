@@ -15,6 +15,8 @@
 package errors_test
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
@@ -49,4 +51,20 @@ var _ = DescribeTable(
 		},
 		"operation apply is not supported on foo.bar.baz: cannot mix foobar with baz",
 	),
+	Entry(
+		"Multiple resources",
+		errors.ErrorMultipleResources{
+			Identifier: "WorkloadEndpoint(namespace1/pod=pod1)",
+			Names:      []string{"node1-k8s-pod1-eth0", "node1-k8s-pod1-net1"},
+		},
+		"multiple resources match WorkloadEndpoint(namespace1/pod=pod1): node1-k8s-pod1-eth0, node1-k8s-pod1-net1",
+	),
+	Entry(
+		"Multiple errors",
+		errors.MultiError{Errors: []error{
+			errors.ErrorResourceDoesNotExist{Identifier: "profile1"},
+			errors.ErrorConnectionUnauthorized{Err: fmt.Errorf("bad token")},
+		}},
+		"2 operation(s) failed: resource does not exist: profile1 with error: <nil>; connection is unauthorized: bad token",
+	),
 )
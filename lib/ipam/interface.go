@@ -16,7 +16,9 @@ package ipam
 
 import (
 	"context"
+	"time"
 
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	cnet "github.com/projectcalico/libcalico-go/lib/net"
 )
 
@@ -51,10 +53,14 @@ type Interface interface {
 	IPsByHandle(ctx context.Context, handleID string) ([]cnet.IP, error)
 
 	// ReleaseByHandle releases all IP addresses that have been assigned
-	// using the provided handle.  Returns an error if no addresses
-	// are assigned with the given handle.
+	// using the provided handle.  Returns an error wrapping ErrHandleNotFound
+	// if the handle does not exist.
 	ReleaseByHandle(ctx context.Context, handleID string) error
 
+	// ReleaseOrphanedHandles releases all IP addresses assigned using handles that
+	// are not in validHandleIDs.  Returns the number of handles released.
+	ReleaseOrphanedHandles(ctx context.Context, validHandleIDs []string) (int, error)
+
 	// ClaimAffinity claims affinity to the given host for all blocks
 	// within the given CIDR.  The given CIDR must fall within a configured
 	// pool. If an empty string is passed as the host, then the value returned by os.Hostname is used.
@@ -96,9 +102,25 @@ type Interface interface {
 	// GetUtilization returns IP utilization info for the specified pools, or for all pools.
 	GetUtilization(ctx context.Context, args GetUtilizationArgs) ([]*PoolUtilization, error)
 
+	// AutoReleaseExpired releases the IP addresses of pods that no longer exist, if the
+	// addresses were assigned more than timeout ago.  Returns the released addresses.
+	AutoReleaseExpired(ctx context.Context, timeout time.Duration, pods PodChecker) ([]cnet.IP, error)
+
 	// EnsureBlock returns single IPv4/IPv6 IPAM block for a host as specified by the provided BlockArgs.
 	// If there is no block allocated already for this host, allocate one and return its' CIDR.
 	// Otherwise, return the CIDR of the IPAM block allocated for this host.
 	// It returns IPv4, IPv6 block CIDR and any error encountered.
 	EnsureBlock(ctx context.Context, args BlockArgs) (*cnet.IPNet, *cnet.IPNet, error)
 }
+
+// BlockReader provides read-only access to the IPAM allocation blocks.  This allows
+// the IPAM logic that only reads blocks to be used without a backend datastore.
+type BlockReader interface {
+	// GetAllocationBlock returns the allocation block with the given CIDR.
+	GetAllocationBlock(ctx context.Context, cidr cnet.IPNet) (*model.AllocationBlock, error)
+
+	// ListAllocationBlocks returns the allocation blocks with affinity to the given
+	// host.  If an empty string is passed as the host then all allocation blocks are
+	// returned.
+	ListAllocationBlocks(ctx context.Context, host string) ([]*model.AllocationBlock, error)
+}
@@ -65,13 +65,15 @@ var (
 // Consumers of the Calico API should not create this directly, but should
 // access IPAM through the main client IPAM accessor (e.g. clientv3.IPAM())
 func NewIPAMClient(client bapi.Client, pools PoolAccessorInterface) Interface {
-	return &ipamClient{
+	rw := blockReaderWriter{
 		client: client,
 		pools:  pools,
-		blockReaderWriter: blockReaderWriter{
-			client: client,
-			pools:  pools,
-		},
+	}
+	return &ipamClient{
+		client:            client,
+		pools:             pools,
+		blockReaderWriter: rw,
+		blockReader:       rw,
 	}
 }
 
@@ -80,6 +82,18 @@ type ipamClient struct {
 	client            bapi.Client
 	pools             PoolAccessorInterface
 	blockReaderWriter blockReaderWriter
+
+	// blockReader is used by the IPAM logic that only needs to read the allocation
+	// blocks.  If not set, the blockReaderWriter is used.
+	blockReader BlockReader
+}
+
+// reader returns the BlockReader used to read allocation blocks.
+func (c ipamClient) reader() BlockReader {
+	if c.blockReader != nil {
+		return c.blockReader
+	}
+	return c.blockReaderWriter
 }
 
 // AutoAssign automatically assigns one or more IP addresses as specified by the
@@ -488,7 +502,7 @@ func (s *blockAssignState) findOrClaimBlock(ctx context.Context, minFreeIps int)
 
 			// First, try to find an unclaimed block.
 			logCtx.Info("Looking for an unclaimed block")
-			subnet, err := s.client.blockReaderWriter.findUnclaimedBlock(ctx, s.host, s.version, s.pools, *config)
+			subnet, err := s.client.blockReaderWriter.findUnclaimedBlock(ctx, s.client.reader(), s.host, s.version, s.pools, *config)
 			if err != nil {
 				if _, ok := err.(noFreeBlocksError); ok {
 					// No free blocks.  Break.
@@ -920,7 +934,7 @@ func (c ipamClient) ReleaseIPs(ctx context.Context, ips []net.IP) ([]net.IP, err
 		}
 
 		if pool == nil {
-			if cidr, err := c.blockReaderWriter.getBlockForIP(ctx, ip); err != nil {
+			if cidr, err := c.blockReaderWriter.getBlockForIP(ctx, c.reader(), ip); err != nil {
 				return nil, err
 			} else {
 				if cidr == nil {
@@ -1445,26 +1459,31 @@ func (c ipamClient) IPsByHandle(ctx context.Context, handleID string) ([]net.IP,
 	assignments := []net.IP{}
 	for k := range handle.Block {
 		_, blockCIDR, _ := net.ParseCIDR(k)
-		obj, err := c.blockReaderWriter.queryBlock(ctx, *blockCIDR, "")
+		ab, err := c.reader().GetAllocationBlock(ctx, *blockCIDR)
 		if err != nil {
 			log.WithError(err).Warningf("Couldn't read block %s referenced by handle %s", blockCIDR, handleID)
 			continue
 		}
 
-		// Pull out the allocationBlock and get all the assignments from it.
-		b := allocationBlock{obj.Value.(*model.AllocationBlock)}
+		// Get all the assignments from the allocationBlock.
+		b := allocationBlock{ab}
 		assignments = append(assignments, b.ipsByHandle(handleID)...)
 	}
 	return assignments, nil
 }
 
 // ReleaseByHandle releases all IP addresses that have been assigned
-// using the provided handle.
+// using the provided handle.  If the handle does not exist, an error wrapping
+// ErrHandleNotFound is returned.
 func (c ipamClient) ReleaseByHandle(ctx context.Context, handleID string) error {
 	handleID = sanitizeHandle(handleID)
 	log.Debugf("Releasing all IPs with handle '%s'", handleID)
 	obj, err := c.blockReaderWriter.queryHandle(ctx, handleID, "")
 	if err != nil {
+		if e, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+			e.Err = ErrHandleNotFound
+			return e
+		}
 		return err
 	}
 	handle := allocationHandle{obj.Value.(*model.IPAMHandle)}
@@ -1669,12 +1688,12 @@ func (c ipamClient) GetAssignmentAttributes(ctx context.Context, addr net.IP) (m
 		return nil, nil, cerrors.ErrorResourceDoesNotExist{Identifier: addr.String(), Err: errors.New("No valid IPPool")}
 	}
 	blockCIDR := getBlockCIDRForAddress(addr, pool)
-	obj, err := c.blockReaderWriter.queryBlock(ctx, blockCIDR, "")
+	b, err := c.reader().GetAllocationBlock(ctx, blockCIDR)
 	if err != nil {
 		log.Errorf("Error reading block %s: %v", blockCIDR, err)
 		return nil, nil, err
 	}
-	block := allocationBlock{obj.Value.(*model.AllocationBlock)}
+	block := allocationBlock{b}
 	attrs, err := block.attributesForIP(addr)
 	if err != nil {
 		return nil, nil, err
@@ -1913,12 +1932,11 @@ func (c ipamClient) GetUtilization(ctx context.Context, args GetUtilizationArgs)
 	}
 
 	// Read all allocation blocks.
-	blocks, err := c.client.List(ctx, model.BlockListOptions{}, "")
+	blocks, err := c.reader().ListAllocationBlocks(ctx, "")
 	if err != nil {
 		return nil, err
 	}
-	for _, kvp := range blocks.KVPairs {
-		b := kvp.Value.(*model.AllocationBlock)
+	for _, b := range blocks {
 		log.Debugf("Got block: %v", b)
 
 		// Find which pool this block belongs to.
@@ -0,0 +1,244 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// fakeBlockReader implements the BlockReader interface using an in-memory set of
+// allocation blocks, so that the IPAM read logic can be tested without a datastore.
+type fakeBlockReader struct {
+	blocks []*model.AllocationBlock
+	lists  int
+}
+
+func (f *fakeBlockReader) GetAllocationBlock(ctx context.Context, cidr cnet.IPNet) (*model.AllocationBlock, error) {
+	for _, b := range f.blocks {
+		if b.CIDR.String() == cidr.String() {
+			return b, nil
+		}
+	}
+	return nil, cerrors.ErrorResourceDoesNotExist{Identifier: model.BlockKey{CIDR: cidr}}
+}
+
+func (f *fakeBlockReader) ListAllocationBlocks(ctx context.Context, host string) ([]*model.AllocationBlock, error) {
+	f.lists++
+	blocks := []*model.AllocationBlock{}
+	for _, b := range f.blocks {
+		if host == "" || getHostAffinity(b) == host {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+// newFakeBlock returns an allocation block for the given CIDR and host, with the
+// given number of addresses assigned to the handle.
+func newFakeBlock(cidr, host, handle string, num int) *model.AllocationBlock {
+	b := newBlock(cnet.MustParseCIDR(cidr), nil)
+	affinity := "host:" + host
+	b.Affinity = &affinity
+	if num > 0 {
+		_, err := b.autoAssign(num, &handle, host, map[string]string{"handle": handle}, false)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	return b.AllocationBlock
+}
+
+// newFakeReaderIPAMClient returns an ipamClient that reads the blocks through a
+// fakeBlockReader.
+func newFakeReaderIPAMClient(pools *ipPoolAccessor, blocks []*model.AllocationBlock) *ipamClient {
+	return &ipamClient{
+		pools:             pools,
+		blockReaderWriter: blockReaderWriter{pools: pools},
+		blockReader:       &fakeBlockReader{blocks: blocks},
+	}
+}
+
+// newBackendIPAMClient returns an ipamClient that reads the blocks through its
+// blockReaderWriter, from a fake backend client holding the blocks.
+func newBackendIPAMClient(pools *ipPoolAccessor, blocks []*model.AllocationBlock) *ipamClient {
+	client := newFakeClient()
+	client.listFuncs["default"] = func(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error) {
+		Expect(list).To(Equal(model.BlockListOptions{}))
+		kvps := &model.KVPairList{}
+		for _, b := range blocks {
+			kvps.KVPairs = append(kvps.KVPairs, &model.KVPair{Key: model.BlockKey{CIDR: b.CIDR}, Value: b, Revision: "1"})
+		}
+		return kvps, nil
+	}
+	client.getFuncs["default"] = func(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+		for _, b := range blocks {
+			if key.String() == (model.BlockKey{CIDR: b.CIDR}).String() {
+				return &model.KVPair{Key: key, Value: b, Revision: "1"}, nil
+			}
+		}
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+	}
+	return NewIPAMClient(client, pools).(*ipamClient)
+}
+
+var _ = describeBlockReader("fake BlockReader", newFakeReaderIPAMClient)
+var _ = describeBlockReader("backend BlockReader", newBackendIPAMClient)
+
+var _ = Describe("IPAM getBlockForIP tests", func() {
+	It("should not list the blocks if the address is in a block of the default size", func() {
+		ic := newFakeReaderIPAMClient(&ipPoolAccessor{}, []*model.AllocationBlock{
+			newFakeBlock("10.0.0.0/26", "host1", "h1", 1),
+			newFakeBlock("fd00::/122", "host1", "h1", 1),
+		})
+		for _, addr := range []string{"10.0.0.1", "fd00::1"} {
+			cidr, err := ic.blockReaderWriter.getBlockForIP(context.Background(), ic.reader(), cnet.MustParseIP(addr))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cidr).NotTo(BeNil())
+		}
+		Expect(ic.blockReader.(*fakeBlockReader).lists).To(BeZero())
+	})
+})
+
+// describeBlockReader runs the IPAM block read tests against the ipamClient returned by
+// newIPAMClient.
+func describeBlockReader(description string, newIPAMClient func(*ipPoolAccessor, []*model.AllocationBlock) *ipamClient) bool {
+	return Describe("IPAM BlockReader tests: "+description, func() {
+		var pools *ipPoolAccessor
+		var ic *ipamClient
+
+		BeforeEach(func() {
+			pools = &ipPoolAccessor{pools: map[string]pool{
+				"10.0.0.0/24": {enabled: true},
+				"fd00::/120":  {enabled: true},
+			}}
+			ic = newIPAMClient(pools, []*model.AllocationBlock{
+				newFakeBlock("10.0.0.0/26", "host1", "h1", 4),
+				newFakeBlock("10.0.0.64/26", "host2", "h2", 64),
+				newFakeBlock("fd00::/122", "host1", "h1", 1),
+				newFakeBlock("192.168.0.0/26", "host3", "h3", 2),
+			})
+		})
+
+		DescribeTable("GetUtilization",
+			func(args GetUtilizationArgs, expected map[string][]BlockUtilization) {
+				usage, err := ic.GetUtilization(context.Background(), args)
+				Expect(err).NotTo(HaveOccurred())
+				actual := map[string][]BlockUtilization{}
+				for _, u := range usage {
+					actual[u.CIDR.String()] = u.Blocks
+				}
+				Expect(actual).To(Equal(expected))
+			},
+			Entry("all pools, including orphaned blocks",
+				GetUtilizationArgs{},
+				map[string][]BlockUtilization{
+					"10.0.0.0/24": {
+						{CIDR: cnet.MustParseCIDR("10.0.0.0/26").IPNet, Capacity: 64, Available: 60},
+						{CIDR: cnet.MustParseCIDR("10.0.0.64/26").IPNet, Capacity: 64, Available: 0},
+					},
+					"fd00::/120": {
+						{CIDR: cnet.MustParseCIDR("fd00::/122").IPNet, Capacity: 64, Available: 63},
+					},
+					"0.0.0.0/0": {
+						{CIDR: cnet.MustParseCIDR("192.168.0.0/26").IPNet, Capacity: 64, Available: 62},
+					},
+				},
+			),
+			Entry("a single requested pool",
+				GetUtilizationArgs{Pools: []string{"fd00::/120"}},
+				map[string][]BlockUtilization{
+					"fd00::/120": {
+						{CIDR: cnet.MustParseCIDR("fd00::/122").IPNet, Capacity: 64, Available: 63},
+					},
+				},
+			),
+		)
+
+		DescribeTable("GetAssignmentAttributes",
+			func(addr string, expectErr bool, expectedHandle string) {
+				attrs, handle, err := ic.GetAssignmentAttributes(context.Background(), cnet.MustParseIP(addr))
+				if expectErr {
+					Expect(err).To(HaveOccurred())
+					return
+				}
+				Expect(err).NotTo(HaveOccurred())
+				Expect(handle).NotTo(BeNil())
+				Expect(*handle).To(Equal(expectedHandle))
+				Expect(attrs).To(Equal(map[string]string{"handle": expectedHandle}))
+			},
+			Entry("IPv4 address in a partially allocated block", "10.0.0.1", false, "h1"),
+			Entry("IPv4 address in a fully allocated block", "10.0.0.127", false, "h2"),
+			Entry("IPv6 address", "fd00::", false, "h1"),
+			Entry("unallocated address", "10.0.0.10", true, ""),
+			Entry("address in a block that does not exist", "10.0.0.200", true, ""),
+			Entry("address not in a pool", "192.168.0.1", true, ""),
+		)
+
+		It("should list the blocks affine to a host", func() {
+			blocks, err := ic.reader().ListAllocationBlocks(context.Background(), "host1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blocks).To(HaveLen(2))
+		})
+
+		DescribeTable("getBlockForIP",
+			func(addr, expected string) {
+				cidr, err := ic.blockReaderWriter.getBlockForIP(context.Background(), ic.reader(), cnet.MustParseIP(addr))
+				Expect(err).NotTo(HaveOccurred())
+				if expected == "" {
+					Expect(cidr).To(BeNil())
+					return
+				}
+				Expect(cidr.String()).To(Equal(expected))
+			},
+			Entry("IPv4 address", "10.0.0.70", "10.0.0.64/26"),
+			Entry("IPv6 address", "fd00::1", "fd00::/122"),
+			Entry("address not in a block", "10.0.0.200", ""),
+		)
+
+		DescribeTable("getBlockForIP with blocks that do not have the default size",
+			func(addr, expected string) {
+				ic = newIPAMClient(pools, []*model.AllocationBlock{
+					newFakeBlock("10.0.1.0/28", "host1", "h1", 1),
+					newFakeBlock("fd00::1:0/124", "host1", "h1", 1),
+				})
+				cidr, err := ic.blockReaderWriter.getBlockForIP(context.Background(), ic.reader(), cnet.MustParseIP(addr))
+				Expect(err).NotTo(HaveOccurred())
+				if expected == "" {
+					Expect(cidr).To(BeNil())
+					return
+				}
+				Expect(cidr.String()).To(Equal(expected))
+			},
+			Entry("IPv4 address", "10.0.1.5", "10.0.1.0/28"),
+			Entry("IPv6 address", "fd00::1:5", "fd00::1:0/124"),
+			Entry("address not in a block", "10.0.1.20", ""),
+		)
+
+		It("should find a block that does not exist yet", func() {
+			p := v3.IPPool{Spec: v3.IPPoolSpec{CIDR: "10.0.0.0/24", BlockSize: 26}}
+			cidr, err := ic.blockReaderWriter.findUnclaimedBlock(context.Background(), ic.reader(), "host1", 4, []v3.IPPool{p}, IPAMConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect([]string{"10.0.0.128/26", "10.0.0.192/26"}).To(ContainElement(cidr.String()))
+		})
+	})
+}
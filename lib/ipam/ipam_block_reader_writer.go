@@ -92,7 +92,7 @@ func (rw blockReaderWriter) getAffineBlocks(ctx context.Context, host string, ve
 // should already be sanitized and only include existing, enabled pools. Note that the block may become claimed
 // between receiving the cidr from this function and attempting to claim the corresponding block as this function
 // does not reserve the returned IPNet.
-func (rw blockReaderWriter) findUnclaimedBlock(ctx context.Context, host string, version int, pools []v3.IPPool, config IPAMConfig) (*cnet.IPNet, error) {
+func (rw blockReaderWriter) findUnclaimedBlock(ctx context.Context, reader BlockReader, host string, version int, pools []v3.IPPool, config IPAMConfig) (*cnet.IPNet, error) {
 	// If there are no pools, we cannot assign addresses.
 	if len(pools) == 0 {
 		return nil, fmt.Errorf("no configured Calico pools for node %s", host)
@@ -100,15 +100,15 @@ func (rw blockReaderWriter) findUnclaimedBlock(ctx context.Context, host string,
 
 	// List blocks up front to reduce number of queries.
 	// We will try to write the block later to prevent races.
-	existingBlocks, err := rw.listBlocks(ctx, "")
+	existingBlocks, err := reader.ListAllocationBlocks(ctx, "")
 	if err != nil {
 		return nil, err
 	}
 
 	/// Build a map for faster lookups.
 	exists := map[string]bool{}
-	for _, e := range existingBlocks.KVPairs {
-		exists[e.Key.(model.BlockKey).CIDR.String()] = true
+	for _, b := range existingBlocks {
+		exists[b.CIDR.String()] = true
 	}
 
 	// Iterate through pools to find a new block.
@@ -405,6 +405,31 @@ func (rw blockReaderWriter) deleteHandle(ctx context.Context, kvp *model.KVPair)
 	return err
 }
 
+// GetAllocationBlock implements the BlockReader interface.
+func (rw blockReaderWriter) GetAllocationBlock(ctx context.Context, cidr cnet.IPNet) (*model.AllocationBlock, error) {
+	obj, err := rw.queryBlock(ctx, cidr, "")
+	if err != nil {
+		return nil, err
+	}
+	return obj.Value.(*model.AllocationBlock), nil
+}
+
+// ListAllocationBlocks implements the BlockReader interface.
+func (rw blockReaderWriter) ListAllocationBlocks(ctx context.Context, host string) ([]*model.AllocationBlock, error) {
+	objs, err := rw.listBlocks(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	blocks := []*model.AllocationBlock{}
+	for _, obj := range objs.KVPairs {
+		b := obj.Value.(*model.AllocationBlock)
+		if host == "" || getHostAffinity(b) == host {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
 // getPoolForIP returns the pool if the given IP is within a configured
 // Calico pool, and nil otherwise.
 func (rw blockReaderWriter) getPoolForIP(ip cnet.IP, enabledPools []v3.IPPool) (*v3.IPPool, error) {
@@ -557,22 +582,45 @@ func randomBlockGenerator(ipPool v3.IPPool, hostName string) func() *cnet.IPNet
 	}
 }
 
+// The block sizes used by IP pools that do not specify one.  getBlockForIP looks for a
+// block of this size before listing every block.
+const (
+	defaultBlockSizeV4 = 26
+	defaultBlockSizeV6 = 122
+)
+
 // Find the block for a given IP (without needing a pool)
-func (rw blockReaderWriter) getBlockForIP(ctx context.Context, ip cnet.IP) (*cnet.IPNet, error) {
-	// Lookup all blocks by providing an empty BlockListOptions to the List operation.
-	opts := model.BlockListOptions{IPVersion: ip.Version()}
-	datastoreObjs, err := rw.client.List(ctx, opts, "")
+func (rw blockReaderWriter) getBlockForIP(ctx context.Context, reader BlockReader, ip cnet.IP) (*cnet.IPNet, error) {
+	// Most blocks have the default size, so look that block up directly first.
+	bits, blockSize := 32, defaultBlockSizeV4
+	if ip.Version() == 6 {
+		bits, blockSize = 128, defaultBlockSizeV6
+	}
+	mask := net.CIDRMask(blockSize, bits)
+	b, err := reader.GetAllocationBlock(ctx, cnet.IPNet{IPNet: net.IPNet{IP: ip.Mask(mask), Mask: mask}})
+	if err == nil {
+		log.Debugf("Found IP %s in block %s", ip.String(), b.CIDR.String())
+		return &b.CIDR, nil
+	} else if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
+		log.Errorf("Error getting block: %v", err)
+		return nil, err
+	}
+
+	// Lookup all blocks by providing an empty host to ListAllocationBlocks.
+	blocks, err := reader.ListAllocationBlocks(ctx, "")
 	if err != nil {
 		log.Errorf("Error getting affine blocks: %v", err)
 		return nil, err
 	}
 
-	// Iterate through and extract the block CIDRs.
-	for _, o := range datastoreObjs.KVPairs {
-		k := o.Key.(model.BlockKey)
-		if k.CIDR.IPNet.Contains(ip.IP) {
-			log.Debugf("Found IP %s in block %s", ip.String(), k.String())
-			return &k.CIDR, nil
+	// Iterate through the blocks of the same IP version and extract the block CIDRs.
+	for _, b := range blocks {
+		if b.CIDR.Version() != ip.Version() {
+			continue
+		}
+		if b.CIDR.IPNet.Contains(ip.IP) {
+			log.Debugf("Found IP %s in block %s", ip.String(), b.CIDR.String())
+			return &b.CIDR, nil
 		}
 	}
 
@@ -15,9 +15,14 @@
 package ipam
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrHandleNotFound indicates that the requested handle does not exist.  It is returned
+// wrapped in a cerrors.ErrorResourceDoesNotExist, so use errors.Is to check for it.
+var ErrHandleNotFound = errors.New("handle not found")
+
 // invalidSizeError indicates that the requested IP network size is not valid.
 type invalidSizeError string
 
@@ -0,0 +1,181 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// The formats of the AttributeTimestamp allocation attribute.  The CNI plugin records the
+// time using time.Time.String.
+var allocationTimestampFormats = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC3339Nano,
+}
+
+// PodChecker is used by AutoReleaseExpired to check whether the pod that an IP address is
+// assigned to still exists.
+type PodChecker interface {
+	PodExists(ctx context.Context, namespace, name string) (bool, error)
+}
+
+// expiredHandle is the set of expired IP addresses assigned to a handle, along with the pod
+// they were assigned to.
+type expiredHandle struct {
+	id             string
+	namespace, pod string
+	ips            []cnet.IP
+}
+
+// AutoReleaseExpired releases the IP addresses assigned to pods that no longer exist, for
+// example because the CNI teardown of the pod failed.  An address is released if it was
+// assigned more than timeout ago, and the pod that it was assigned to no longer exists
+// according to the supplied PodChecker.  The timeout guards against releasing the addresses
+// of a pod that is being created and is not yet visible to the PodChecker.
+//
+// Only addresses with the pod, namespace and timestamp attributes recorded by the CNI
+// plugin are considered; other assignments, such as tunnel addresses, are never released.
+// Only the expired addresses are released, so any other addresses assigned to the same
+// handle are kept, and the handle is only removed once it has no addresses left.  The
+// released addresses are returned, including those released before any error.
+func (c ipamClient) AutoReleaseExpired(ctx context.Context, timeout time.Duration, pods PodChecker) ([]cnet.IP, error) {
+	handles, err := c.expiredHandles(ctx, time.Now().Add(-timeout), pods)
+	if err != nil {
+		return nil, err
+	}
+
+	var released []cnet.IP
+	for _, h := range handles {
+		unallocated, err := c.ReleaseIPs(ctx, h.ips)
+		if err != nil {
+			log.WithError(err).WithField("handle", h.id).Warning("Failed to release expired IP addresses")
+			return released, err
+		}
+		for _, ip := range h.ips {
+			if containsIP(unallocated, ip) {
+				// The address has been released since the blocks were listed.
+				log.WithFields(log.Fields{"ip": ip, "handle": h.id}).Debug("Expired IP address has already been released")
+				continue
+			}
+			released = append(released, ip)
+		}
+	}
+	if len(released) != 0 {
+		log.Infof("Released %d IP addresses assigned to pods that no longer exist", len(released))
+	}
+	return released, nil
+}
+
+// expiredHandles returns the handles with addresses assigned before the cutoff to pods that
+// no longer exist, along with those addresses.  The handles are returned in order of handle
+// ID.
+func (c ipamClient) expiredHandles(ctx context.Context, cutoff time.Time, pods PodChecker) ([]*expiredHandle, error) {
+	blocks, err := c.reader().ListAllocationBlocks(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	handles := map[string]*expiredHandle{}
+	for _, b := range blocks {
+		for ordinal, attrIdx := range b.Allocations {
+			if attrIdx == nil || *attrIdx >= len(b.Attributes) {
+				continue
+			}
+			attr := b.Attributes[*attrIdx]
+			if attr.AttrPrimary == nil {
+				continue
+			}
+			ip := cnet.IP{IP: b.OrdinalToIP(ordinal).IP}
+			logCtx := log.WithFields(log.Fields{"ip": ip, "handle": *attr.AttrPrimary})
+
+			namespace, pod := attr.AttrSecondary[AttributeNamespace], attr.AttrSecondary[AttributePod]
+			if namespace == "" || pod == "" {
+				logCtx.Debug("Skipping IP address that is not assigned to a pod")
+				continue
+			}
+			assigned, ok := parseAllocationTimestamp(attr.AttrSecondary[AttributeTimestamp])
+			if !ok {
+				logCtx.Debug("Skipping IP address with no valid assignment timestamp")
+				continue
+			}
+			if !assigned.Before(cutoff) {
+				logCtx.Debug("Skipping IP address that has not expired")
+				continue
+			}
+
+			h := handles[*attr.AttrPrimary]
+			if h == nil {
+				h = &expiredHandle{id: *attr.AttrPrimary, namespace: namespace, pod: pod}
+				handles[*attr.AttrPrimary] = h
+			}
+			h.ips = append(h.ips, ip)
+		}
+	}
+
+	// Check the handles in a consistent order.
+	ids := make([]string, 0, len(handles))
+	for id := range handles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var expired []*expiredHandle
+	for _, id := range ids {
+		h := handles[id]
+		exists, err := pods.PodExists(ctx, h.namespace, h.pod)
+		if err != nil {
+			log.WithError(err).WithField("handle", id).Warning("Unable to check whether pod exists")
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+		log.WithFields(log.Fields{"handle": id, "namespace": h.namespace, "pod": h.pod}).Info(
+			"Releasing expired IP addresses of pod that no longer exists")
+		expired = append(expired, h)
+	}
+	return expired, nil
+}
+
+// parseAllocationTimestamp parses the AttributeTimestamp attribute of an allocation.
+func parseAllocationTimestamp(s string) (time.Time, bool) {
+	// Strip the monotonic clock reading included by time.Time.String.
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+	for _, f := range allocationTimestampFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// containsIP returns whether the address is in the list.
+func containsIP(ips []cnet.IP, ip cnet.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// fakePodChecker reports that the pods in the set exist.
+type fakePodChecker struct {
+	pods    map[string]bool
+	checked []string
+	err     error
+}
+
+func (f *fakePodChecker) PodExists(ctx context.Context, namespace, name string) (bool, error) {
+	f.checked = append(f.checked, namespace+"/"+name)
+	return f.pods[namespace+"/"+name], f.err
+}
+
+var _ = Describe("IPAM expired allocation tests", func() {
+	now := time.Now()
+	var reader *fakeBlockReader
+	var ic *ipamClient
+	var pods *fakePodChecker
+
+	// assign assigns num addresses from the block to the handle, with the given attributes.
+	assign := func(b allocationBlock, handle string, num int, attrs map[string]string) {
+		_, err := b.autoAssign(num, &handle, "host1", attrs, false)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	podAttrs := func(namespace, pod string, assigned time.Time) map[string]string {
+		return map[string]string{
+			AttributeNamespace: namespace,
+			AttributePod:       pod,
+			AttributeTimestamp: assigned.UTC().String(),
+		}
+	}
+
+	BeforeEach(func() {
+		b1 := newBlock(cnet.MustParseCIDR("10.0.0.0/30"), nil)
+		assign(b1, "deleted-old", 1, podAttrs("default", "deleted-old", now.Add(-time.Hour)))
+		assign(b1, "running-old", 1, podAttrs("default", "running-old", now.Add(-time.Hour)))
+		assign(b1, "deleted-new", 1, podAttrs("default", "deleted-new", now.Add(-time.Second)))
+		assign(b1, "tunnel", 1, map[string]string{AttributeType: AttributeTypeVXLAN, AttributeNode: "host1"})
+
+		b2 := newBlock(cnet.MustParseCIDR("fd00::/126"), nil)
+		assign(b2, "deleted-old", 1, podAttrs("default", "deleted-old", now.Add(-2*time.Hour)))
+		assign(b2, "dual-stack", 1, podAttrs("ns1", "dual-stack", now.Add(-time.Hour)))
+		assign(b2, "no-timestamp", 1, map[string]string{AttributeNamespace: "ns1", AttributePod: "no-timestamp"})
+
+		b3 := newBlock(cnet.MustParseCIDR("10.0.1.0/30"), nil)
+		assign(b3, "dual-stack", 1, podAttrs("ns1", "dual-stack", now.Add(-time.Second)))
+
+		reader = &fakeBlockReader{blocks: []*model.AllocationBlock{b1.AllocationBlock, b2.AllocationBlock, b3.AllocationBlock}}
+		ic = &ipamClient{blockReader: reader}
+		pods = &fakePodChecker{pods: map[string]bool{"default/running-old": true}}
+	})
+
+	// expiredIPs returns the addresses of the expired handles.
+	expiredIPs := func(cutoff time.Time) []cnet.IP {
+		handles, err := ic.expiredHandles(context.Background(), cutoff, pods)
+		Expect(err).NotTo(HaveOccurred())
+		var ips []cnet.IP
+		for _, h := range handles {
+			ips = append(ips, h.ips...)
+		}
+		return ips
+	}
+
+	It("should only return the expired addresses of pods that do not exist", func() {
+		handles, err := ic.expiredHandles(context.Background(), now.Add(-time.Minute), pods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(handles).To(HaveLen(2))
+		Expect(handles[0].id).To(Equal("deleted-old"))
+		Expect(handles[0].ips).To(ConsistOf(cnet.MustParseIP("10.0.0.0"), cnet.MustParseIP("fd00::")))
+
+		// The address of the handle that has not expired yet is not returned.
+		Expect(handles[1].id).To(Equal("dual-stack"))
+		Expect(handles[1].ips).To(ConsistOf(cnet.MustParseIP("fd00::1")))
+
+		// The pods of handles that have not expired, or that have no timestamp, are
+		// not checked.
+		Expect(pods.checked).To(Equal([]string{"default/deleted-old", "ns1/dual-stack", "default/running-old"}))
+	})
+
+	It("should return all the addresses of a handle once they have all expired", func() {
+		Expect(expiredIPs(now)).To(ConsistOf(
+			cnet.MustParseIP("10.0.0.0"), cnet.MustParseIP("fd00::"),
+			cnet.MustParseIP("10.0.0.2"),
+			cnet.MustParseIP("fd00::1"), cnet.MustParseIP("10.0.1.0"),
+		))
+	})
+
+	It("should return the error if a pod cannot be checked", func() {
+		pods.err = errors.New("API unavailable")
+		_, err := ic.expiredHandles(context.Background(), now, pods)
+		Expect(err).To(MatchError("API unavailable"))
+	})
+
+	It("should not release anything if no handles have expired", func() {
+		ips, err := ic.AutoReleaseExpired(context.Background(), 24*time.Hour, pods)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ips).To(BeEmpty())
+		Expect(pods.checked).To(BeEmpty())
+	})
+
+	DescribeTable("parseAllocationTimestamp",
+		func(s string, expected time.Time, ok bool) {
+			t, parsed := parseAllocationTimestamp(s)
+			Expect(parsed).To(Equal(ok))
+			Expect(t.Equal(expected)).To(BeTrue())
+		},
+		Entry("time.Time.String in UTC", "2021-03-04 05:06:07.123456789 +0000 UTC",
+			time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.UTC), true),
+		Entry("time.Time.String with a monotonic reading", "2021-03-04 05:06:07 +0000 UTC m=+1.000000001",
+			time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), true),
+		Entry("RFC 3339", "2021-03-04T05:06:07Z", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC), true),
+		Entry("empty", "", time.Time{}, false),
+		Entry("invalid", "yesterday", time.Time{}, false),
+	)
+})
+
+var _ = Describe("IPAM expired allocation release tests", func() {
+	It("should only release the expired addresses of a handle", func() {
+		// Store a block with addresses assigned to a single handle: one that has expired,
+		// one that has not expired yet, and one without the pod attributes.
+		now := time.Now()
+		handle := "mixed"
+		blockCIDR := cnet.MustParseCIDR("10.0.0.0/29")
+		b := newBlock(blockCIDR, nil)
+		for _, attrs := range []map[string]string{
+			{AttributeNamespace: "default", AttributePod: "pod1", AttributeTimestamp: now.Add(-time.Hour).UTC().String()},
+			{AttributeNamespace: "default", AttributePod: "pod1", AttributeTimestamp: now.Add(-time.Second).UTC().String()},
+			nil,
+		} {
+			_, err := b.autoAssign(1, &handle, "host1", attrs, false)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		handleKey := model.IPAMHandleKey{HandleID: handle}
+		blockKey := model.BlockKey{CIDR: blockCIDR}
+		kvps := map[string]*model.KVPair{
+			handleKey.String(): {
+				Key:   handleKey,
+				Value: &model.IPAMHandle{HandleID: handle, Block: map[string]int{blockCIDR.String(): 3}},
+			},
+			blockKey.String(): {Key: blockKey, Value: b.AllocationBlock},
+		}
+
+		fc := newFakeClient()
+		fc.getFuncs["default"] = func(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+			if kvp, ok := kvps[key.String()]; ok {
+				return kvp, nil
+			}
+			return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+		}
+		fc.updateFuncs["default"] = func(ctx context.Context, object *model.KVPair) (*model.KVPair, error) {
+			kvps[object.Key.String()] = object
+			return object, nil
+		}
+		fc.listFuncs["default"] = func(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error) {
+			return &model.KVPairList{KVPairs: []*model.KVPair{kvps[blockKey.String()]}}, nil
+		}
+		ic := &ipamClient{
+			client:            fc,
+			pools:             &ipPoolAccessor{pools: map[string]pool{"10.0.0.0/29": {enabled: true, blockSize: 29}}},
+			blockReaderWriter: blockReaderWriter{client: fc},
+		}
+
+		released, err := ic.AutoReleaseExpired(context.Background(), time.Minute, &fakePodChecker{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(released).To(ConsistOf(cnet.MustParseIP("10.0.0.0")))
+
+		// The other addresses are still assigned to the handle, which is kept.
+		block := allocationBlock{kvps[blockKey.String()].Value.(*model.AllocationBlock)}
+		Expect(block.ipsByHandle(handle)).To(ConsistOf(cnet.MustParseIP("10.0.0.1"), cnet.MustParseIP("10.0.0.2")))
+		Expect(kvps[handleKey.String()].Value.(*model.IPAMHandle).Block).To(Equal(map[string]int{blockCIDR.String(): 2}))
+	})
+})
@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/set"
+)
+
+// ReleaseOrphanedHandles releases the IP addresses of every handle that is not in
+// validHandleIDs, for example the handles of workloads that were deleted without their
+// addresses being released.  The handles are compared after sanitization, in the same
+// way as ReleaseByHandle.
+//
+// A failure to release one handle does not stop the others from being released; the
+// failures are returned in a cerrors.MultiError.  Handles that are deleted by another
+// client while this runs are not counted.  Returns the number of handles released.
+func (c ipamClient) ReleaseOrphanedHandles(ctx context.Context, validHandleIDs []string) (int, error) {
+	valid := set.NewStringSet()
+	for _, h := range validHandleIDs {
+		valid.Add(sanitizeHandle(h))
+	}
+
+	handles, err := c.blockReaderWriter.listHandles(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	var errs []error
+	for _, kvp := range handles.KVPairs {
+		handleID := kvp.Key.(model.IPAMHandleKey).HandleID
+		if valid.Contains(handleID) {
+			continue
+		}
+
+		logCtx := log.WithField("handle", handleID)
+		logCtx.Info("Releasing orphaned handle")
+		if err := c.ReleaseByHandle(ctx, handleID); err != nil {
+			if errors.Is(err, ErrHandleNotFound) {
+				logCtx.Debug("Orphaned handle has already been released")
+				continue
+			}
+			logCtx.WithError(err).Warning("Failed to release orphaned handle")
+			errs = append(errs, err)
+			continue
+		}
+		released++
+	}
+
+	if len(errs) > 0 {
+		return released, cerrors.MultiError{Errors: errs}
+	}
+	return released, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("IPAM orphaned handle tests", func() {
+	var kvps map[string]*model.KVPair
+	var ic *ipamClient
+	var blockCIDR cnet.IPNet
+	handles := []string{"handle-0", "handle-1", "handle-2", "handle-3", "handle-4"}
+
+	BeforeEach(func() {
+		// Store the handles and the block in memory, with each of the handles assigned a
+		// single address from the block.
+		kvps = map[string]*model.KVPair{}
+		blockCIDR = cnet.MustParseCIDR("10.0.0.0/29")
+		affinity := "host:host1"
+		b := newBlock(blockCIDR, nil)
+		b.Affinity = &affinity
+		for _, h := range handles {
+			handle := h
+			_, err := b.autoAssign(1, &handle, "host1", nil, false)
+			Expect(err).NotTo(HaveOccurred())
+			key := model.IPAMHandleKey{HandleID: h}
+			kvps[key.String()] = &model.KVPair{
+				Key:   key,
+				Value: &model.IPAMHandle{HandleID: h, Block: map[string]int{blockCIDR.String(): 1}},
+			}
+		}
+		blockKey := model.BlockKey{CIDR: blockCIDR}
+		kvps[blockKey.String()] = &model.KVPair{Key: blockKey, Value: b.AllocationBlock}
+
+		fc := newFakeClient()
+		fc.getFuncs["default"] = func(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+			if kvp, ok := kvps[key.String()]; ok {
+				return kvp, nil
+			}
+			return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+		}
+		fc.updateFuncs["default"] = func(ctx context.Context, object *model.KVPair) (*model.KVPair, error) {
+			kvps[object.Key.String()] = object
+			return object, nil
+		}
+		fc.deleteKVPFuncs["default"] = func(ctx context.Context, object *model.KVPair) (*model.KVPair, error) {
+			delete(kvps, object.Key.String())
+			return object, nil
+		}
+		fc.listFuncs["default"] = func(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error) {
+			l := &model.KVPairList{}
+			for _, kvp := range kvps {
+				if _, ok := kvp.Key.(model.IPAMHandleKey); ok {
+					l.KVPairs = append(l.KVPairs, kvp)
+				}
+			}
+			return l, nil
+		}
+		ic = &ipamClient{client: fc, blockReaderWriter: blockReaderWriter{client: fc}}
+	})
+
+	It("should release only the handles that are not valid", func() {
+		released, err := ic.ReleaseOrphanedHandles(context.Background(), []string{"handle-1", "handle-3"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(released).To(Equal(3))
+
+		for _, h := range []string{"handle-0", "handle-2", "handle-4"} {
+			Expect(kvps).NotTo(HaveKey(model.IPAMHandleKey{HandleID: h}.String()))
+		}
+
+		// The valid handles and their addresses are untouched.
+		block := allocationBlock{kvps[model.BlockKey{CIDR: blockCIDR}.String()].Value.(*model.AllocationBlock)}
+		for _, h := range []string{"handle-1", "handle-3"} {
+			kvp := kvps[model.IPAMHandleKey{HandleID: h}.String()]
+			Expect(kvp).NotTo(BeNil())
+			Expect(kvp.Value.(*model.IPAMHandle).Block).To(Equal(map[string]int{blockCIDR.String(): 1}))
+			Expect(block.ipsByHandle(h)).To(HaveLen(1), fmt.Sprintf("handle %s", h))
+		}
+		Expect(block.NumFreeAddresses()).To(Equal(8 - 2))
+	})
+
+	It("should release nothing if all of the handles are valid", func() {
+		released, err := ic.ReleaseOrphanedHandles(context.Background(), handles)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(released).To(BeZero())
+		Expect(kvps).To(HaveLen(len(handles) + 1))
+	})
+
+	It("should return ErrHandleNotFound when releasing a handle that does not exist", func() {
+		err := ic.ReleaseByHandle(context.Background(), "missing")
+		Expect(errors.Is(err, ErrHandleNotFound)).To(BeTrue())
+
+		// The error is still a resource does not exist error for existing callers.
+		_, ok := err.(cerrors.ErrorResourceDoesNotExist)
+		Expect(ok).To(BeTrue())
+	})
+})
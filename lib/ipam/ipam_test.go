@@ -21,6 +21,7 @@ import (
 	"net"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -750,6 +751,30 @@ var _ = testutils.E2eDatastoreDescribe("IPAM tests", testutils.DatastoreAll, fun
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		It("should release the handle of an expired pod", func() {
+			applyNode(bc, kc, "test-host", nil)
+			deleteAllPools()
+			applyPool("10.0.0.0/24", true, "")
+
+			handle := "expired-handle"
+			ctx := context.Background()
+			attrs := map[string]string{
+				AttributeNamespace: "default",
+				AttributePod:       "deleted",
+				AttributeTimestamp: time.Now().Add(-time.Hour).UTC().String(),
+			}
+			v4ia, _, err := ic.AutoAssign(ctx, AutoAssignArgs{Num4: 1, HandleID: &handle, Attrs: attrs, Hostname: "test-host"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v4ia.IPs).To(HaveLen(1))
+
+			released, err := ic.AutoReleaseExpired(ctx, time.Minute, &fakePodChecker{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(released).To(Equal([]cnet.IP{{IP: v4ia.IPs[0].IP}}))
+
+			_, err = ic.IPsByHandle(ctx, handle)
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	Describe("IPAM IP borrowing", func() {
@@ -2480,6 +2505,67 @@ var _ = testutils.E2eDatastoreDescribe("IPAM tests", testutils.DatastoreAll, fun
 		Entry("Claim affinity to the same block again but for host-b this time", testArgsClaimAff{"10.0.0.0/24", "host-b", false, []string{"10.0.0.0/24", "fd80:24e2:f998:72d6::/120"}, net.IP{}, 0, 4, nil}),
 	)
 
+	Describe("ClaimAffinity repeated and concurrent claims", func() {
+		ctx := context.Background()
+		cidr := cnet.MustParseNetwork("10.0.0.0/24")
+
+		BeforeEach(func() {
+			bc.Clean()
+			deleteAllPools()
+			applyPool("10.0.0.0/24", true, "")
+			applyNode(bc, kc, "host-a", nil)
+			applyNode(bc, kc, "host-b", nil)
+		})
+
+		AfterEach(func() {
+			deleteNode(bc, kc, "host-a")
+			deleteNode(bc, kc, "host-b")
+		})
+
+		It("should succeed when the same host claims the blocks again", func() {
+			claimed, failed, err := ic.ClaimAffinity(ctx, cidr, "host-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).To(HaveLen(4))
+			Expect(failed).To(BeEmpty())
+
+			claimedAgain, failed, err := ic.ClaimAffinity(ctx, cidr, "host-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimedAgain).To(ConsistOf(claimed))
+			Expect(failed).To(BeEmpty())
+		})
+
+		It("should give each block to exactly one host when hosts claim concurrently", func() {
+			type result struct {
+				claimed, failed []cnet.IPNet
+				err             error
+			}
+			hosts := []string{"host-a", "host-b"}
+			results := make([]result, len(hosts))
+			var wg sync.WaitGroup
+			for i, host := range hosts {
+				wg.Add(1)
+				go func(i int, host string) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					r := &results[i]
+					r.claimed, r.failed, r.err = ic.ClaimAffinity(ctx, cidr, host)
+				}(i, host)
+			}
+			wg.Wait()
+
+			owners := map[string]string{}
+			for i, r := range results {
+				Expect(r.err).NotTo(HaveOccurred())
+				Expect(len(r.claimed) + len(r.failed)).To(Equal(4))
+				for _, b := range r.claimed {
+					Expect(owners).NotTo(HaveKey(b.String()))
+					owners[b.String()] = hosts[i]
+				}
+			}
+			Expect(owners).To(HaveLen(4))
+		})
+	})
+
 	Describe("ensure that GetIPAMConfig and SetIPAMConfig work as expected", func() {
 		ctx := context.Background()
 
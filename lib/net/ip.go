@@ -15,11 +15,32 @@
 package net
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
 	"net"
+	"strings"
 )
 
+var (
+	privateNets   = mustParseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+	linkLocalNets = mustParseCIDRs("169.254.0.0/16", "fe80::/10")
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
 // Sub class net.IP so that we can add JSON marshalling and unmarshalling.
 type IP struct {
 	net.IP
@@ -52,7 +73,7 @@ func (i *IP) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// ParseIP returns an IP from a string
+// ParseIP returns an IP from a string, or nil if the string is not a valid IP address.
 func ParseIP(ip string) *IP {
 	addr := net.ParseIP(ip)
 	if addr == nil {
@@ -66,6 +87,50 @@ func ParseIP(ip string) *IP {
 	return &IP{addr}
 }
 
+// ParseIPStrict parses an IP address string in the same manner as ParseIP, but returns
+// an error for an empty string, for an IPv6 address with a zone ID (e.g. "fe80::1%eth0"),
+// and for an IPv4 address with a leading zero in any octet, since these may be
+// interpreted as octal on some systems.
+func ParseIPStrict(s string) (IP, error) {
+	if s == "" {
+		return IP{}, errors.New("empty IP address")
+	}
+	if strings.Contains(s, "%") {
+		return IP{}, fmt.Errorf("IP address %s has a zone ID", s)
+	}
+
+	// Check the octets of any dotted IPv4 part of the address.
+	if i := strings.LastIndex(s, ":"); i < len(s)-1 && strings.Contains(s[i+1:], ".") {
+		for _, octet := range strings.Split(s[i+1:], ".") {
+			if len(octet) > 1 && octet[0] == '0' {
+				return IP{}, fmt.Errorf("IP address %s has an octet with a leading zero", s)
+			}
+		}
+	}
+
+	ip := ParseIP(s)
+	if ip == nil {
+		return IP{}, fmt.Errorf("invalid IP address %s", s)
+	}
+	return *ip, nil
+}
+
+// ToCanonical returns the IP in its canonical form.  An IPv4 address is returned in its
+// 4-byte form, even if it is held as a 16-byte IPv4-mapped IPv6 address; any other address
+// is returned unchanged.
+func (i IP) ToCanonical() IP {
+	if v4 := i.To4(); v4 != nil {
+		return IP{v4}
+	}
+	return i
+}
+
+// Equal returns true if the IPs are the same address.  The canonical forms are compared, so
+// an IPv4 address is equal to the same address held as an IPv4-mapped IPv6 address.
+func (i IP) Equal(other IP) bool {
+	return bytes.Equal(i.ToCanonical().IP, other.ToCanonical().IP)
+}
+
 // Version returns the IP version for an IP, or 0 if the IP is not valid.
 func (i IP) Version() int {
 	if i.To4() != nil {
@@ -76,6 +141,67 @@ func (i IP) Version() int {
 	return 0
 }
 
+// IsPrivate returns true if the IP is in one of the RFC 1918 private IPv4 ranges
+// (10.0.0.0/8, 172.16.0.0/12 and 192.168.0.0/16), the RFC 4193 unique local IPv6 range
+// (fc00::/7), or is a loopback address.
+func (i IP) IsPrivate() bool {
+	return i.IP.IsLoopback() || containedIn(i.IP, privateNets)
+}
+
+// IsLinkLocal returns true if the IP is an IPv4 (169.254.0.0/16) or IPv6 (fe80::/10)
+// link local address.
+func (i IP) IsLinkLocal() bool {
+	return containedIn(i.IP, linkLocalNets)
+}
+
+func containedIn(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Increment returns the IP address following this one.  False is returned if the address
+// is the last address of its family (255.255.255.255 or ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff)
+// or is not valid.  An IPv4 address in IPv6 form is incremented as an IPv4 address, and the
+// result has the same form as the receiver.
+func (i IP) Increment() (IP, bool) {
+	return i.step(1)
+}
+
+// Decrement returns the IP address preceding this one.  False is returned if the address
+// is the first address of its family (0.0.0.0 or ::) or is not valid.  An IPv4 address in
+// IPv6 form is decremented as an IPv4 address, and the result has the same form as the
+// receiver.
+func (i IP) Decrement() (IP, bool) {
+	return i.step(-1)
+}
+
+// step adds delta, which must be 1 or -1, to the IP address.
+func (i IP) step(delta int) (IP, bool) {
+	if i.Version() == 0 {
+		return IP{}, false
+	}
+	result := make(net.IP, len(i.IP))
+	copy(result, i.IP)
+
+	// Only step the last four bytes of an IPv4 address, so that an IPv4 address in IPv6
+	// form does not step outside of the IPv4 range.
+	start := 0
+	if i.To4() != nil {
+		start = len(result) - net.IPv4len
+	}
+	for b := len(result) - 1; b >= start; b-- {
+		result[b] += byte(delta)
+		if (delta > 0 && result[b] != 0) || (delta < 0 && result[b] != 0xff) {
+			return IP{result}, true
+		}
+	}
+	return IP{}, false
+}
+
 // Network returns the IP address as a fully masked IPNet type.
 func (i *IP) Network() *IPNet {
 	// Unmarshaling an IPv4 address returns a 16-byte format of the
@@ -91,19 +217,15 @@ func (i *IP) Network() *IPNet {
 	return n
 }
 
-// MustParseIP parses the string into an IP.
+// MustParseIP parses the string into an IP, panicking with a message that includes the
+// string if it is not a valid IP address.  Use ParseIP to get nil for an invalid address
+// instead.
 func MustParseIP(i string) IP {
-	var ip IP
-	err := ip.UnmarshalText([]byte(i))
-	if err != nil {
-		panic(err)
-	}
-	// Always return IPv4 values as 4-bytes to be consistent with IPv4 IPNet
-	// representations.
-	if ip4 := ip.To4(); ip4 != nil {
-		ip.IP = ip4
+	ip := ParseIP(i)
+	if ip == nil {
+		panic(fmt.Sprintf("MustParseIP: invalid IP %q", i))
 	}
-	return ip
+	return *ip
 }
 
 func IPToBigInt(ip IP) *big.Int {
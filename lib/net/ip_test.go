@@ -0,0 +1,170 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net_test
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("IP", func() {
+	DescribeTable("ParseIPStrict with a valid IP",
+		func(s, expected string, version int) {
+			ip, err := cnet.ParseIPStrict(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal(expected))
+			Expect(ip.Version()).To(Equal(version))
+		},
+		Entry("IPv4", "10.0.0.1", "10.0.0.1", 4),
+		Entry("IPv4 with zero octets", "10.0.0.0", "10.0.0.0", 4),
+		Entry("IPv4 unspecified", "0.0.0.0", "0.0.0.0", 4),
+		Entry("IPv6", "fd00::1", "fd00::1", 6),
+		Entry("IPv6 with leading zeros in a group", "fd00:0000::0001", "fd00::1", 6),
+		Entry("IPv4-mapped IPv6", "::ffff:10.0.0.1", "10.0.0.1", 4),
+	)
+
+	DescribeTable("ParseIPStrict with an invalid IP",
+		func(s string) {
+			_, err := cnet.ParseIPStrict(s)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty string", ""),
+		Entry("IPv4 with a leading zero", "010.0.0.1"),
+		Entry("IPv4 with a leading zero in the last octet", "10.0.0.01"),
+		Entry("IPv4-mapped IPv6 with a leading zero", "::ffff:10.0.0.01"),
+		Entry("IPv6 with a zone ID", "fe80::1%eth0"),
+		Entry("not an IP", "foo"),
+		Entry("a CIDR", "10.0.0.1/32"),
+	)
+
+	DescribeTable("IP classification",
+		func(s string, private, linkLocal bool) {
+			ip := cnet.MustParseIP(s)
+			Expect(ip.IsPrivate()).To(Equal(private))
+			Expect(ip.IsLinkLocal()).To(Equal(linkLocal))
+		},
+		Entry("RFC 1918 10/8", "10.1.2.3", true, false),
+		Entry("RFC 1918 172.16/12", "172.31.255.255", true, false),
+		Entry("just outside 172.16/12", "172.32.0.1", false, false),
+		Entry("RFC 1918 192.168/16", "192.168.0.1", true, false),
+		Entry("IPv4 loopback", "127.0.0.1", true, false),
+		Entry("public IPv4", "8.8.8.8", false, false),
+		Entry("IPv4 link local", "169.254.1.1", false, true),
+		Entry("IPv4-mapped RFC 1918", "::ffff:10.0.0.1", true, false),
+		Entry("RFC 4193 fc00::/7", "fd00::1", true, false),
+		Entry("RFC 4193 lower half", "fc00::1", true, false),
+		Entry("IPv6 loopback", "::1", true, false),
+		Entry("public IPv6", "2001:db8::1", false, false),
+		Entry("IPv6 link local", "fe80::1", false, true),
+		Entry("just outside fe80::/10", "fec0::1", false, false),
+	)
+
+	DescribeTable("Increment and Decrement",
+		func(s, next, prev string) {
+			ip := cnet.MustParseIP(s)
+			n, ok := ip.Increment()
+			if next == "" {
+				Expect(ok).To(BeFalse())
+			} else {
+				Expect(ok).To(BeTrue())
+				Expect(n.String()).To(Equal(next))
+			}
+			p, ok := ip.Decrement()
+			if prev == "" {
+				Expect(ok).To(BeFalse())
+			} else {
+				Expect(ok).To(BeTrue())
+				Expect(p.String()).To(Equal(prev))
+			}
+			Expect(ip).To(Equal(cnet.MustParseIP(s)), "receiver should not be modified")
+		},
+		Entry("IPv4", "10.0.0.1", "10.0.0.2", "10.0.0.0"),
+		Entry("IPv4 carry", "10.0.0.255", "10.0.1.0", "10.0.0.254"),
+		Entry("IPv4 borrow", "10.1.0.0", "10.1.0.1", "10.0.255.255"),
+		Entry("last IPv4 address", "255.255.255.255", "", "255.255.255.254"),
+		Entry("first IPv4 address", "0.0.0.0", "0.0.0.1", ""),
+		Entry("IPv6", "fd00::ffff", "fd00::1:0", "fd00::fffe"),
+		Entry("last IPv6 address", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe"),
+		Entry("first IPv6 address", "::", "::1", ""),
+	)
+
+	It("should step an IPv4 address in IPv6 form within the IPv4 range", func() {
+		ip := cnet.IP{IP: net.ParseIP("::ffff:255.255.255.254")}
+		Expect(ip.IP).To(HaveLen(16))
+		n, ok := ip.Increment()
+		Expect(ok).To(BeTrue())
+		Expect(n.IP).To(HaveLen(16))
+		Expect(n.String()).To(Equal("255.255.255.255"))
+		_, ok = n.Increment()
+		Expect(ok).To(BeFalse())
+
+		_, ok = cnet.IP{IP: net.ParseIP("::ffff:0.0.0.0")}.Decrement()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should panic with the invalid address in MustParseIP", func() {
+		Expect(func() { cnet.MustParseIP("10.0.0.300") }).To(PanicWith(`MustParseIP: invalid IP "10.0.0.300"`))
+		Expect(func() { cnet.MustParseIP("") }).To(PanicWith(`MustParseIP: invalid IP ""`))
+	})
+
+	It("should return nil from ParseIP for an invalid address", func() {
+		Expect(cnet.ParseIP("10.0.0.300")).To(BeNil())
+		Expect(cnet.ParseIP("fd00::1")).To(Equal(&cnet.IP{IP: net.ParseIP("fd00::1")}))
+	})
+
+	It("should not step an invalid IP", func() {
+		_, ok := cnet.IP{}.Increment()
+		Expect(ok).To(BeFalse())
+		_, ok = cnet.IP{}.Decrement()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should return IPv4 addresses as 4 bytes", func() {
+		ip, err := cnet.ParseIPStrict("10.0.0.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ip.IP).To(HaveLen(4))
+	})
+})
+
+var _ = DescribeTable("IP canonical form",
+	func(ip cnet.IP, expectedLen int, expectedStr string) {
+		c := ip.ToCanonical()
+		Expect(c.IP).To(HaveLen(expectedLen))
+		Expect(c.String()).To(Equal(expectedStr))
+		Expect(c.Equal(ip)).To(BeTrue())
+		Expect(ip.Equal(c)).To(BeTrue())
+	},
+	Entry("4-byte IPv4", cnet.IP{IP: net.ParseIP("10.0.0.1").To4()}, 4, "10.0.0.1"),
+	Entry("IPv4-mapped IPv6", cnet.IP{IP: net.ParseIP("10.0.0.1").To16()}, 4, "10.0.0.1"),
+	Entry("IPv6", cnet.MustParseIP("fd00::1"), 16, "fd00::1"),
+	Entry("IPv4-compatible IPv6", cnet.MustParseIP("::a00:1"), 16, "::a00:1"),
+)
+
+var _ = DescribeTable("IP Equal",
+	func(a, b cnet.IP, expected bool) {
+		Expect(a.Equal(b)).To(Equal(expected))
+		Expect(b.Equal(a)).To(Equal(expected))
+	},
+	Entry("same IPv4", cnet.MustParseIP("10.0.0.1"), cnet.MustParseIP("10.0.0.1"), true),
+	Entry("IPv4 and IPv4-mapped IPv6", cnet.IP{IP: net.ParseIP("10.0.0.1").To4()}, cnet.IP{IP: net.ParseIP("10.0.0.1").To16()}, true),
+	Entry("different IPv4", cnet.MustParseIP("10.0.0.1"), cnet.MustParseIP("10.0.0.2"), false),
+	Entry("IPv4 and IPv4-compatible IPv6", cnet.MustParseIP("10.0.0.1"), cnet.MustParseIP("::a00:1"), false),
+	Entry("same IPv6", cnet.MustParseIP("fd00::1"), cnet.MustParseIP("fd00:0::1"), true),
+)
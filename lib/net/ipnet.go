@@ -16,7 +16,25 @@ package net
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net"
+	"strings"
+)
+
+var (
+	// ErrNotAnIP is returned (wrapped) by ParseIPNet if the string does not contain a
+	// valid IP address.
+	ErrNotAnIP = errors.New("not an IP address")
+
+	// ErrMissingPrefixLen is returned (wrapped) by ParseIPNet if the string contains a
+	// valid IP address followed by a "/" without a valid prefix length.
+	ErrMissingPrefixLen = errors.New("missing or invalid prefix length")
+
+	// ErrNoGateway is returned by Gateway for a host route, which has no address that can
+	// be used as a gateway.
+	ErrNoGateway = errors.New("host route has no gateway address")
 )
 
 // Sub class net.IPNet so that we can add JSON marshalling and unmarshalling.
@@ -56,6 +74,138 @@ func (i *IPNet) Version() int {
 	return 0
 }
 
+// AddressVersion returns the IP version (4 or 6) of the addresses in the IPNet, or 0 if
+// not a valid IP net.  Unlike Version, this is defined on the struct type.
+func (i IPNet) AddressVersion() int {
+	return i.Version()
+}
+
+// IsHostRoute returns true if the IPNet contains a single address, i.e. it is a /32 IPv4
+// network or a /128 IPv6 network.
+func (i IPNet) IsHostRoute() bool {
+	ones, bits := i.Mask.Size()
+	return bits != 0 && ones == bits
+}
+
+// IsDefaultRoute returns true if the IPNet is the IPv4 or IPv6 default route, i.e.
+// 0.0.0.0/0 or ::/0.
+func (i IPNet) IsDefaultRoute() bool {
+	ones, bits := i.Mask.Size()
+	return bits != 0 && ones == 0
+}
+
+// NetworkAddress returns the first address in the IPNet, i.e. the IP masked with the
+// network mask.
+func (i IPNet) NetworkAddress() IP {
+	return IP{i.IP.Mask(i.Mask)}
+}
+
+// BroadcastAddress returns the last address in the IPNet, i.e. the network address with
+// all of the host bits set.  IPv6 has no broadcast address, so for an IPv6 network this
+// is simply the address with the all-ones host part.
+func (i IPNet) BroadcastAddress() IP {
+	ip := i.IP.Mask(i.Mask)
+	if ip == nil {
+		return IP{}
+	}
+
+	// The mask may be the 16-byte form of an IPv4 mask, in which case the IPv4 mask is
+	// the last 4 bytes.
+	mask := i.Mask[len(i.Mask)-len(ip):]
+	for b := range ip {
+		ip[b] |= ^mask[b]
+	}
+	return IP{ip}
+}
+
+// Broadcast4 returns the broadcast address of an IPv4 network, and true.  IPv6 networks
+// have no broadcast address, and neither do /31 (point-to-point links, as per RFC 3021)
+// and /32 IPv4 networks, so for these an empty IP and false are returned.
+func (i IPNet) Broadcast4() (IP, bool) {
+	if i.Version() != 4 {
+		return IP{}, false
+	}
+	if ones, bits := i.Mask.Size(); bits-ones < 2 {
+		return IP{}, false
+	}
+	return i.BroadcastAddress(), true
+}
+
+// Gateway returns the conventional gateway address of the network, which is the first host
+// address (the network address + 1, e.g. 10.0.0.1 for 10.0.0.0/24).  A /31 IPv4 network
+// has no network address (as per RFC 3021), so its lower address is returned.  ErrNoGateway
+// is returned for a /32 or /128 host route.
+func (i IPNet) Gateway() (IP, error) {
+	if i.IsHostRoute() {
+		return IP{}, ErrNoGateway
+	}
+	network := i.NetworkAddress()
+	if network.IP == nil {
+		return IP{}, fmt.Errorf("invalid IP network %q", i.String())
+	}
+	if ones, bits := i.Mask.Size(); i.Version() == 4 && bits-ones == 1 {
+		return network, nil
+	}
+
+	// Increment the address in its natural length, so that an IPv6 address is never
+	// mistaken for an IPv4 address.
+	gw := network.To4()
+	if gw == nil {
+		gw = network.To16()
+	}
+	incrementBytes(gw)
+	return IP{gw}, nil
+}
+
+// Enumerate returns up to limit host addresses of the network, in order, and true if the
+// network has more host addresses than were returned.  The network and broadcast addresses
+// of an IPv4 network are not host addresses, except in a /31 network (a point-to-point
+// link, as per RFC 3021) and a /32 host route, where every address is a host address.
+// Every address of an IPv6 network is returned.
+func (i IPNet) Enumerate(limit int) ([]IP, bool) {
+	network := i.NetworkAddress()
+	if network.IP == nil {
+		return nil, false
+	}
+	if limit < 0 {
+		limit = 0
+	}
+
+	// Work with the address in its natural length, so that an IPv6 address is never
+	// mistaken for an IPv4 address.
+	ip := network.To4()
+	if ip == nil {
+		ip = network.To16()
+	}
+	ones, bits := i.Mask.Size()
+	available := big.NewInt(0).Lsh(big.NewInt(1), uint(bits-ones))
+	if len(ip) == net.IPv4len && bits-ones >= 2 {
+		available.Sub(available, big.NewInt(2))
+		incrementBytes(ip)
+	}
+
+	n := limit
+	if available.Cmp(big.NewInt(int64(limit))) < 0 {
+		n = int(available.Int64())
+	}
+	ips := make([]IP, 0, n)
+	for len(ips) < n {
+		ips = append(ips, IP{append(net.IP(nil), ip...)})
+		incrementBytes(ip)
+	}
+	return ips, available.Cmp(big.NewInt(int64(n))) > 0
+}
+
+// incrementBytes increments the address in place, wrapping on overflow.
+func incrementBytes(ip net.IP) {
+	for b := len(ip) - 1; b >= 0; b-- {
+		ip[b]++
+		if ip[b] != 0 {
+			break
+		}
+	}
+}
+
 // IsNetOverlap is a utility function that returns true if the two subnet have an overlap.
 func (i IPNet) IsNetOverlap(n net.IPNet) bool {
 	return n.Contains(i.IP) || i.Contains(n.IP)
@@ -110,6 +260,31 @@ func ParseCIDROrIP(c string) (*IP, *IPNet, error) {
 	return nil, nil, err
 }
 
+// ParseIPNet parses a CIDR, or an IP address which is treated as a /32 or /128 host route.
+// The IP address in the returned IPNet is not masked.  The error wraps ErrNotAnIP if the
+// string does not contain a valid IP address, and ErrMissingPrefixLen if it is a valid IP
+// address with a "/" but no valid prefix length; use errors.Is to check for these.
+func ParseIPNet(s string) (IPNet, error) {
+	if ip, cidr, err := ParseCIDR(s); err == nil {
+		return IPNet{net.IPNet{IP: ip.IP, Mask: cidr.Mask}}, nil
+	}
+
+	addr, prefixLen := s, ""
+	hasPrefix := false
+	if i := strings.Index(s, "/"); i >= 0 {
+		addr, prefixLen, hasPrefix = s[:i], s[i+1:], true
+	}
+	ip := ParseIP(addr)
+	if ip == nil {
+		return IPNet{}, fmt.Errorf("invalid IP network %q: %w", s, ErrNotAnIP)
+	}
+	if hasPrefix {
+		return IPNet{}, fmt.Errorf("invalid IP network %q: %w %q", s, ErrMissingPrefixLen, prefixLen)
+	}
+	bits := 8 * len(ip.IP)
+	return IPNet{net.IPNet{IP: ip.IP, Mask: net.CIDRMask(bits, bits)}}, nil
+}
+
 // String returns a friendly name for the network.  The standard net package
 // implements String() on the pointer, which means it will not be invoked on a
 // struct type, so we re-implement on the struct type.
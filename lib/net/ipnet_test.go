@@ -0,0 +1,207 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net_test
+
+import (
+	"errors"
+	"net"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("IPNet", func() {
+	DescribeTable("route classification",
+		func(cidr string, version int, hostRoute, defaultRoute bool) {
+			n := cnet.MustParseCIDR(cidr)
+			Expect(n.AddressVersion()).To(Equal(version))
+			Expect(n.IsHostRoute()).To(Equal(hostRoute))
+			Expect(n.IsDefaultRoute()).To(Equal(defaultRoute))
+		},
+		Entry("IPv4 /32", "10.0.0.1/32", 4, true, false),
+		Entry("IPv4 /31", "10.0.0.0/31", 4, false, false),
+		Entry("IPv4 /24", "10.0.0.0/24", 4, false, false),
+		Entry("IPv4 default route", "0.0.0.0/0", 4, false, true),
+		Entry("IPv6 /128", "fd00::1/128", 6, true, false),
+		Entry("IPv6 /64", "fd00::/64", 6, false, false),
+		Entry("IPv6 default route", "::/0", 6, false, true),
+	)
+
+	DescribeTable("network and broadcast addresses",
+		func(cidr, network, broadcast string) {
+			n := cnet.MustParseCIDR(cidr)
+			Expect(n.NetworkAddress().String()).To(Equal(network))
+			Expect(n.BroadcastAddress().String()).To(Equal(broadcast))
+		},
+		Entry("IPv4 /0", "0.0.0.0/0", "0.0.0.0", "255.255.255.255"),
+		Entry("IPv4 /1", "128.0.0.0/1", "128.0.0.0", "255.255.255.255"),
+		Entry("IPv4 /24", "10.1.2.0/24", "10.1.2.0", "10.1.2.255"),
+		Entry("IPv4 /31", "10.1.2.4/31", "10.1.2.4", "10.1.2.5"),
+		Entry("IPv4 /32", "10.1.2.3/32", "10.1.2.3", "10.1.2.3"),
+		Entry("IPv6 /64", "fd00:1::/64", "fd00:1::", "fd00:1::ffff:ffff:ffff:ffff"),
+		Entry("IPv6 /128", "fd00::1/128", "fd00::1", "fd00::1"),
+	)
+
+	DescribeTable("IPv4 broadcast address",
+		func(cidr, broadcast string, ok bool) {
+			n := cnet.MustParseCIDR(cidr)
+			b, isBroadcast := n.Broadcast4()
+			Expect(isBroadcast).To(Equal(ok))
+			if ok {
+				Expect(b.String()).To(Equal(broadcast))
+			} else {
+				Expect(b.IP).To(BeNil())
+			}
+		},
+		Entry("IPv4 /0", "0.0.0.0/0", "255.255.255.255", true),
+		Entry("IPv4 /24", "10.1.2.0/24", "10.1.2.255", true),
+		Entry("IPv4 /30", "10.1.2.4/30", "10.1.2.7", true),
+		Entry("IPv4 /31 has no broadcast address", "10.1.2.4/31", "", false),
+		Entry("IPv4 /32 is a host address", "10.1.2.3/32", "", false),
+		Entry("IPv6 has no broadcast address", "fd00:1::/64", "", false),
+	)
+
+	DescribeTable("gateway address",
+		func(cidr, gateway string) {
+			n := cnet.MustParseCIDR(cidr)
+			gw, err := n.Gateway()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gw.String()).To(Equal(gateway))
+			Expect(n.Contains(gw.IP)).To(BeTrue())
+		},
+		Entry("IPv4 /24", "10.1.2.0/24", "10.1.2.1"),
+		Entry("IPv4 /24 from a host address", "10.1.2.3/24", "10.1.2.1"),
+		Entry("IPv4 /30", "10.1.2.4/30", "10.1.2.5"),
+		Entry("IPv4 /31 uses the lower address", "10.1.2.4/31", "10.1.2.4"),
+		Entry("IPv4 /0", "0.0.0.0/0", "0.0.0.1"),
+		Entry("IPv6 /64", "fd00:1::/64", "fd00:1::1"),
+		Entry("IPv6 /127", "fd00:1::/127", "fd00:1::1"),
+		Entry("IPv6 /96 from the zero network", "::/96", "::1"),
+	)
+
+	DescribeTable("host address enumeration",
+		func(cidr string, limit int, expected []string, more bool) {
+			ips, hasMore := cnet.MustParseCIDR(cidr).Enumerate(limit)
+			strs := []string{}
+			for _, ip := range ips {
+				strs = append(strs, ip.String())
+			}
+			Expect(strs).To(Equal(expected))
+			Expect(hasMore).To(Equal(more))
+		},
+		Entry("IPv4 /24", "10.1.2.0/24", 5,
+			[]string{"10.1.2.1", "10.1.2.2", "10.1.2.3", "10.1.2.4", "10.1.2.5"}, true),
+		Entry("IPv4 /30", "10.1.2.4/30", 5, []string{"10.1.2.5", "10.1.2.6"}, false),
+		Entry("IPv4 /30 at the limit", "10.1.2.4/30", 2, []string{"10.1.2.5", "10.1.2.6"}, false),
+		Entry("IPv4 /31 uses both addresses", "10.1.2.4/31", 5, []string{"10.1.2.4", "10.1.2.5"}, false),
+		Entry("IPv4 /32", "10.1.2.3/32", 5, []string{"10.1.2.3"}, false),
+		Entry("IPv4 /0", "0.0.0.0/0", 2, []string{"0.0.0.1", "0.0.0.2"}, true),
+		Entry("IPv6 /64", "fd00:1::/64", 3, []string{"fd00:1::", "fd00:1::1", "fd00:1::2"}, true),
+		Entry("IPv6 /126 in the zero network", "::/126", 5, []string{"::", "::1", "::2", "::3"}, false),
+		Entry("IPv6 /128", "fd00::1/128", 5, []string{"fd00::1"}, false),
+		Entry("zero limit", "10.1.2.0/24", 0, []string{}, true),
+	)
+
+	It("should return each enumerated host address in its natural length", func() {
+		ips, _ := cnet.MustParseCIDR("10.1.2.0/30").Enumerate(5)
+		for _, ip := range ips {
+			Expect(ip.IP).To(HaveLen(4))
+		}
+		ips, _ = cnet.MustParseCIDR("::/126").Enumerate(5)
+		for _, ip := range ips {
+			Expect(ip.Version()).To(Equal(6))
+		}
+	})
+
+	It("should return ErrNoGateway for a host route", func() {
+		for _, cidr := range []string{"10.1.2.3/32", "fd00::1/128"} {
+			_, err := cnet.MustParseCIDR(cidr).Gateway()
+			Expect(err).To(Equal(cnet.ErrNoGateway), cidr)
+		}
+	})
+
+	It("should not modify the IPNet when calculating the gateway address", func() {
+		n := cnet.IPNet{IPNet: net.IPNet{IP: net.ParseIP("10.1.2.0"), Mask: net.CIDRMask(24, 32)}}
+		_, err := n.Gateway()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n.IP.String()).To(Equal("10.1.2.0"))
+	})
+
+	It("should not modify the IPNet when calculating the broadcast address", func() {
+		n := cnet.IPNet{IPNet: net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(24, 32)}}
+		Expect(n.NetworkAddress().String()).To(Equal("10.1.2.0"))
+		Expect(n.BroadcastAddress().String()).To(Equal("10.1.2.255"))
+		Expect(n.IP.String()).To(Equal("10.1.2.3"))
+	})
+
+	It("should handle an IPv4 network with a 16-byte mask", func() {
+		n := cnet.IPNet{IPNet: net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(120, 128)}}
+		Expect(n.NetworkAddress().String()).To(Equal("10.1.2.0"))
+		Expect(n.BroadcastAddress().String()).To(Equal("10.1.2.255"))
+	})
+
+	It("should treat an IP address as a host route", func() {
+		_, n, err := cnet.ParseCIDROrIP("10.0.0.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n.IsHostRoute()).To(BeTrue())
+		_, n, err = cnet.ParseCIDROrIP("fd00::1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n.IsHostRoute()).To(BeTrue())
+	})
+
+	It("should not classify an empty IPNet", func() {
+		n := cnet.IPNet{}
+		Expect(n.AddressVersion()).To(Equal(0))
+		Expect(n.IsHostRoute()).To(BeFalse())
+		Expect(n.IsDefaultRoute()).To(BeFalse())
+	})
+
+	DescribeTable("ParseIPNet",
+		func(s, expected string) {
+			n, err := cnet.ParseIPNet(s)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n.String()).To(Equal(expected))
+		},
+		Entry("IPv4 CIDR", "10.0.0.0/24", "10.0.0.0/24"),
+		Entry("unmasked IPv4 CIDR", "10.0.0.1/24", "10.0.0.1/24"),
+		Entry("IPv4 address", "10.0.0.1", "10.0.0.1/32"),
+		Entry("IPv6 CIDR", "fd00::/64", "fd00::/64"),
+		Entry("IPv6 address", "fd00::1", "fd00::1/128"),
+	)
+
+	DescribeTable("ParseIPNet errors",
+		func(s string, expected error) {
+			_, err := cnet.ParseIPNet(s)
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, expected)).To(BeTrue(), err.Error())
+		},
+		Entry("empty string", "", cnet.ErrNotAnIP),
+		Entry("garbage", "not-an-ip", cnet.ErrNotAnIP),
+		Entry("invalid IP with a prefix length", "10.0.0.300/24", cnet.ErrNotAnIP),
+		Entry("IPv4 address without a prefix length", "10.0.0.1/", cnet.ErrMissingPrefixLen),
+		Entry("IPv4 address with an invalid prefix length", "10.0.0.1/33", cnet.ErrMissingPrefixLen),
+		Entry("IPv6 address with a non-numeric prefix length", "fd00::1/x", cnet.ErrMissingPrefixLen),
+	)
+
+	It("should return a 4-byte IP for an IPv4 address", func() {
+		n, err := cnet.ParseIPNet("10.0.0.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n.IP).To(HaveLen(4))
+		Expect(n.IsHostRoute()).To(BeTrue())
+	})
+})
@@ -0,0 +1,107 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"math/big"
+	"net"
+)
+
+// IPRange is an inclusive range of IP addresses from First to Last.  A range is only valid
+// if both addresses have the same IP version and First is not after Last; an invalid range
+// contains no addresses.
+type IPRange struct {
+	First IP
+	Last  IP
+}
+
+// Contains returns true if the IP is within the range.
+func (r IPRange) Contains(ip IP) bool {
+	first, last, ok := r.bounds()
+	if !ok || ip.IP == nil || ip.Version() != r.First.Version() {
+		return false
+	}
+	i := IPToBigInt(ip)
+	return i.Cmp(first) >= 0 && i.Cmp(last) <= 0
+}
+
+// Size returns the number of addresses in the range.
+func (r IPRange) Size() *big.Int {
+	first, last, ok := r.bounds()
+	if !ok {
+		return big.NewInt(0)
+	}
+	size := big.NewInt(0).Sub(last, first)
+	return size.Add(size, big.NewInt(1))
+}
+
+// Subnets returns the minimal set of CIDRs that exactly cover the range, in address order.
+// Each CIDR is the largest aligned block that starts at the first uncovered address and does
+// not extend beyond the end of the range, as described in RFC 4632.  Nil is returned for an
+// invalid range.
+func (r IPRange) Subnets() []IPNet {
+	first, last, ok := r.bounds()
+	if !ok {
+		return nil
+	}
+	bits := 32
+	if r.First.Version() == 6 {
+		bits = 128
+	}
+
+	var subnets []IPNet
+	addr := first
+	for addr.Cmp(last) <= 0 {
+		// Start with the largest block aligned on the address, and halve it until it
+		// fits within the range.
+		hostBits := bits
+		if addr.Sign() != 0 {
+			hostBits = int(addr.TrailingZeroBits())
+		}
+		for {
+			blockLast := big.NewInt(0).Lsh(big.NewInt(1), uint(hostBits))
+			blockLast.Add(blockLast, addr).Sub(blockLast, big.NewInt(1))
+			if blockLast.Cmp(last) <= 0 {
+				break
+			}
+			hostBits--
+		}
+
+		subnets = append(subnets, IPNet{net.IPNet{
+			IP:   addr.FillBytes(make([]byte, bits/8)),
+			Mask: net.CIDRMask(bits-hostBits, bits),
+		}})
+		addr = big.NewInt(0).Add(addr, big.NewInt(0).Lsh(big.NewInt(1), uint(hostBits)))
+	}
+	return subnets
+}
+
+// String returns the range in the form "<first>-<last>".
+func (r IPRange) String() string {
+	return r.First.String() + "-" + r.Last.String()
+}
+
+// bounds returns the first and last addresses of the range as integers, and whether the
+// range is valid.
+func (r IPRange) bounds() (first, last *big.Int, ok bool) {
+	if r.First.IP == nil || r.Last.IP == nil || r.First.Version() != r.Last.Version() {
+		return nil, nil, false
+	}
+	first, last = IPToBigInt(r.First), IPToBigInt(r.Last)
+	if first.Cmp(last) > 0 {
+		return nil, nil, false
+	}
+	return first, last, true
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net_test
+
+import (
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("IPRange", func() {
+	newRange := func(first, last string) cnet.IPRange {
+		return cnet.IPRange{First: cnet.MustParseIP(first), Last: cnet.MustParseIP(last)}
+	}
+
+	DescribeTable("Subnets and Size",
+		func(first, last string, size *big.Int, expected ...string) {
+			r := newRange(first, last)
+			Expect(r.Size()).To(Equal(size))
+			subnets := []string{}
+			for _, s := range r.Subnets() {
+				subnets = append(subnets, s.String())
+			}
+			Expect(subnets).To(Equal(expected))
+		},
+		Entry("unaligned IPv4 range", "10.0.0.1", "10.0.0.5", big.NewInt(5),
+			"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31"),
+		Entry("single IPv4 address", "10.0.0.1", "10.0.0.1", big.NewInt(1), "10.0.0.1/32"),
+		Entry("aligned IPv4 block", "10.0.0.0", "10.0.0.255", big.NewInt(256), "10.0.0.0/24"),
+		Entry("IPv4 range across blocks", "10.0.0.255", "10.0.2.0", big.NewInt(258),
+			"10.0.0.255/32", "10.0.1.0/24", "10.0.2.0/32"),
+		Entry("all IPv4 addresses", "0.0.0.0", "255.255.255.255", bigPow2(32), "0.0.0.0/0"),
+		Entry("IPv6 range", "fd00::1", "fd00::10", big.NewInt(16),
+			"fd00::1/128", "fd00::2/127", "fd00::4/126", "fd00::8/125", "fd00::10/128"),
+		Entry("all IPv6 addresses", "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", bigPow2(128), "::/0"),
+	)
+
+	DescribeTable("Contains",
+		func(first, last, ip string, expected bool) {
+			Expect(newRange(first, last).Contains(cnet.MustParseIP(ip))).To(Equal(expected))
+		},
+		Entry("first address", "10.0.0.1", "10.0.0.5", "10.0.0.1", true),
+		Entry("last address", "10.0.0.1", "10.0.0.5", "10.0.0.5", true),
+		Entry("before the range", "10.0.0.1", "10.0.0.5", "10.0.0.0", false),
+		Entry("after the range", "10.0.0.1", "10.0.0.5", "10.0.0.6", false),
+		Entry("IPv6 address in the range", "fd00::1", "fd00::10", "fd00::a", true),
+		Entry("IPv6 address in an IPv4 range", "0.0.0.0", "255.255.255.255", "::1", false),
+	)
+
+	DescribeTable("invalid ranges",
+		func(first, last string) {
+			r := newRange(first, last)
+			Expect(r.Size()).To(Equal(big.NewInt(0)))
+			Expect(r.Subnets()).To(BeNil())
+			Expect(r.Contains(r.First)).To(BeFalse())
+		},
+		Entry("reversed", "10.0.0.5", "10.0.0.1"),
+		Entry("mixed IP versions", "10.0.0.1", "fd00::1"),
+	)
+})
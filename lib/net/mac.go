@@ -15,6 +15,7 @@
 package net
 
 import (
+	"bytes"
 	"encoding/json"
 	"net"
 )
@@ -24,6 +25,24 @@ type MAC struct {
 	net.HardwareAddr
 }
 
+// ParseMAC parses a MAC address in any of the formats accepted by the standard library
+// net.ParseMAC, e.g. "02-42-7D-C6-F0-80" or "0242.7dc6.f080".  Regardless of the input
+// format, the returned MAC is printed in the lowercase colon-separated form, e.g.
+// "02:42:7d:c6:f0:80", so that MAC addresses may be compared by their string form.
+func ParseMAC(s string) (*MAC, error) {
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		return nil, err
+	}
+	return &MAC{HardwareAddr: mac}, nil
+}
+
+// Equal returns true if the MAC addresses are the same.  This compares the hardware
+// address bytes, so is not affected by differences in the underlying slice capacity.
+func (m MAC) Equal(other MAC) bool {
+	return bytes.Equal(m.HardwareAddr, other.HardwareAddr)
+}
+
 // MarshalJSON interface for a MAC
 func (m MAC) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.String())
@@ -35,10 +54,10 @@ func (m *MAC) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
 	}
-	if mac, err := net.ParseMAC(s); err != nil {
+	if mac, err := ParseMAC(s); err != nil {
 		return err
 	} else {
-		m.HardwareAddr = mac
+		*m = *mac
 		return nil
 	}
 }
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("MAC", func() {
+	DescribeTable("ParseMAC should normalise to the lowercase colon-separated form",
+		func(input string) {
+			mac, err := cnet.ParseMAC(input)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mac.String()).To(Equal("02:42:7d:c6:f0:80"))
+		},
+		Entry("lowercase colon-separated", "02:42:7d:c6:f0:80"),
+		Entry("uppercase colon-separated", "02:42:7D:C6:F0:80"),
+		Entry("hyphen-separated", "02-42-7D-C6-F0-80"),
+		Entry("dot-separated", "0242.7dc6.f080"),
+	)
+
+	DescribeTable("ParseMAC should reject invalid MAC addresses",
+		func(input string) {
+			_, err := cnet.ParseMAC(input)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty", ""),
+		Entry("too short", "02:42:7d:c6:f0"),
+		Entry("invalid characters", "02:42:7d:c6:f0:zz"),
+	)
+
+	It("should compare MACs parsed from different formats as equal", func() {
+		a, err := cnet.ParseMAC("02-42-7D-C6-F0-80")
+		Expect(err).NotTo(HaveOccurred())
+		b, err := cnet.ParseMAC("02:42:7d:c6:f0:80")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.Equal(*b)).To(BeTrue())
+	})
+
+	It("should marshal an unmarshalled MAC in the normalised form", func() {
+		var mac cnet.MAC
+		Expect(json.Unmarshal([]byte(`"02-42-7D-C6-F0-80"`), &mac)).To(Succeed())
+		b, err := json.Marshal(mac)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`"02:42:7d:c6:f0:80"`))
+	})
+})
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// Pool wraps an IPNet to allow the addresses within the network to be enumerated.
+type Pool struct {
+	IPNet
+}
+
+// NewPool returns a Pool for the network containing the supplied IPNet.
+func NewPool(n IPNet) Pool {
+	p := Pool{}
+	p.Mask = n.Mask
+	p.IP = n.IP.Mask(n.Mask)
+	return p
+}
+
+// Size returns the total number of addresses in the pool, including the network and
+// broadcast addresses of an IPv4 pool.
+func (p Pool) Size() *big.Int {
+	ones, bits := p.Mask.Size()
+	return big.NewInt(0).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+// HostSize returns the number of usable host addresses in the pool.  For IPv4 pools this
+// excludes the network and broadcast addresses, except for /31 (point-to-point links, as per
+// RFC 3021) and /32 pools, where every address is usable.  Every address of an IPv6 pool is
+// usable.
+func (p Pool) HostSize() *big.Int {
+	size := p.Size()
+	if p.reservesNetworkAndBroadcast() {
+		size.Sub(size, big.NewInt(2))
+	}
+	return size
+}
+
+// NthAddress returns the nth (zero-indexed) usable host address in the pool.  An error
+// is returned if n is out of range.
+func (p Pool) NthAddress(n int) (IP, error) {
+	if n < 0 || big.NewInt(int64(n)).Cmp(p.HostSize()) >= 0 {
+		return IP{}, fmt.Errorf("address %d is out of range for pool %s with %s host addresses", n, p.String(), p.HostSize())
+	}
+
+	offset := big.NewInt(int64(n))
+	if p.reservesNetworkAndBroadcast() {
+		offset.Add(offset, big.NewInt(1))
+	}
+
+	// Convert back to an IP with the same length as the network address.  This avoids
+	// any ambiguity between IPv4 addresses and IPv6 addresses with leading zeros.
+	network := p.IP.To4()
+	if network == nil {
+		network = p.IP.To16()
+	}
+	addr := big.NewInt(0).SetBytes(network)
+	addr.Add(addr, offset)
+	return IP{net.IP(addr.FillBytes(make([]byte, len(network))))}, nil
+}
+
+// reservesNetworkAndBroadcast returns true if the first and last addresses of the pool are
+// the network and broadcast addresses, and so are not usable host addresses.
+func (p Pool) reservesNetworkAndBroadcast() bool {
+	ones, bits := p.Mask.Size()
+	return p.Version() == 4 && bits-ones > 1
+}
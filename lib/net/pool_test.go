@@ -0,0 +1,83 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package net_test
+
+import (
+	"math"
+	"math/big"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+func bigPow2(n uint) *big.Int {
+	return big.NewInt(0).Lsh(big.NewInt(1), n)
+}
+
+var _ = Describe("Pool", func() {
+	DescribeTable("Size and HostSize",
+		func(cidr string, size, hostSize *big.Int) {
+			p := cnet.NewPool(cnet.MustParseCIDR(cidr))
+			Expect(p.Size()).To(Equal(size))
+			Expect(p.HostSize()).To(Equal(hostSize))
+		},
+		Entry("IPv4 /24", "10.0.0.0/24", big.NewInt(256), big.NewInt(254)),
+		Entry("IPv4 /30", "10.0.0.0/30", big.NewInt(4), big.NewInt(2)),
+		Entry("IPv4 /31", "10.0.0.0/31", big.NewInt(2), big.NewInt(2)),
+		Entry("IPv4 /32", "10.0.0.1/32", big.NewInt(1), big.NewInt(1)),
+		Entry("IPv4 /0", "0.0.0.0/0", bigPow2(32), big.NewInt(0).Sub(bigPow2(32), big.NewInt(2))),
+		Entry("IPv6 /64", "fd00::/64", bigPow2(64), bigPow2(64)),
+		Entry("IPv6 /128", "fd00::1/128", big.NewInt(1), big.NewInt(1)),
+		Entry("IPv6 /0", "::/0", bigPow2(128), bigPow2(128)),
+	)
+
+	DescribeTable("NthAddress",
+		func(cidr string, n int, expected string) {
+			p := cnet.NewPool(cnet.MustParseCIDR(cidr))
+			ip, err := p.NthAddress(n)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal(expected))
+			Expect(ip.Version()).To(Equal(p.Version()))
+		},
+		Entry("first host of an IPv4 /24", "10.0.0.0/24", 0, "10.0.0.1"),
+		Entry("last host of an IPv4 /24", "10.0.0.0/24", 253, "10.0.0.254"),
+		Entry("first host of the IPv4 /0", "0.0.0.0/0", 0, "0.0.0.1"),
+		Entry("first address of an IPv4 /31", "10.0.0.0/31", 0, "10.0.0.0"),
+		Entry("second address of an IPv4 /31", "10.0.0.0/31", 1, "10.0.0.1"),
+		Entry("only address of an IPv4 /32", "10.0.0.1/32", 0, "10.0.0.1"),
+		Entry("first address of an IPv6 /64", "fd00::/64", 0, "fd00::"),
+		Entry("an address of an IPv6 /64", "fd00::/64", 255, "fd00::ff"),
+		Entry("only address of an IPv6 /128", "fd00::1/128", 0, "fd00::1"),
+		Entry("an address of the IPv6 /0", "::/0", 0x01020304, "::102:304"),
+		Entry("the last int address of the IPv6 /0", "::/0", math.MaxInt32, "::7fff:ffff"),
+		Entry("a pool specified with a host address", "10.0.0.10/24", 0, "10.0.0.1"),
+	)
+
+	DescribeTable("NthAddress out of range",
+		func(cidr string, n int) {
+			p := cnet.NewPool(cnet.MustParseCIDR(cidr))
+			_, err := p.NthAddress(n)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("negative index", "10.0.0.0/24", -1),
+		Entry("broadcast address of an IPv4 /24", "10.0.0.0/24", 254),
+		Entry("beyond an IPv4 /31", "10.0.0.0/31", 2),
+		Entry("beyond an IPv4 /32", "10.0.0.1/32", 1),
+		Entry("beyond an IPv6 /128", "fd00::1/128", 1),
+	)
+})
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// Subnets returns a channel that receives, in order, each of the subnets of the IPNet with
+// the supplied prefix length.  The channel is closed once all of the subnets have been
+// sent.  The subnets are generated by a goroutine as they are received, so the caller must
+// drain the channel to allow the goroutine to exit.  An error is returned if the prefix
+// length is shorter than that of the IPNet, or longer than the address length.
+func (i IPNet) Subnets(prefixLen int) (<-chan IPNet, error) {
+	network, count, step, err := i.subnetRange(prefixLen)
+	if err != nil {
+		return nil, err
+	}
+	mask := net.CIDRMask(prefixLen, len(network)*8)
+
+	subnets := make(chan IPNet)
+	go func() {
+		defer close(subnets)
+		addr := big.NewInt(0).SetBytes(network)
+		for n := big.NewInt(0); n.Cmp(count) < 0; n.Add(n, big.NewInt(1)) {
+			subnets <- IPNet{net.IPNet{IP: addr.FillBytes(make([]byte, len(network))), Mask: mask}}
+			addr.Add(addr, step)
+		}
+	}()
+	return subnets, nil
+}
+
+// maxSubnetsSliceCount is the maximum number of subnets returned by SubnetsSlice, which
+// allows for each /64 of an IPv6 /48, or each address of an IPv4 /16.
+const maxSubnetsSliceCount = 1 << 16
+
+// SubnetsSlice returns each of the subnets of the IPNet with the supplied prefix length.
+// See Subnets for details.  An error is also returned if there are more than 65536
+// subnets; use Subnets to enumerate more than that.
+func (i IPNet) SubnetsSlice(prefixLen int) ([]IPNet, error) {
+	_, count, _, err := i.subnetRange(prefixLen)
+	if err != nil {
+		return nil, err
+	}
+	if count.Cmp(big.NewInt(maxSubnetsSliceCount)) > 0 {
+		return nil, fmt.Errorf("%s has %s subnets with prefix length %d, more than the maximum of %d",
+			i.String(), count.String(), prefixLen, maxSubnetsSliceCount)
+	}
+	subnets, err := i.Subnets(prefixLen)
+	if err != nil {
+		return nil, err
+	}
+	var result []IPNet
+	for s := range subnets {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// subnetRange returns the network address of the IPNet, the number of subnets with the
+// supplied prefix length and the number of addresses in each of them.
+func (i IPNet) subnetRange(prefixLen int) (network net.IP, count, step *big.Int, err error) {
+	ones, bits := i.Mask.Size()
+	if bits == 0 {
+		return nil, nil, nil, fmt.Errorf("invalid network %s", i.String())
+	}
+
+	// Masking may shorten a 16-byte mask to the 4-byte form of an IPv4 network, in which
+	// case the prefix lengths are relative to the shorter address.
+	network = i.IP.Mask(i.Mask)
+	if excess := bits - len(network)*8; excess > 0 {
+		ones -= excess
+		bits -= excess
+	}
+	if prefixLen < ones || prefixLen > bits {
+		return nil, nil, nil, fmt.Errorf("prefix length %d is not valid for subnets of %s", prefixLen, i.String())
+	}
+	count = big.NewInt(0).Lsh(big.NewInt(1), uint(prefixLen-ones))
+	step = big.NewInt(0).Lsh(big.NewInt(1), uint(bits-prefixLen))
+	return network, count, step, nil
+}
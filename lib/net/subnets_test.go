@@ -0,0 +1,102 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package net_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+func subnetStrings(subnets []cnet.IPNet) []string {
+	var s []string
+	for _, n := range subnets {
+		s = append(s, n.String())
+	}
+	return s
+}
+
+var _ = Describe("Subnets", func() {
+	DescribeTable("SubnetsSlice",
+		func(cidr string, prefixLen int, expected []string) {
+			subnets, err := cnet.MustParseCIDR(cidr).SubnetsSlice(prefixLen)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnetStrings(subnets)).To(Equal(expected))
+		},
+		Entry("IPv4 /24 into /26s", "10.0.1.0/24", 26,
+			[]string{"10.0.1.0/26", "10.0.1.64/26", "10.0.1.128/26", "10.0.1.192/26"}),
+		Entry("IPv4 /24 into itself", "10.0.1.0/24", 24, []string{"10.0.1.0/24"}),
+		Entry("IPv4 /31 into /32s", "10.0.1.254/31", 32, []string{"10.0.1.254/32", "10.0.1.255/32"}),
+		Entry("IPv4 /0 into /1s", "0.0.0.0/0", 1, []string{"0.0.0.0/1", "128.0.0.0/1"}),
+		Entry("IPv6 /62 into /64s", "fd00:1:2:4::/62", 64,
+			[]string{"fd00:1:2:4::/64", "fd00:1:2:5::/64", "fd00:1:2:6::/64", "fd00:1:2:7::/64"}),
+	)
+
+	It("should send each /64 of an IPv6 /48 through the channel", func() {
+		subnets, err := cnet.MustParseCIDR("fd00:1:2::/48").Subnets(64)
+		Expect(err).NotTo(HaveOccurred())
+		count := 0
+		var first, last cnet.IPNet
+		for n := range subnets {
+			if count == 0 {
+				first = n
+			}
+			last = n
+			count++
+		}
+		Expect(count).To(Equal(65536))
+		Expect(first.String()).To(Equal("fd00:1:2::/64"))
+		Expect(last.String()).To(Equal("fd00:1:2:ffff::/64"))
+	})
+
+	It("should return IPv4 subnets in the 4-byte form", func() {
+		subnets, err := cnet.MustParseCIDR("10.0.1.0/24").SubnetsSlice(25)
+		Expect(err).NotTo(HaveOccurred())
+		for _, n := range subnets {
+			Expect(n.IP).To(HaveLen(4))
+			Expect(n.Version()).To(Equal(4))
+		}
+	})
+
+	DescribeTable("invalid prefix lengths",
+		func(cidr string, prefixLen int) {
+			_, err := cnet.MustParseCIDR(cidr).Subnets(prefixLen)
+			Expect(err).To(HaveOccurred())
+			_, err = cnet.MustParseCIDR(cidr).SubnetsSlice(prefixLen)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("shorter than the IPv4 network", "10.0.1.0/24", 23),
+		Entry("longer than an IPv4 address", "10.0.1.0/24", 33),
+		Entry("shorter than the IPv6 network", "fd00::/64", 48),
+		Entry("longer than an IPv6 address", "fd00::/64", 129),
+	)
+
+	It("should only return up to 65536 subnets in a slice", func() {
+		subnets, err := cnet.MustParseCIDR("10.0.0.0/16").SubnetsSlice(32)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subnets).To(HaveLen(65536))
+
+		_, err = cnet.MustParseCIDR("10.0.0.0/15").SubnetsSlice(32)
+		Expect(err).To(MatchError("10.0.0.0/15 has 131072 subnets with prefix length 32, more than the maximum of 65536"))
+		_, err = cnet.MustParseCIDR("fd00::/64").SubnetsSlice(128)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an empty IPNet", func() {
+		_, err := cnet.IPNet{}.Subnets(24)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -14,6 +14,8 @@
 
 package options
 
+import "time"
+
 // ListOptions is the query options a List or Watch operation in the Calico API.
 type ListOptions struct {
 	// The namespace of the resource to List or Watch.  If blank, the list or watch wildcards
@@ -36,4 +38,13 @@ type ListOptions struct {
 	// as a mechanism for enumerating endpoints within a Pod (since the name construction for a
 	// Workload endpoint is hierarchically constructed).
 	Prefix bool
+
+	// The node of the WorkloadEndpoints to List.  If blank, the list is not filtered by node.
+	// Only used when listing WorkloadEndpoints.
+	Node string
+
+	// The maximum time allowed for the List or Watch.  If nil, the operation is only bounded
+	// by the supplied context.  For a Watch this bounds the lifetime of the watch, which is
+	// terminated once the timeout expires.
+	Timeout *time.Duration
 }
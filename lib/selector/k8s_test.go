@@ -0,0 +1,183 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/projectcalico/libcalico-go/lib/selector"
+)
+
+var _ = Describe("FromK8sSelector", func() {
+	k8sLabels := []map[string]string{
+		{},
+		{"app": "web"},
+		{"app": "db"},
+		{"app": "web", "tier": "frontend"},
+		{"app": "web", "tier": "backend", "projectcalico.org/name": "x"},
+		{"tier": "frontend"},
+	}
+
+	DescribeTable("should match the same labels as the Kubernetes selector",
+		func(k8sSelector, expected string) {
+			ks, err := labels.Parse(k8sSelector)
+			Expect(err).NotTo(HaveOccurred())
+			sel, err := selector.FromK8sSelector(ks)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sel.String()).To(Equal(expected))
+
+			// The string form should parse to an equivalent Calico selector.
+			reparsed, err := selector.Parse(sel.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reparsed.String()).To(Equal(sel.String()))
+
+			for _, l := range k8sLabels {
+				Expect(sel.Evaluate(l)).To(Equal(ks.Matches(labels.Set(l))), "labels: %v", l)
+			}
+		},
+		Entry("everything", "", "all()"),
+		Entry("equals", "app=web", `app == "web"`),
+		Entry("double equals", "app==web", `app == "web"`),
+		Entry("not equals", "app!=web", `app != "web"`),
+		Entry("in", "app in (web, db)", `app in {"db", "web"}`),
+		Entry("not in", "app notin (web)", `app not in {"web"}`),
+		Entry("exists", "tier", `has(tier)`),
+		Entry("does not exist", "!tier", `!has(tier)`),
+		Entry("prefixed key", "projectcalico.org/name=x", `projectcalico.org/name == "x"`),
+		Entry("multiple requirements", "app in (web), !tier",
+			`(app in {"web"} && !has(tier))`),
+	)
+
+	It("should reject requirements without a Calico equivalent", func() {
+		ks, err := labels.Parse("replicas>1")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = selector.FromK8sSelector(ks)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no Calico equivalent"))
+	})
+
+	It("should reject a selector that matches nothing", func() {
+		_, err := selector.FromK8sSelector(labels.Nothing())
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ToK8sRequirements", func() {
+	k8sLabels := []map[string]string{
+		{},
+		{"app": "web"},
+		{"app": "db"},
+		{"app": "web", "tier": "frontend"},
+		{"app": "web", "tier": "backend", "projectcalico.org/name": "x"},
+		{"tier": "frontend"},
+	}
+
+	toK8sSelector := func(reqs []metav1.LabelSelectorRequirement) labels.Selector {
+		ks, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: reqs})
+		Expect(err).NotTo(HaveOccurred())
+		return ks
+	}
+
+	DescribeTable("should convert to requirements matching the same labels",
+		func(calicoSelector string, expected []metav1.LabelSelectorRequirement) {
+			sel, err := selector.Parse(calicoSelector)
+			Expect(err).NotTo(HaveOccurred())
+			reqs, err := selector.ToK8sRequirements(sel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs).To(Equal(expected))
+
+			ks := toK8sSelector(reqs)
+			for _, l := range k8sLabels {
+				Expect(ks.Matches(labels.Set(l))).To(Equal(sel.Evaluate(l)), "labels: %v", l)
+			}
+
+			// Converting back should give an equivalent Calico selector.
+			roundTrip, err := selector.FromK8sSelector(ks)
+			Expect(err).NotTo(HaveOccurred())
+			for _, l := range k8sLabels {
+				Expect(roundTrip.Evaluate(l)).To(Equal(sel.Evaluate(l)), "labels: %v", l)
+			}
+		},
+		Entry("everything", "all()", []metav1.LabelSelectorRequirement{}),
+		Entry("equals", `app == "web"`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}},
+		}),
+		Entry("not equals", `app != "web"`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"web"}},
+		}),
+		Entry("in", `app in {"web", "db"}`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"db", "web"}},
+		}),
+		Entry("not in", `app not in {"web"}`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"web"}},
+		}),
+		Entry("has", "has(tier)", []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+		}),
+		Entry("not has", "!has(tier)", []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpDoesNotExist},
+		}),
+		Entry("conjunction", `app == "web" && has(tier) && projectcalico.org/name != "x"`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}},
+			{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+			{Key: "projectcalico.org/name", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"x"}},
+		}),
+		Entry("nested conjunction", `app == "web" && (has(tier) && all())`, []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}},
+			{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+		}),
+	)
+
+	DescribeTable("should round trip Kubernetes selectors",
+		func(k8sSelector string) {
+			ks, err := labels.Parse(k8sSelector)
+			Expect(err).NotTo(HaveOccurred())
+			sel, err := selector.FromK8sSelector(ks)
+			Expect(err).NotTo(HaveOccurred())
+			reqs, err := selector.ToK8sRequirements(sel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(toK8sSelector(reqs).String()).To(Equal(ks.String()))
+		},
+		Entry("everything", ""),
+		Entry("in", "app in (db,web)"),
+		Entry("not in", "app notin (web)"),
+		Entry("exists", "tier"),
+		Entry("does not exist", "!tier"),
+		Entry("multiple requirements", "app in (web),!tier"),
+	)
+
+	DescribeTable("should reject selectors without a Kubernetes equivalent",
+		func(calicoSelector string) {
+			sel, err := selector.Parse(calicoSelector)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = selector.ToK8sRequirements(sel)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no Kubernetes equivalent"))
+		},
+		Entry("top level OR", `app == "web" || has(tier)`),
+		Entry("nested OR", `has(tier) && (app == "web" || app == "db")`),
+		Entry("negated expression", `!(app == "web")`),
+		Entry("negated conjunction", `!(has(app) && has(tier))`),
+		Entry("contains", `app contains "we"`),
+		Entry("starts with", `app starts with "we"`),
+		Entry("global", "global()"),
+		Entry("invalid Kubernetes label value", `app == "not a valid value"`),
+	)
+})
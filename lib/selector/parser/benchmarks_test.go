@@ -0,0 +1,54 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/projectcalico/libcalico-go/lib/selector"
+	"github.com/projectcalico/libcalico-go/lib/selector/parser"
+)
+
+var benchmarkResult bool
+
+// endpointLabels returns 100 labels, similar to those of a heavily labelled endpoint.
+func endpointLabels() map[string]string {
+	labels := map[string]string{}
+	for i := 0; i < 100; i++ {
+		labels[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	return labels
+}
+
+const benchmarkSelector = `label-1 == "value-1" && has(label-50) && label-99 in {"value-98", "value-99"} && ` +
+	`(label-7 starts with "val" || label-200 == "missing") && label-3 != "value-4" && !has(label-100)`
+
+func BenchmarkSelectorEvaluateMap(b *testing.B) {
+	sel := selector.MustParse(benchmarkSelector)
+	labels := endpointLabels()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult = sel.Evaluate(labels)
+	}
+}
+
+func BenchmarkSelectorEvaluateLabels(b *testing.B) {
+	sel := selector.MustParse(benchmarkSelector)
+	labels := parser.MapAsLabels(endpointLabels())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkResult = sel.EvaluateLabels(labels)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// FromK8sSelector converts a parsed Kubernetes label selector into the equivalent Selector.
+// An error is returned if the selector contains a requirement that cannot be expressed as a
+// Calico selector.
+func FromK8sSelector(s labels.Selector) (Selector, error) {
+	reqs, selectable := s.Requirements()
+	if !selectable {
+		return nil, fmt.Errorf("selector %q matches nothing and has no Calico equivalent", s.String())
+	}
+	if len(reqs) == 0 {
+		return &selectorRoot{root: &AllNode{}}, nil
+	}
+
+	operands := make([]node, 0, len(reqs))
+	for i := range reqs {
+		n, err := k8sRequirementToNode(&reqs[i])
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, n)
+	}
+	if len(operands) == 1 {
+		return &selectorRoot{root: operands[0]}, nil
+	}
+	return &selectorRoot{root: &AndNode{Operands: operands}}, nil
+}
+
+// k8sRequirementToNode returns the AST node for a single Kubernetes selector requirement.
+func k8sRequirementToNode(r *labels.Requirement) (node, error) {
+	values := r.Values().List()
+	switch r.Operator() {
+	case selection.Equals, selection.DoubleEquals:
+		if len(values) != 1 {
+			return nil, fmt.Errorf("requirement %q must have exactly one value", r.String())
+		}
+		return &LabelEqValueNode{LabelName: r.Key(), Value: values[0]}, nil
+	case selection.NotEquals:
+		if len(values) != 1 {
+			return nil, fmt.Errorf("requirement %q must have exactly one value", r.String())
+		}
+		return &LabelNeValueNode{LabelName: r.Key(), Value: values[0]}, nil
+	case selection.In:
+		return &LabelInSetNode{LabelName: r.Key(), Value: ConvertToStringSetInPlace(values)}, nil
+	case selection.NotIn:
+		return &LabelNotInSetNode{LabelName: r.Key(), Value: ConvertToStringSetInPlace(values)}, nil
+	case selection.Exists:
+		return &HasNode{LabelName: r.Key()}, nil
+	case selection.DoesNotExist:
+		return &NotNode{Operand: &HasNode{LabelName: r.Key()}}, nil
+	}
+	return nil, fmt.Errorf("requirement %q uses operator %q, which has no Calico equivalent", r.String(), r.Operator())
+}
+
+// ToK8sRequirements converts a parsed selector into the equivalent Kubernetes label selector
+// requirements, which must all be satisfied for the selector to match.  Only a conjunction
+// of label comparisons can be represented; an error is returned if the selector contains an
+// OR, a negation of anything other than has(), or an operator without a Kubernetes
+// equivalent, such as "contains".
+func ToK8sRequirements(s Selector) ([]metav1.LabelSelectorRequirement, error) {
+	sel, ok := s.(*selectorRoot)
+	if !ok {
+		return nil, fmt.Errorf("selector %q was not returned by Parse", s.String())
+	}
+	reqs, err := appendK8sRequirements([]metav1.LabelSelectorRequirement{}, sel.root)
+	if err != nil {
+		return nil, fmt.Errorf("selector %q has no Kubernetes equivalent: %v", sel.String(), err)
+	}
+	return reqs, nil
+}
+
+// appendK8sRequirements appends the Kubernetes requirements for the supplied AST node.
+func appendK8sRequirements(reqs []metav1.LabelSelectorRequirement, n node) ([]metav1.LabelSelectorRequirement, error) {
+	var r metav1.LabelSelectorRequirement
+	switch n := n.(type) {
+	case *AllNode:
+		return reqs, nil
+	case *AndNode:
+		var err error
+		for _, op := range n.Operands {
+			if reqs, err = appendK8sRequirements(reqs, op); err != nil {
+				return nil, err
+			}
+		}
+		return reqs, nil
+	case *OrNode:
+		return nil, fmt.Errorf("OR expressions cannot be represented")
+	case *LabelEqValueNode:
+		r = newK8sRequirement(n.LabelName, metav1.LabelSelectorOpIn, n.Value)
+	case *LabelNeValueNode:
+		r = newK8sRequirement(n.LabelName, metav1.LabelSelectorOpNotIn, n.Value)
+	case *LabelInSetNode:
+		r = newK8sRequirement(n.LabelName, metav1.LabelSelectorOpIn, n.Value...)
+	case *LabelNotInSetNode:
+		r = newK8sRequirement(n.LabelName, metav1.LabelSelectorOpNotIn, n.Value...)
+	case *HasNode:
+		r = newK8sRequirement(n.LabelName, metav1.LabelSelectorOpExists)
+	case *NotNode:
+		has, ok := n.Operand.(*HasNode)
+		if !ok {
+			return nil, fmt.Errorf("only has() may be negated")
+		}
+		r = newK8sRequirement(has.LabelName, metav1.LabelSelectorOpDoesNotExist)
+	default:
+		return nil, fmt.Errorf("%q cannot be represented", strings.Join(n.collectFragments(nil), ""))
+	}
+
+	// Check that the label name and values are valid in Kubernetes.
+	if _, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{r},
+	}); err != nil {
+		return nil, err
+	}
+	return append(reqs, r), nil
+}
+
+// newK8sRequirement returns a Kubernetes requirement with a copy of the supplied values.
+func newK8sRequirement(key string, op metav1.LabelSelectorOperator, values ...string) metav1.LabelSelectorRequirement {
+	return metav1.LabelSelectorRequirement{Key: key, Operator: op, Values: append([]string(nil), values...)}
+}
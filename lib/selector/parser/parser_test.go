@@ -81,6 +81,16 @@ var selectorTests = []selectorTest{
 			{"a": `"`},
 			{"a": `'`},
 		}},
+	{`a notin {"'", '"', "c"}`,
+		[]map[string]string{
+			{},
+			{"a": "e"},
+		},
+		[]map[string]string{
+			{"a": "c"},
+			{"a": `"`},
+			{"a": `'`},
+		}},
 
 	{`a == 'a'`, []map[string]string{{"a": "a"}}, []map[string]string{}},
 	{`a == "a"`, []map[string]string{{"a": "a"}}, []map[string]string{}},
@@ -120,6 +130,7 @@ var selectorTests = []selectorTest{
 	{`a in {"a", "b"}`, []map[string]string{{"a": "a"}}, []map[string]string{}},
 	{`a in {"a", "b"}`, []map[string]string{{"a": "b"}}, []map[string]string{}},
 	{`a not in {"d", "e"}`, []map[string]string{{"a": "a"}}, []map[string]string{}},
+	{`a notin {"d", "e"}`, []map[string]string{{"a": "a"}}, []map[string]string{}},
 	{`has(a)`, []map[string]string{{"a": "b"}}, []map[string]string{}},
 	{`!has(a)`, []map[string]string{{"b": "b"}}, []map[string]string{}},
 	{``, []map[string]string{{}}, []map[string]string{}},
@@ -138,6 +149,7 @@ var selectorTests = []selectorTest{
 	{`a != 'a'`, []map[string]string{}, []map[string]string{{"a": "a"}}},
 	{`a in {"a"}`, []map[string]string{}, []map[string]string{{"a": "b"}}},
 	{`a not in {"a"}`, []map[string]string{}, []map[string]string{{"a": "a"}}},
+	{`a notin {"a"}`, []map[string]string{}, []map[string]string{{"a": "a"}}},
 	{`a in {"a", "b"}`, []map[string]string{}, []map[string]string{{"a": "c"}}},
 	{`has(b)`, []map[string]string{}, []map[string]string{{"a": "b"}}},
 	{`!!has(b)`, []map[string]string{}, []map[string]string{{"a": "b"}}},
@@ -213,6 +225,8 @@ var canonicalisationTests = []struct {
 	{`a startswith '"'`, `a starts with '"'`, ""},
 	{`a endswith "'"`, `a ends with "'"`, ""},
 	{`a!='"'`, `a != '"'`, ""},
+	{`a notin {"b"}`, `a not in {"b"}`, ""},
+	{`a not in{"b"}`, `a not in {"b"}`, ""},
 	// Set items get sorted/de-duped.
 	{`a in {"d"}`, `a in {"d"}`, ""},
 	{`a in {"a", "b"}`, `a in {"a", "b"}`, ""},
@@ -334,6 +348,7 @@ var _ = Describe("Visitor", func() {
 		Entry("should visit a NotNode", "!(k == 'v')", "!visited/k == \"v\"", testVisitor),
 		Entry("should visit a LabelInSetNode", "k in {'v'}", "visited/k in {\"v\"}", testVisitor),
 		Entry("should visit a LabelNotInSetNode", "k not in {'v'}", "visited/k not in {\"v\"}", testVisitor),
+		Entry("should visit a LabelNotInSetNode using notin", "k notin {'v'}", "visited/k not in {\"v\"}", testVisitor),
 		Entry("should visit a big complex selector",
 			"!(!(k == 'v' && has(t) || all()) && (a in {'b', 'c'}))",
 			"!(!((visited/k == \"v\" && has(visited/t)) || all()) && visited/a in {\"b\", \"c\"})",
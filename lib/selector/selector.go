@@ -14,7 +14,14 @@
 
 package selector
 
-import "github.com/projectcalico/libcalico-go/lib/selector/parser"
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/projectcalico/libcalico-go/lib/selector/parser"
+)
 
 // Selector represents a label selector.
 type Selector interface {
@@ -36,3 +43,32 @@ type Selector interface {
 func Parse(selector string) (sel Selector, err error) {
 	return parser.Parse(selector)
 }
+
+// MustParse is like Parse but panics if the selector cannot be parsed.  It simplifies the
+// initialization of selectors from string literals, in the same way as regexp.MustCompile.
+func MustParse(selector string) Selector {
+	sel, err := Parse(selector)
+	if err != nil {
+		panic(fmt.Sprintf("selector: Parse(%q): %v", selector, err))
+	}
+	return sel
+}
+
+// FromK8sSelector converts a parsed Kubernetes label selector into the equivalent Selector.
+// The In, NotIn, Exists, DoesNotExist, Equals and NotEquals operators are supported; an error
+// is returned for any other requirement, such as the numeric Gt and Lt operators.
+func FromK8sSelector(s labels.Selector) (Selector, error) {
+	return parser.FromK8sSelector(s)
+}
+
+// ToK8sRequirements converts a Selector returned by this package into the equivalent
+// Kubernetes label selector requirements.  Only selectors that are a conjunction of equality,
+// set membership and has()/!has() comparisons can be converted; an error is returned for any
+// other selector.
+func ToK8sRequirements(sel Selector) ([]metav1.LabelSelectorRequirement, error) {
+	ps, ok := sel.(parser.Selector)
+	if !ok {
+		return nil, fmt.Errorf("selector %q was not parsed by the selector package", sel.String())
+	}
+	return parser.ToK8sRequirements(ps)
+}
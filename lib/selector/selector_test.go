@@ -0,0 +1,40 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/selector"
+)
+
+var _ = Describe("MustParse", func() {
+	It("should return the parsed selector", func() {
+		sel := selector.MustParse(`a == "b" && has(c)`)
+		Expect(sel.String()).To(Equal(`(a == "b" && has(c))`))
+		Expect(sel.Evaluate(map[string]string{"a": "b", "c": ""})).To(BeTrue())
+	})
+
+	It("should panic with the selector and the error for an invalid selector", func() {
+		defer func() {
+			r := recover()
+			Expect(r).To(BeAssignableToTypeOf(""))
+			Expect(r).To(HavePrefix(`selector: Parse("a == "): `))
+		}()
+		selector.MustParse(`a == `)
+		Fail("MustParse did not panic")
+	})
+})
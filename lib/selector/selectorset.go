@@ -0,0 +1,121 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector
+
+import "github.com/projectcalico/libcalico-go/lib/selector/parser"
+
+// SelectorSet is a pool of selectors that can be evaluated together against a single set
+// of labels.  The selectors are indexed by the label names that they reference so that a
+// selector is only evaluated if the labels contain at least one of those names.
+//
+// A selector that references none of the label names only sees absent labels, so its
+// result is the same as its result against empty labels, which is calculated when the
+// selector is added.  This keeps negated expressions, such as !has(a), correct.
+type SelectorSet struct {
+	entries     []selectorSetEntry
+	byLabelName map[string][]int
+}
+
+type selectorSetEntry struct {
+	sel Selector
+	// matchesEmpty is the result of evaluating the selector against empty labels.
+	matchesEmpty bool
+	// alwaysEvaluate is set if the label names referenced by the selector are not
+	// known, in which case the selector is evaluated against every set of labels.
+	alwaysEvaluate bool
+}
+
+// NewSelectorSet returns a SelectorSet containing the supplied selectors.
+func NewSelectorSet(selectors ...Selector) *SelectorSet {
+	s := &SelectorSet{byLabelName: map[string][]int{}}
+	for _, sel := range selectors {
+		s.Add(sel)
+	}
+	return s
+}
+
+// Add adds a selector to the set.
+func (s *SelectorSet) Add(sel Selector) {
+	idx := len(s.entries)
+	entry := selectorSetEntry{
+		sel:          sel,
+		matchesEmpty: sel.Evaluate(nil),
+	}
+
+	if ps, ok := sel.(parser.Selector); ok {
+		v := labelNamesVisitor{names: map[string]bool{}}
+		ps.AcceptVisitor(&v)
+		for name := range v.names {
+			s.byLabelName[name] = append(s.byLabelName[name], idx)
+		}
+	} else {
+		entry.alwaysEvaluate = true
+	}
+	s.entries = append(s.entries, entry)
+}
+
+// Len returns the number of selectors in the set.
+func (s *SelectorSet) Len() int {
+	return len(s.entries)
+}
+
+// Evaluate returns the selectors in the set that match the given labels, in the order
+// that they were added.
+func (s *SelectorSet) Evaluate(labels map[string]string) []Selector {
+	candidates := make([]bool, len(s.entries))
+	for name := range labels {
+		for _, idx := range s.byLabelName[name] {
+			candidates[idx] = true
+		}
+	}
+
+	var matches []Selector
+	for idx, entry := range s.entries {
+		if candidates[idx] || entry.alwaysEvaluate {
+			if entry.sel.Evaluate(labels) {
+				matches = append(matches, entry.sel)
+			}
+		} else if entry.matchesEmpty {
+			matches = append(matches, entry.sel)
+		}
+	}
+	return matches
+}
+
+// labelNamesVisitor collects the label names referenced by a selector.
+type labelNamesVisitor struct {
+	names map[string]bool
+}
+
+func (v *labelNamesVisitor) Visit(n interface{}) {
+	switch np := n.(type) {
+	case *parser.LabelEqValueNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelNeValueNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelContainsValueNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelStartsWithValueNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelEndsWithValueNode:
+		v.names[np.LabelName] = true
+	case *parser.HasNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelInSetNode:
+		v.names[np.LabelName] = true
+	case *parser.LabelNotInSetNode:
+		v.names[np.LabelName] = true
+	}
+}
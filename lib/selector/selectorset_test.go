@@ -0,0 +1,126 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/selector"
+)
+
+var setSelectors = []string{
+	`a == "a1"`,
+	`a != "a1"`,
+	`has(b)`,
+	`!has(b)`,
+	`a == "a1" && b == "b1"`,
+	`a == "a1" || !has(c)`,
+	`!(c in {"c1", "c2"})`,
+	`c not in {"c1"}`,
+	`d starts with "d" || e ends with "e"`,
+	`all()`,
+	`global()`,
+}
+
+var _ = Describe("SelectorSet", func() {
+	var set *selector.SelectorSet
+	BeforeEach(func() {
+		set = selector.NewSelectorSet()
+		for _, s := range setSelectors {
+			set.Add(selector.MustParse(s))
+		}
+	})
+
+	It("should contain the selectors", func() {
+		Expect(set.Len()).To(Equal(len(setSelectors)))
+	})
+
+	DescribeTable("should return the same matches as evaluating each selector",
+		func(labels map[string]string) {
+			expected := []string{}
+			for _, s := range setSelectors {
+				if selector.MustParse(s).Evaluate(labels) {
+					expected = append(expected, selector.MustParse(s).String())
+				}
+			}
+			matches := []string{}
+			for _, sel := range set.Evaluate(labels) {
+				matches = append(matches, sel.String())
+			}
+			Expect(matches).To(Equal(expected))
+		},
+		Entry("nil labels", nil),
+		Entry("unreferenced labels", map[string]string{"z": "z1"}),
+		Entry("a only", map[string]string{"a": "a1"}),
+		Entry("a and b", map[string]string{"a": "a1", "b": "b1"}),
+		Entry("a with another value", map[string]string{"a": "a2", "z": "z1"}),
+		Entry("excluded c", map[string]string{"c": "c1"}),
+		Entry("other c", map[string]string{"c": "c3"}),
+		Entry("d and e", map[string]string{"d": "d1", "e": "1"}),
+	)
+
+	It("should be empty when created with no selectors", func() {
+		Expect(selector.NewSelectorSet().Evaluate(map[string]string{"a": "a1"})).To(BeEmpty())
+	})
+})
+
+// benchmarkLabels returns 50 labels, similar to those of a heavily labelled endpoint.
+func benchmarkLabels() map[string]string {
+	labels := map[string]string{}
+	for i := 0; i < 50; i++ {
+		labels[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	return labels
+}
+
+// benchmarkSelectors returns 1000 selectors, most of which reference labels that the
+// benchmark endpoint does not have.
+func benchmarkSelectors() []selector.Selector {
+	var sels []selector.Selector
+	for i := 0; i < 1000; i++ {
+		sels = append(sels, selector.MustParse(fmt.Sprintf(`label-%d == "value-%d" && has(role-%d)`, i, i, i%10)))
+	}
+	return sels
+}
+
+var benchmarkMatches int
+
+func BenchmarkSelectorLoop(b *testing.B) {
+	sels := benchmarkSelectors()
+	labels := benchmarkLabels()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkMatches = 0
+		for _, sel := range sels {
+			if sel.Evaluate(labels) {
+				benchmarkMatches++
+			}
+		}
+	}
+}
+
+func BenchmarkSelectorSet(b *testing.B) {
+	set := selector.NewSelectorSet(benchmarkSelectors()...)
+	labels := benchmarkLabels()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchmarkMatches = len(set.Evaluate(labels))
+	}
+}
@@ -178,7 +178,7 @@ func Tokenize(input string) (tokens []Token, err error) {
 					tokens = append(tokens, Token{TokEndsWith, nil})
 					input = input[idxs[1]:]
 				} else if idxs := notInRegex.FindStringIndex(input); idxs != nil {
-					// Found "not in"
+					// Found "not in" (the whitespace is optional, so this also matches "notin")
 					tokens = append(tokens, Token{TokNotIn, nil})
 					input = input[idxs[1]:]
 				} else if idxs := inRegex.FindStringIndex(input); idxs != nil {
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package set
+
+import "sort"
+
+// StringSet is a set of strings.  Unlike Set, the members are typed, and the set
+// operations return a new StringSet rather than modifying the receiver.
+type StringSet map[string]empty
+
+// NewStringSet returns a StringSet containing the supplied members.
+func NewStringSet(members ...string) StringSet {
+	s := make(StringSet, len(members))
+	for _, m := range members {
+		s.Add(m)
+	}
+	return s
+}
+
+func (set StringSet) Len() int {
+	return len(set)
+}
+
+func (set StringSet) Add(item string) {
+	set[item] = emptyValue
+}
+
+func (set StringSet) Remove(item string) {
+	delete(set, item)
+}
+
+func (set StringSet) Contains(item string) bool {
+	_, present := set[item]
+	return present
+}
+
+// Union returns a new StringSet containing the members of either set.
+func (set StringSet) Union(other StringSet) StringSet {
+	result := make(StringSet, len(set)+len(other))
+	for item := range set {
+		result.Add(item)
+	}
+	for item := range other {
+		result.Add(item)
+	}
+	return result
+}
+
+// Intersection returns a new StringSet containing the members of both sets.
+func (set StringSet) Intersection(other StringSet) StringSet {
+	result := StringSet{}
+	for item := range set {
+		if other.Contains(item) {
+			result.Add(item)
+		}
+	}
+	return result
+}
+
+// Difference returns a new StringSet containing the members of the set that are
+// not members of other.
+func (set StringSet) Difference(other StringSet) StringSet {
+	result := StringSet{}
+	for item := range set {
+		if !other.Contains(item) {
+			result.Add(item)
+		}
+	}
+	return result
+}
+
+// ToSlice returns the members of the set as a sorted slice.
+func (set StringSet) ToSlice() []string {
+	result := make([]string, 0, len(set))
+	for item := range set {
+		result = append(result, item)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package set_test
+
+import (
+	"github.com/projectcalico/libcalico-go/lib/set"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StringSet", func() {
+	var s set.StringSet
+	BeforeEach(func() {
+		s = set.NewStringSet()
+	})
+
+	It("should be empty", func() {
+		Expect(s.Len()).To(BeZero())
+		Expect(s.ToSlice()).To(BeEmpty())
+	})
+	It("should not contain the empty string", func() {
+		Expect(s.Contains("")).To(BeFalse())
+	})
+
+	Describe("after adding a, b and a again", func() {
+		BeforeEach(func() {
+			s.Add("a")
+			s.Add("b")
+			s.Add("a")
+		})
+		It("should contain a and b", func() {
+			Expect(s.Len()).To(Equal(2))
+			Expect(s.Contains("a")).To(BeTrue())
+			Expect(s.Contains("b")).To(BeTrue())
+			Expect(s.Contains("c")).To(BeFalse())
+		})
+		It("should no longer contain a after removing it", func() {
+			s.Remove("a")
+			Expect(s.Contains("a")).To(BeFalse())
+			Expect(s.ToSlice()).To(Equal([]string{"b"}))
+		})
+		It("should do nothing when removing a missing item", func() {
+			s.Remove("c")
+			Expect(s.ToSlice()).To(Equal([]string{"a", "b"}))
+		})
+	})
+
+	It("should return the members sorted", func() {
+		s = set.NewStringSet("c", "a", "b", "a")
+		Expect(s.ToSlice()).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	DescribeTable("set operations",
+		func(a, b, union, intersection, difference []string) {
+			sa := set.NewStringSet(a...)
+			sb := set.NewStringSet(b...)
+			Expect(sa.Union(sb).ToSlice()).To(Equal(union))
+			Expect(sa.Intersection(sb).ToSlice()).To(Equal(intersection))
+			Expect(sa.Difference(sb).ToSlice()).To(Equal(difference))
+
+			// The operands should not be modified.
+			Expect(sa.Len()).To(Equal(len(set.NewStringSet(a...))))
+			Expect(sb.Len()).To(Equal(len(set.NewStringSet(b...))))
+		},
+		Entry("both empty", nil, nil, []string{}, []string{}, []string{}),
+		Entry("empty other", []string{"a", "b"}, nil, []string{"a", "b"}, []string{}, []string{"a", "b"}),
+		Entry("empty receiver", nil, []string{"a", "b"}, []string{"a", "b"}, []string{}, []string{}),
+		Entry("disjoint", []string{"a"}, []string{"b"}, []string{"a", "b"}, []string{}, []string{"a"}),
+		Entry("overlapping", []string{"a", "b", "c"}, []string{"b", "c", "d"},
+			[]string{"a", "b", "c", "d"}, []string{"b", "c"}, []string{"a"}),
+		Entry("identical", []string{"a", "b"}, []string{"b", "a"}, []string{"a", "b"}, []string{"a", "b"}, []string{}),
+		Entry("subset", []string{"a"}, []string{"a", "b"}, []string{"a", "b"}, []string{"a"}, []string{}),
+	)
+
+	It("should handle a nil StringSet as empty", func() {
+		var n set.StringSet
+		Expect(n.Len()).To(BeZero())
+		Expect(n.Contains("a")).To(BeFalse())
+		Expect(n.Union(set.NewStringSet("a")).ToSlice()).To(Equal([]string{"a"}))
+		Expect(set.NewStringSet("a").Difference(n).ToSlice()).To(Equal([]string{"a"}))
+	})
+})
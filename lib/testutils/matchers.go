@@ -0,0 +1,59 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutils
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// EqualMAC returns a matcher that checks the actual value (a cnet.MAC or *cnet.MAC)
+// is the same MAC address as the expected value, using MAC.Equal.  A nil expected
+// value only matches a nil *cnet.MAC.
+func EqualMAC(expected *cnet.MAC) types.GomegaMatcher {
+	return &macMatcher{expected: expected}
+}
+
+type macMatcher struct {
+	expected *cnet.MAC
+}
+
+func (m *macMatcher) Match(actual interface{}) (bool, error) {
+	var mac *cnet.MAC
+	switch a := actual.(type) {
+	case cnet.MAC:
+		mac = &a
+	case *cnet.MAC:
+		mac = a
+	default:
+		return false, fmt.Errorf("EqualMAC matcher expects a cnet.MAC or *cnet.MAC, got:\n%s", format.Object(actual, 1))
+	}
+	if mac == nil || m.expected == nil {
+		return mac == nil && m.expected == nil, nil
+	}
+	return mac.Equal(*m.expected), nil
+}
+
+func (m *macMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, "to equal MAC", m.expected)
+}
+
+func (m *macMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, "not to equal MAC", m.expected)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+// GlobalBGPConfig converts the global BGP config stored as v1 key value pairs under
+// /calico/bgp/v1/global/ into the v3 BGPConfiguration resource.  The v1 client returns
+// the as_num, loglevel and node_mesh keys as the AsNumber, LogLevel and NodeMeshEnabled
+// GlobalBGPConfigKeys respectively.
+//
+// The v1 data model has no BGP communities, but some deployments store them in custom keys
+// under /calico/bgp/v1/global/communities/<name>; these are returned with the name
+// "communities/<name>".  The v3 BGPConfiguration only allows the Communities that are used
+// by its PrefixAdvertisements, which have no v1 equivalent, so these cannot be migrated and
+// are returned as ConfigConversionErrors.
+type GlobalBGPConfig struct{}
+
+// GlobalBGPConfigCommunityPrefix is the prefix of the names of the GlobalBGPConfigKeys that
+// contain a BGP community value.
+const GlobalBGPConfigCommunityPrefix = "communities/"
+
+// largeCommunityPrefix is an optional prefix of a v1 large community value.
+const largeCommunityPrefix = "large:"
+
+// BackendV1ToAPIV3 converts the supplied v1 GlobalBGPConfigKey KVPairs into the "default"
+// v3 BGPConfiguration.  Any value may be absent, in which case the corresponding field is
+// left unset.  An AS number that is not a valid, non-reserved, 32-bit AS number, and any BGP
+// community, is returned as a ConfigConversionError.  If none of the config is present, the
+// returned resource is nil.
+func (_ GlobalBGPConfig) BackendV1ToAPIV3(kvps []*model.KVPair) (*apiv3.BGPConfiguration, []ConfigConversionError) {
+	res := apiv3.NewBGPConfiguration()
+	res.Name = "default"
+
+	var errs []ConfigConversionError
+	setField := false
+	for _, kvp := range kvps {
+		key, ok := kvp.Key.(model.GlobalBGPConfigKey)
+		if !ok {
+			continue
+		}
+		value, _ := kvp.Value.(string)
+		if value == "" {
+			continue
+		}
+
+		if strings.HasPrefix(key.Name, GlobalBGPConfigCommunityPrefix) {
+			name := strings.TrimPrefix(key.Name, GlobalBGPConfigCommunityPrefix)
+			community, err := convertCommunity(value)
+			if err != nil {
+				log.WithError(err).WithField("Community", name).Info("Invalid BGP community")
+				errs = append(errs, ConfigConversionError{
+					Cause:   fmt.Errorf("BGP community %s is not valid: %v", name, err),
+					KeyV1:   key,
+					ValueV1: value,
+				})
+				continue
+			}
+			// The community is valid, but is not used by any of the (non-existent) prefix
+			// advertisements, so would be rejected by the v3 validation.
+			log.WithField("Community", name).Info("BGP community is not used by a prefix advertisement")
+			errs = append(errs, ConfigConversionError{
+				Cause: fmt.Errorf("BGP community %s (%s) is not used by any prefix advertisement, "+
+					"which v3 requires: add it to the BGPConfiguration with its prefix advertisements "+
+					"after the upgrade", name, community),
+				KeyV1:   key,
+				ValueV1: value,
+			})
+			continue
+		}
+
+		switch key.Name {
+		case "AsNumber":
+			asNum, err := convertASNumber(value)
+			if err != nil {
+				log.WithError(err).WithField("ASNumber", value).Info("Invalid global default ASNumber")
+				errs = append(errs, ConfigConversionError{
+					Cause:   fmt.Errorf("default ASNumber is not valid: %v", err),
+					KeyV1:   key,
+					ValueV1: value,
+				})
+				continue
+			}
+			res.Spec.ASNumber = &asNum
+		case "LogLevel":
+			res.Spec.LogSeverityScreen = ConvertLogLevel(value)
+		case "NodeMeshEnabled":
+			nodeMeshEnabled := strings.ToLower(value) == "true"
+			res.Spec.NodeToNodeMeshEnabled = &nodeMeshEnabled
+		default:
+			log.WithField("name", key.Name).Debug("Ignoring global BGP config with no v3 equivalent")
+			continue
+		}
+		setField = true
+	}
+
+	if !setField {
+		return nil, errs
+	}
+	log.WithField("APIV3", res).Debug("Converted BGPConfiguration")
+	return res, errs
+}
+
+// convertASNumber parses a v1 AS number, which may be in plain or dotted notation.  AS
+// number 0 is reserved (RFC 7607) and is rejected along with any value that does not fit in
+// 32 bits.
+func convertASNumber(value string) (numorstring.ASNumber, error) {
+	asNum, err := numorstring.ASNumberFromString(value)
+	if err != nil {
+		return 0, err
+	}
+	if asNum == 0 {
+		return 0, fmt.Errorf("AS number 0 is reserved")
+	}
+	return asNum, nil
+}
+
+// convertCommunity parses a v1 BGP community value.  A standard community has the format
+// aa:nn, where each part is a 16-bit number.  A large community has the format aa:nn:mm,
+// optionally prefixed with "large:", where each part is a 32-bit number.  The value is
+// returned in the v3 format, without the prefix.
+func convertCommunity(value string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(value, largeCommunityPrefix), ":")
+	bitSize := 0
+	switch {
+	case len(parts) == 2 && !strings.HasPrefix(value, largeCommunityPrefix):
+		bitSize = 16
+	case len(parts) == 3:
+		bitSize = 32
+	default:
+		return "", fmt.Errorf("community %q must have the format aa:nn or aa:nn:mm", value)
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, bitSize); err != nil {
+			return "", fmt.Errorf("community %q must contain %d-bit numbers", value, bitSize)
+		}
+	}
+	return strings.Join(parts, ":"), nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+var (
+	asn64512 = numorstring.ASNumber(64512)
+	asnDot   = numorstring.ASNumber(65536 + 10)
+	meshOn   = true
+	meshOff  = false
+)
+
+var _ = DescribeTable("v1->v3 global BGP config conversion tests",
+	func(kvps []*model.KVPair, spec *apiv3.BGPConfigurationSpec) {
+		res, errs := GlobalBGPConfig{}.BackendV1ToAPIV3(kvps)
+		Expect(errs).To(BeEmpty())
+		if spec == nil {
+			Expect(res).To(BeNil())
+			return
+		}
+		Expect(res).NotTo(BeNil())
+		Expect(res.Name).To(Equal("default"))
+		Expect(res.Spec).To(Equal(*spec))
+	},
+	Entry("all config",
+		[]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: "64512"},
+			{Key: model.GlobalBGPConfigKey{Name: "LogLevel"}, Value: "debug"},
+			{Key: model.GlobalBGPConfigKey{Name: "NodeMeshEnabled"}, Value: "true"},
+		},
+		&apiv3.BGPConfigurationSpec{ASNumber: &asn64512, LogSeverityScreen: "Debug", NodeToNodeMeshEnabled: &meshOn},
+	),
+	Entry("dotted AS number",
+		[]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: "1.10"},
+		},
+		&apiv3.BGPConfigurationSpec{ASNumber: &asnDot},
+	),
+	Entry("node mesh disabled",
+		[]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "NodeMeshEnabled"}, Value: "false"},
+		},
+		&apiv3.BGPConfigurationSpec{NodeToNodeMeshEnabled: &meshOff},
+	),
+	Entry("empty values are ignored",
+		[]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: ""},
+			{Key: model.GlobalBGPConfigKey{Name: "LogLevel"}, Value: "warning"},
+		},
+		&apiv3.BGPConfigurationSpec{LogSeverityScreen: "Warning"},
+	),
+	Entry("config with no v3 equivalent is ignored",
+		[]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "errorlevel"}, Value: "info"},
+		},
+		nil,
+	),
+	Entry("no config", nil, nil),
+)
+
+var _ = DescribeTable("v1->v3 global BGP config conversion of communities",
+	func(value string, cause string) {
+		res, errs := GlobalBGPConfig{}.BackendV1ToAPIV3([]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: "64512"},
+			{Key: model.GlobalBGPConfigKey{Name: "communities/c1"}, Value: value},
+		})
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].KeyV1).To(Equal(model.GlobalBGPConfigKey{Name: "communities/c1"}))
+		Expect(errs[0].ValueV1).To(Equal(value))
+		Expect(errs[0].Cause.Error()).To(ContainSubstring(cause))
+		Expect(res).NotTo(BeNil())
+		Expect(res.Spec).To(Equal(apiv3.BGPConfigurationSpec{ASNumber: &asn64512}))
+	},
+	Entry("standard community", "65535:100", "BGP community c1 (65535:100) is not used by any prefix advertisement"),
+	Entry("large community", "large:4294967295:1:2", "BGP community c1 (4294967295:1:2) is not used by any prefix advertisement"),
+	Entry("large community without a prefix", "64512:300:400", "BGP community c1 (64512:300:400) is not used by any prefix advertisement"),
+	Entry("standard community out of range", "65536:100", "BGP community c1 is not valid"),
+	Entry("large community out of range", "4294967296:1:2", "BGP community c1 is not valid"),
+	Entry("large prefix on a standard community", "large:100:200", "BGP community c1 is not valid"),
+	Entry("too many parts", "1:2:3:4", "BGP community c1 is not valid"),
+	Entry("single number", "100", "BGP community c1 is not valid"),
+	Entry("not a number", "abc:def", "BGP community c1 is not valid"),
+)
+
+var _ = DescribeTable("v1->v3 global BGP config conversion of invalid AS numbers",
+	func(asNum string) {
+		res, errs := GlobalBGPConfig{}.BackendV1ToAPIV3([]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: asNum},
+			{Key: model.GlobalBGPConfigKey{Name: "LogLevel"}, Value: "info"},
+		})
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].KeyV1).To(Equal(model.GlobalBGPConfigKey{Name: "AsNumber"}))
+		Expect(errs[0].ValueV1).To(Equal(asNum))
+		Expect(res).NotTo(BeNil())
+		Expect(res.Spec.ASNumber).To(BeNil())
+		Expect(res.Spec.LogSeverityScreen).To(Equal("Info"))
+	},
+	Entry("reserved AS number", "0"),
+	Entry("AS number out of range", "4294967296"),
+	Entry("dotted AS number out of range", "65536.1"),
+	Entry("not a number", "abc"),
+)
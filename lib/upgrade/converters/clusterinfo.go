@@ -0,0 +1,43 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+// ClusterInformation converts the cluster wide values stored as v1 global config
+// (e.g. /calico/v1/config/ClusterType and /calico/v1/config/CalicoVersion) into the
+// v3 ClusterInformation resource.
+type ClusterInformation struct{}
+
+// BackendV1ToAPIV3 converts the supplied v1 GlobalConfigKey KVPairs into the "default"
+// v3 ClusterInformation.  Config that is not part of the ClusterInformation is ignored,
+// and any value may be absent.  If none of the ClusterInformation config is present,
+// the returned resource is nil.
+func (_ ClusterInformation) BackendV1ToAPIV3(kvps []*model.KVPair) (*apiv3.ClusterInformation, []ConfigConversionError) {
+	res := apiv3.NewClusterInformation()
+	res.Name = "default"
+
+	setField, errs := ConfigV1ToAPIV3(kvps, res)
+	if !setField {
+		return nil, errs
+	}
+	log.WithField("APIV3", res).Debug("Converted ClusterInformation")
+	return res, errs
+}
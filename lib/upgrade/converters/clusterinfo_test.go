@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+var _ = DescribeTable("v1->v3 ClusterInformation conversion tests",
+	func(kvps []*model.KVPair, spec *apiv3.ClusterInformationSpec) {
+		res, errs := ClusterInformation{}.BackendV1ToAPIV3(kvps)
+		Expect(errs).To(BeEmpty())
+		if spec == nil {
+			Expect(res).To(BeNil())
+			return
+		}
+		Expect(res).NotTo(BeNil())
+		Expect(res.Name).To(Equal("default"))
+		Expect(res.Spec).To(Equal(*spec))
+	},
+	Entry("ClusterType and CalicoVersion",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "ClusterType"}, Value: "k8s,bgp"},
+			{Key: model.GlobalConfigKey{Name: "CalicoVersion"}, Value: "v2.6.5"},
+		},
+		&apiv3.ClusterInformationSpec{ClusterType: "k8s,bgp", CalicoVersion: "v2.6.5"},
+	),
+	Entry("only ClusterType",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "ClusterType"}, Value: "k8s,bgp"},
+		},
+		&apiv3.ClusterInformationSpec{ClusterType: "k8s,bgp"},
+	),
+	Entry("only CalicoVersion",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "CalicoVersion"}, Value: "v2.6.5"},
+		},
+		&apiv3.ClusterInformationSpec{CalicoVersion: "v2.6.5"},
+	),
+	Entry("FelixConfiguration values are ignored",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "InterfacePrefix"}, Value: "cali"},
+			{Key: model.GlobalConfigKey{Name: "CalicoVersion"}, Value: "v2.6.5"},
+		},
+		&apiv3.ClusterInformationSpec{CalicoVersion: "v2.6.5"},
+	),
+	Entry("neither ClusterType nor CalicoVersion",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "InterfacePrefix"}, Value: "cali"},
+		},
+		nil,
+	),
+	Entry("no config", nil, nil),
+)
@@ -0,0 +1,394 @@
+// Copyright (c) 2017-2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/set"
+)
+
+// AnnotationConfigV1Prefix is the annotation prefix used to store v1 config values
+// that do not map onto a field in the v3 FelixConfiguration.
+const AnnotationConfigV1Prefix = "migration.projectcalico.org/configv1."
+
+// nonFelixConfigNames are the v1 config names that are stored alongside the felix
+// config but are migrated to other v3 resources, so are not considered unknown.
+var nonFelixConfigNames = set.NewStringSet(
+	// The per-host IPIP tunnel address is migrated to the v3 Node.
+	"IpInIpTunnelAddr",
+)
+
+// FelixConfiguration converts the flat v1 felix configuration key/value pairs
+// (stored under /calico/v1/config and /calico/v1/host/<node>/config) into a v3
+// FelixConfiguration resource.
+type FelixConfiguration struct{}
+
+// ConfigConversionError contains the details of a v1 config value that could not
+// be converted into the v3 resource.
+type ConfigConversionError struct {
+	Cause   error
+	KeyV1   model.Key
+	ValueV1 string
+}
+
+// BackendV1ToAPIV3 converts the supplied v1 GlobalConfigKey or HostConfigKey
+// KVPairs into a v3 FelixConfiguration with the given name. Values that cannot be
+// parsed are returned as ConversionErrors, and config names that are not known
+// to v3 are stored as annotations on the returned resource. If there is no v1
+// config that maps onto a FelixConfiguration field, the returned resource is nil.
+func (_ FelixConfiguration) BackendV1ToAPIV3(name string, kvps []*model.KVPair) (*apiv3.FelixConfiguration, []ConfigConversionError) {
+	res := apiv3.NewFelixConfiguration()
+	res.Name = name
+
+	setField, errs := ConfigV1ToAPIV3(kvps, res)
+
+	// Config names used by the v1 ClusterInformation are also stored under the
+	// global config path, so are not considered unknown.
+	known := configNames(reflect.TypeOf(apiv3.FelixConfigurationSpec{})).Union(
+		configNames(reflect.TypeOf(apiv3.ClusterInformationSpec{})),
+	).Union(nonFelixConfigNames)
+
+	// Unknown config alone does not warrant a FelixConfiguration.
+	if !setField {
+		return nil, errs
+	}
+
+	configv1, _ := configV1ToMap(kvps)
+	names := set.NewStringSet()
+	for n := range configv1 {
+		names.Add(n)
+	}
+	for _, n := range names.Difference(known).ToSlice() {
+		log.WithFields(log.Fields{
+			"name":       name,
+			"configName": n,
+		}).Warning("Unknown v1 config value, storing as annotation on FelixConfiguration")
+		if res.Annotations == nil {
+			res.Annotations = map[string]string{}
+		}
+		res.Annotations[AnnotationConfigV1Prefix+n] = configv1[n]
+	}
+
+	log.WithField("APIV3", res).Debug("Converted FelixConfiguration")
+	return res, errs
+}
+
+// ConfigV1ToAPIV3 converts a slice of v1 config KVPairs into the appropriate v3
+// values and merges the results into the Spec of the supplied v3 resource (a
+// FelixConfiguration or ClusterInformation). It returns whether any field in the
+// Spec was set, along with the details of any values that could not be converted.
+func ConfigV1ToAPIV3(kvps []*model.KVPair, res Resource) (bool, []ConfigConversionError) {
+	logCxtRes := log.WithFields(log.Fields{
+		"kind": res.GetObjectKind().GroupVersionKind().Kind,
+		"name": res.GetObjectMeta().GetName(),
+	})
+
+	// Convert the KVP slice into a name value map.
+	configv1, keysv1 := configV1ToMap(kvps)
+
+	// Extract the Spec from the resource FelixConfiguration or ClusterInfo.
+	specValue := reflect.ValueOf(res).Elem().FieldByName("Spec")
+	if !specValue.IsValid() {
+		return false, []ConfigConversionError{{
+			Cause: fmt.Errorf("unable to process config resource type: %v", res),
+		}}
+	}
+
+	// Loop through the Spec setting each field from the supplied KVPair data.
+	var errs []ConfigConversionError
+	setField := false
+	specType := specValue.Type()
+	for i := 0; i < specType.NumField(); i++ {
+		field := specType.Field(i)
+		fieldValue := specValue.Field(i)
+
+		// Get the v1 config value associated with the field.
+		configName := getConfigName(field)
+		logCxt := logCxtRes.WithFields(log.Fields{
+			"field":      field.Name,
+			"configName": configName,
+		})
+		configStrValue, ok := configv1[configName]
+		if !ok {
+			logCxt.Debug("config value is not configured in v1")
+			continue
+		}
+		addError := func(err error) {
+			errs = append(errs, ConfigConversionError{
+				Cause:   err,
+				KeyV1:   keysv1[configName],
+				ValueV1: configStrValue,
+			})
+		}
+
+		isPtr := field.Type.Kind() == reflect.Ptr
+		fieldName := field.Name
+
+		switch {
+		case strings.HasPrefix(fieldName, "Failsafe"):
+			// Special-case the Failsafe ports - these require parsing and settings as a struct.
+			if configStrValue == "none" {
+				// Has no failsafe ports
+				vProtoPort := &[]apiv3.ProtoPort{}
+				fieldValue.Set(reflect.ValueOf(vProtoPort))
+				setField = true
+				continue
+			}
+
+			vProtoPort, err := parseProtoPort(configStrValue)
+			if err != nil {
+				logCxt.WithError(err).Info("Failed to parse field")
+				addError(err)
+				continue
+			}
+			fieldValue.Set(reflect.ValueOf(vProtoPort)) // pointer to proto port slice.
+			setField = true
+			continue
+		case strings.HasPrefix(fieldName, "LogSeverity"):
+			// The log level fields need to have their value converted to the appropriate v3 value,
+			// but other than that are treated as normal string fields.
+			configStrValue = ConvertLogLevel(configStrValue)
+		}
+
+		_, ok = fieldValue.Interface().(*metav1.Duration)
+		if ok {
+			if duration, err := strconv.ParseFloat(configStrValue, 64); err != nil {
+				logCxt.WithError(err).Info("Failed to parse float for Duration field")
+				addError(fmt.Errorf("failed to parse float for Duration field: %v", err))
+			} else {
+				switch field.Tag.Get("configv1timescale") {
+				case "milliseconds":
+					fieldValue.Set(reflect.ValueOf(&metav1.Duration{Duration: time.Duration(duration * float64(time.Millisecond))}))
+				default:
+					fieldValue.Set(reflect.ValueOf(&metav1.Duration{Duration: time.Duration(duration * float64(time.Second))}))
+				}
+				setField = true
+				continue
+			}
+		}
+
+		// Set the field value based on the field type.
+		var kind reflect.Kind
+		if isPtr {
+			kind = field.Type.Elem().Kind()
+		} else {
+			kind = fieldValue.Kind()
+		}
+
+		switch kind {
+		case reflect.Uint32:
+			if value, err := strconv.ParseUint(configStrValue, 10, 32); err != nil {
+				logCxt.WithError(err).Info("Failed to parse uint32 field")
+				addError(fmt.Errorf("failed to parse uint32 field: %v", err))
+				continue
+			} else if isPtr {
+				vu := uint32(value)
+				fieldValue.Set(reflect.ValueOf(&vu))
+			} else {
+				fieldValue.SetUint(value)
+			}
+		case reflect.Int:
+			if value, err := strconv.ParseInt(configStrValue, 10, 64); err != nil {
+				logCxt.WithError(err).Info("Failed to parse int field")
+				addError(fmt.Errorf("failed to parse int field: %v", err))
+				continue
+			} else if isPtr {
+				vi := int(value)
+				fieldValue.Set(reflect.ValueOf(&vi))
+			} else {
+				fieldValue.SetInt(value)
+			}
+		case reflect.Bool:
+			if value, err := strconv.ParseBool(configStrValue); err != nil {
+				logCxt.WithError(err).Info("Failed to parse bool field")
+				addError(fmt.Errorf("failed to parse bool field: %v", err))
+				continue
+			} else if isPtr {
+				fieldValue.Set(reflect.ValueOf(&value))
+			} else {
+				fieldValue.SetBool(value)
+			}
+		case reflect.String:
+			if isPtr {
+				fieldValue.Set(reflect.ValueOf(&configStrValue))
+			} else {
+				fieldValue.SetString(configStrValue)
+			}
+		default:
+			logCxt.Info("Unhandle field type")
+			addError(fmt.Errorf("unhandled field type, please raise an issue on GitHub " +
+				"(https://github.com/projectcalico/calico) that includes this error message"))
+			continue
+		}
+
+		// We must have set a field in the spec.
+		setField = true
+	}
+
+	return setField, errs
+}
+
+// ConvertLogLevel converts the v1 log level to the equivalent v3 log level. We
+// ignore errors, defaulting to info in the event of a conversion error.
+func ConvertLogLevel(logLevel string) string {
+	switch strings.ToLower(logLevel) {
+	case "debug":
+		return "Debug"
+	case "info":
+		return "Info"
+	case "warning":
+		return "Warning"
+	case "error":
+		return "Error"
+	case "fatal":
+		return "Fatal"
+	case "panic":
+		return "Fatal"
+	case "":
+		return ""
+	default:
+		return "Info"
+	}
+}
+
+// configV1ToMap converts the global or per-host config KVPairs into maps of
+// config name to value and config name to v1 key.
+func configV1ToMap(kvps []*model.KVPair) (map[string]string, map[string]model.Key) {
+	keysv1 := map[string]model.Key{}
+	configv1 := map[string]string{}
+	for _, kvp := range kvps {
+		if kvp.Value == nil {
+			continue
+		}
+		switch key := kvp.Key.(type) {
+		case model.GlobalConfigKey:
+			configv1[key.Name] = kvp.Value.(string)
+			keysv1[key.Name] = key
+		case model.HostConfigKey:
+			configv1[key.Name] = kvp.Value.(string)
+			keysv1[key.Name] = key
+		}
+	}
+	return configv1, keysv1
+}
+
+// configNames returns the set of v1 config names for the fields in the supplied
+// Spec type.
+func configNames(specType reflect.Type) set.StringSet {
+	names := set.NewStringSet()
+	for i := 0; i < specType.NumField(); i++ {
+		names.Add(getConfigName(specType.Field(i)))
+	}
+	return names
+}
+
+func parseProtoPortFailed(msg string) error {
+	return fmt.Errorf("failed to parse ProtoPort-%s", msg)
+}
+
+func parseProtoPort(raw string) (*[]apiv3.ProtoPort, error) {
+	var result []apiv3.ProtoPort
+	for _, portStr := range strings.Split(raw, ",") {
+		portStr = strings.Trim(portStr, " ")
+		if portStr == "" {
+			continue
+		}
+
+		protocolStr := "tcp"
+		netStr := ""
+
+		// Check if IPv6 network is set
+		if strings.Contains(portStr, "[") && strings.Contains(portStr, "]") {
+			// Grab the IPv6 network
+			startIndex := strings.Index(portStr, "[")
+			endIndex := strings.Index(portStr, "]:")
+			netStr = portStr[startIndex+1 : endIndex]
+
+			// Remove the IPv6 network value from portStr
+			var withoutIPv6 strings.Builder
+			withoutIPv6.WriteString(portStr[:startIndex])
+			withoutIPv6.WriteString(portStr[endIndex+2:])
+			portStr = withoutIPv6.String()
+		}
+
+		parts := strings.Split(portStr, ":")
+		if len(parts) > 3 {
+			return nil, parseProtoPortFailed("ports should be <protocol>:<net>:<number> or <protocol>:<number> or <number>")
+		}
+
+		if len(parts) > 2 {
+			netStr = parts[1]
+			protocolStr = strings.ToUpper(parts[0])
+			portStr = parts[2]
+		}
+
+		if len(parts) == 2 {
+			protocolStr = strings.ToUpper(parts[0])
+			portStr = parts[1]
+		}
+
+		if protocolStr != "TCP" && protocolStr != "UDP" {
+			return nil, parseProtoPortFailed("unknown protocol: " + protocolStr)
+		}
+
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, parseProtoPortFailed("ports should be integers")
+		}
+		if port < 0 || port > 65535 {
+			err = parseProtoPortFailed("ports must be in range 0-65535")
+			return nil, err
+		}
+
+		protoPort := apiv3.ProtoPort{
+			Protocol: protocolStr,
+			Port:     uint16(port),
+		}
+
+		if netStr != "" {
+			_, netParsed, err := net.ParseCIDROrIP(netStr)
+			if err != nil {
+				err = parseProtoPortFailed("invalid CIDR or IP " + netStr)
+				return nil, err
+			}
+			protoPort.Net = netParsed.String()
+		}
+
+		result = append(result, protoPort)
+	}
+
+	return &result, nil
+}
+
+// Return the config name from the field. The field name is either specified in the
+// configname tag, otherwise it just uses the struct field name.
+func getConfigName(field reflect.StructField) string {
+	name := field.Tag.Get("confignamev1")
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+var ipipMTU = 1440
+var vxlanMTU = 1410
+var wireguardMTU = 1420
+var ipv6Support = true
+
+var felixConfigTable = []TableEntry{
+	Entry("IPIP MTU",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "IpInIpMtu"}, Value: "1440"},
+		},
+		apiv3.FelixConfigurationSpec{IPIPMTU: &ipipMTU},
+		map[string]string(nil),
+	),
+	Entry("VXLAN MTU",
+		[]*model.KVPair{
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "VXLANMTU"}, Value: "1410"},
+		},
+		apiv3.FelixConfigurationSpec{VXLANMTU: &vxlanMTU},
+		map[string]string(nil),
+	),
+	Entry("IPv4 MTUs",
+		[]*model.KVPair{
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "IpInIpMtu"}, Value: "1440"},
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "VXLANMTU"}, Value: "1410"},
+		},
+		apiv3.FelixConfigurationSpec{IPIPMTU: &ipipMTU, VXLANMTU: &vxlanMTU},
+		map[string]string(nil),
+	),
+	Entry("IPv6 MTUs",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "Ipv6Support"}, Value: "true"},
+			{Key: model.GlobalConfigKey{Name: "VXLANMTU"}, Value: "1410"},
+			{Key: model.GlobalConfigKey{Name: "WireguardMTU"}, Value: "1420"},
+		},
+		apiv3.FelixConfigurationSpec{
+			IPv6Support:  &ipv6Support,
+			VXLANMTU:     &vxlanMTU,
+			WireguardMTU: &wireguardMTU,
+		},
+		map[string]string(nil),
+	),
+	Entry("Log severities are converted to the v3 values",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "LogSeverityScreen"}, Value: "WARNING"},
+			{Key: model.GlobalConfigKey{Name: "LogSeverityFile"}, Value: "panic"},
+			{Key: model.GlobalConfigKey{Name: "LogSeveritySys"}, Value: "foo"},
+		},
+		apiv3.FelixConfigurationSpec{
+			LogSeverityScreen: "Warning",
+			LogSeverityFile:   "Fatal",
+			LogSeveritySys:    "Info",
+		},
+		map[string]string(nil),
+	),
+	Entry("Unknown config names are stored as annotations",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "InterfacePrefix"}, Value: "cali"},
+			{Key: model.GlobalConfigKey{Name: "SomeRetiredOption"}, Value: "true"},
+		},
+		apiv3.FelixConfigurationSpec{InterfacePrefix: "cali"},
+		map[string]string{
+			"migration.projectcalico.org/configv1.SomeRetiredOption": "true",
+		},
+	),
+	Entry("ClusterInformation config names are not treated as unknown",
+		[]*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "InterfacePrefix"}, Value: "cali"},
+			{Key: model.GlobalConfigKey{Name: "ClusterGUID"}, Value: "abcdef"},
+		},
+		apiv3.FelixConfigurationSpec{InterfacePrefix: "cali"},
+		map[string]string(nil),
+	),
+	Entry("The per-host IPIP tunnel address is not treated as unknown",
+		[]*model.KVPair{
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "InterfacePrefix"}, Value: "cali"},
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "IpInIpTunnelAddr"}, Value: "10.0.0.1"},
+		},
+		apiv3.FelixConfigurationSpec{InterfacePrefix: "cali"},
+		map[string]string(nil),
+	),
+}
+
+var _ = DescribeTable("v1->v3 FelixConfiguration conversion tests",
+	func(kvps []*model.KVPair, spec apiv3.FelixConfigurationSpec, annotations map[string]string) {
+		res, errs := FelixConfiguration{}.BackendV1ToAPIV3("default", kvps)
+		Expect(errs).To(BeEmpty())
+		Expect(res).NotTo(BeNil())
+		Expect(res.Name).To(Equal("default"))
+		Expect(res.Spec).To(Equal(spec))
+		Expect(res.Annotations).To(Equal(annotations))
+	},
+
+	felixConfigTable...,
+)
+
+var _ = Describe("v1->v3 FelixConfiguration conversion failures", func() {
+	It("should return a conversion error for an invalid MTU", func() {
+		key := model.GlobalConfigKey{Name: "IpInIpMtu"}
+		res, errs := FelixConfiguration{}.BackendV1ToAPIV3("default", []*model.KVPair{
+			{Key: key, Value: "big"},
+			{Key: model.GlobalConfigKey{Name: "InterfacePrefix"}, Value: "cali"},
+		})
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].KeyV1).To(Equal(key))
+		Expect(errs[0].ValueV1).To(Equal("big"))
+		Expect(res).NotTo(BeNil())
+		Expect(res.Spec).To(Equal(apiv3.FelixConfigurationSpec{InterfacePrefix: "cali"}))
+	})
+
+	It("should return a nil resource when the only config is the IPIP tunnel address", func() {
+		res, errs := FelixConfiguration{}.BackendV1ToAPIV3("node.node1", []*model.KVPair{
+			{Key: model.HostConfigKey{Hostname: "node1", Name: "IpInIpTunnelAddr"}, Value: "10.0.0.1"},
+		})
+		Expect(errs).To(BeEmpty())
+		Expect(res).To(BeNil())
+	})
+
+	It("should return a nil resource when all of the config is unknown", func() {
+		res, errs := FelixConfiguration{}.BackendV1ToAPIV3("default", []*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "SomeRetiredOption"}, Value: "true"},
+		})
+		Expect(errs).To(BeEmpty())
+		Expect(res).To(BeNil())
+	})
+
+	It("should return a nil resource when there is no config", func() {
+		res, errs := FelixConfiguration{}.BackendV1ToAPIV3("default", nil)
+		Expect(errs).To(BeEmpty())
+		Expect(res).To(BeNil())
+	})
+})
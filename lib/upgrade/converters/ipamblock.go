@@ -0,0 +1,85 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/set"
+)
+
+const hostAffinityPrefix = "host:"
+
+// IPAMBlock converts a v1 IPAM allocation block into the v3 format.  The block uses the
+// same backend model in both versions, but the affinity must reference the v3 node name.
+type IPAMBlock struct {
+	// Handles contains the IDs of the IPAM handles in the v1 datastore.
+	Handles set.StringSet
+
+	// Nodes contains the v1 names of the nodes in the v1 datastore, which should include
+	// the hosts that only have per-host Felix config.  If nil, the nodes are not checked
+	// and the affinity of every block is converted, so that the affinities may be
+	// validated after the migration.
+	Nodes set.StringSet
+}
+
+// BackendV1ToBackendV3 converts a v1 AllocationBlock KVPair into the v3 format.  An error is
+// returned if an allocation in the block references an IPAM handle that does not exist.  If
+// the block is affine to a node that is not one of the Nodes, the affinity is removed so that
+// the block may be claimed by another node.
+func (c IPAMBlock) BackendV1ToBackendV3(kvp *model.KVPair) (*model.KVPair, error) {
+	v1Block, ok := kvp.Value.(*model.AllocationBlock)
+	if !ok {
+		return nil, fmt.Errorf("value is not a valid AllocationBlock resource: %T", kvp.Value)
+	}
+
+	for _, attr := range v1Block.Attributes {
+		if attr.AttrPrimary != nil && !c.Handles.Contains(*attr.AttrPrimary) {
+			return nil, fmt.Errorf("IPAM block %s references IPAM handle %s which does not exist",
+				v1Block.CIDR, *attr.AttrPrimary)
+		}
+	}
+
+	block := *v1Block
+	node := ""
+	if block.Affinity != nil && strings.HasPrefix(*block.Affinity, hostAffinityPrefix) {
+		node = strings.TrimPrefix(*block.Affinity, hostAffinityPrefix)
+	} else if block.HostAffinity != nil {
+		node = *block.HostAffinity
+	}
+
+	if node != "" {
+		if c.Nodes == nil || c.Nodes.Contains(node) {
+			aff := hostAffinityPrefix + ConvertNodeName(node)
+			block.Affinity = &aff
+		} else {
+			log.WithFields(log.Fields{
+				"CIDR": block.CIDR,
+				"Node": node,
+			}).Info("IPAM block is affine to a node that does not exist, removing affinity")
+			block.Affinity = nil
+			block.HostAffinity = nil
+		}
+	}
+
+	return &model.KVPair{
+		Key:   kvp.Key,
+		Value: &block,
+	}, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/set"
+)
+
+var _ = Describe("v1->v3 IPAM block conversion tests", func() {
+	strPtr := func(s string) *string { return &s }
+	blockKey := model.BlockKey{CIDR: cnet.MustParseNetwork("10.0.0.0/26")}
+	converter := IPAMBlock{
+		Handles: set.NewStringSet("handle1", "handle2"),
+		Nodes:   set.NewStringSet("Node1.Example.com"),
+	}
+
+	DescribeTable("block affinity conversion",
+		func(affinity, hostAffinity, expectedAffinity *string) {
+			v1Block := &model.AllocationBlock{
+				CIDR:         blockKey.CIDR,
+				Affinity:     affinity,
+				HostAffinity: hostAffinity,
+				Attributes: []model.AllocationAttribute{
+					{AttrPrimary: strPtr("handle1")},
+					{AttrPrimary: strPtr("handle2")},
+				},
+			}
+			kvp, err := converter.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: v1Block})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kvp.Key).To(Equal(blockKey))
+			block := kvp.Value.(*model.AllocationBlock)
+			Expect(block.Affinity).To(Equal(expectedAffinity))
+			Expect(block.Attributes).To(Equal(v1Block.Attributes))
+		},
+		Entry("no affinity", nil, nil, nil),
+		Entry("host affinity to an existing node",
+			strPtr("host:Node1.Example.com"), nil, strPtr("host:node1.example.com")),
+		Entry("deprecated host affinity to an existing node",
+			nil, strPtr("Node1.Example.com"), strPtr("host:node1.example.com")),
+		Entry("host affinity to a deleted node",
+			strPtr("host:node2.example.com"), nil, nil),
+		Entry("deprecated host affinity to a deleted node",
+			nil, strPtr("node2.example.com"), nil),
+	)
+
+	It("should not modify the v1 block", func() {
+		v1Block := &model.AllocationBlock{
+			CIDR:     blockKey.CIDR,
+			Affinity: strPtr("host:Node1.Example.com"),
+		}
+		_, err := converter.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: v1Block})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*v1Block.Affinity).To(Equal("host:Node1.Example.com"))
+	})
+
+	It("should remove the deprecated host affinity of a block affine to a deleted node", func() {
+		v1Block := &model.AllocationBlock{
+			CIDR:         blockKey.CIDR,
+			HostAffinity: strPtr("node2.example.com"),
+		}
+		kvp, err := converter.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: v1Block})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvp.Value.(*model.AllocationBlock).HostAffinity).To(BeNil())
+	})
+
+	It("should convert the affinity without checking the node if the nodes are not supplied", func() {
+		v1Block := &model.AllocationBlock{
+			CIDR:     blockKey.CIDR,
+			Affinity: strPtr("host:Node2.Example.com"),
+		}
+		unchecked := IPAMBlock{Handles: converter.Handles}
+		kvp, err := unchecked.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: v1Block})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvp.Value.(*model.AllocationBlock).Affinity).To(Equal(strPtr("host:node2.example.com")))
+	})
+
+	It("should fail if an allocation references a handle that does not exist", func() {
+		v1Block := &model.AllocationBlock{
+			CIDR: blockKey.CIDR,
+			Attributes: []model.AllocationAttribute{
+				{AttrPrimary: strPtr("handle1")},
+				{AttrPrimary: strPtr("handle3")},
+			},
+		}
+		_, err := converter.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: v1Block})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("handle3"))
+	})
+
+	It("should fail if the value is not an AllocationBlock", func() {
+		_, err := converter.BackendV1ToBackendV3(&model.KVPair{Key: blockKey, Value: &model.IPPool{}})
+		Expect(err).To(HaveOccurred())
+	})
+})
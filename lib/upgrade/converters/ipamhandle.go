@@ -0,0 +1,91 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+// AnnotationOriginalHandleID is the annotation used to record the v1 handle ID of a
+// converted IPAMHandle, since the handle ID is modified to form the resource name.
+const AnnotationOriginalHandleID = "projectcalico.org/original-handle-id"
+
+// IPAMHandle converts a v1 IPAM handle into a v3 IPAMHandle resource.
+type IPAMHandle struct{}
+
+// BackendV1ToAPIV3 converts a v1 IPAMHandle KVPair into a v3 IPAMHandle.  The original
+// handle ID is stored in the Spec and in the AnnotationOriginalHandleID annotation.  An
+// error is returned if the handle ID cannot be converted to a valid resource name.
+func (_ IPAMHandle) BackendV1ToAPIV3(kvp *model.KVPair) (*libapiv3.IPAMHandle, error) {
+	key, ok := kvp.Key.(model.IPAMHandleKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not a valid IPAMHandle key: %T", kvp.Key)
+	}
+	v1Handle, ok := kvp.Value.(*model.IPAMHandle)
+	if !ok {
+		return nil, fmt.Errorf("value is not a valid IPAMHandle resource: %T", kvp.Value)
+	}
+
+	// The name is the lowercased handle ID, as used by the Kubernetes datastore
+	// IPAMHandle client, with any "/" replaced by "-" since "/" is not valid in a
+	// name.  Handle IDs that differ only in these characters convert to the same name.
+	name := strings.Replace(strings.ToLower(key.HandleID), "/", "-", -1)
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+		return nil, fmt.Errorf("IPAM handle %s cannot be converted to a valid name: %s",
+			key.HandleID, strings.Join(errs, "; "))
+	}
+
+	var block map[string]int
+	if v1Handle.Block != nil {
+		block = make(map[string]int, len(v1Handle.Block))
+		for k, v := range v1Handle.Block {
+			block[k] = v
+		}
+	}
+
+	handle := libapiv3.NewIPAMHandle()
+	handle.Name = name
+	handle.Annotations = map[string]string{AnnotationOriginalHandleID: key.HandleID}
+	handle.Spec = libapiv3.IPAMHandleSpec{
+		HandleID: key.HandleID,
+		Block:    block,
+		Deleted:  v1Handle.Deleted,
+	}
+	return handle, nil
+}
+
+// APIV3ToBackendV1 converts a v3 IPAMHandle back into a v1 IPAMHandle KVPair.  The handle
+// ID is taken from the AnnotationOriginalHandleID annotation if present, and otherwise
+// from the Spec.
+func (_ IPAMHandle) APIV3ToBackendV1(handle *libapiv3.IPAMHandle) *model.KVPair {
+	handleID := handle.Spec.HandleID
+	if id, ok := handle.Annotations[AnnotationOriginalHandleID]; ok {
+		handleID = id
+	}
+	return &model.KVPair{
+		Key: model.IPAMHandleKey{HandleID: handleID},
+		Value: &model.IPAMHandle{
+			HandleID: handleID,
+			Block:    handle.Spec.Block,
+			Deleted:  handle.Spec.Deleted,
+		},
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converters
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+)
+
+var _ = Describe("v1->v3 IPAM handle conversion tests", func() {
+	DescribeTable("handle round trip",
+		func(handleID, expectedName string) {
+			v1 := &model.KVPair{
+				Key: model.IPAMHandleKey{HandleID: handleID},
+				Value: &model.IPAMHandle{
+					HandleID: handleID,
+					Block:    map[string]int{"10.0.0.0/26": 2},
+				},
+			}
+			handle, err := IPAMHandle{}.BackendV1ToAPIV3(v1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(handle.Name).To(Equal(expectedName))
+			Expect(handle.Annotations).To(Equal(map[string]string{AnnotationOriginalHandleID: handleID}))
+			Expect(handle.Spec.HandleID).To(Equal(handleID))
+			Expect(handle.Spec.Block).To(Equal(map[string]int{"10.0.0.0/26": 2}))
+
+			Expect(IPAMHandle{}.APIV3ToBackendV1(handle)).To(Equal(v1))
+		},
+		Entry("handle with slashes", "k8s-pod-network/default/pod1", "k8s-pod-network-default-pod1"),
+		Entry("UUID handle", "0f3b8c3e-6a57-4e2c-9b1e-1f9b3c9d7e21", "0f3b8c3e-6a57-4e2c-9b1e-1f9b3c9d7e21"),
+		Entry("mixed case handle", "k8s-pod-network.ABC123", "k8s-pod-network.abc123"),
+	)
+
+	It("should fall back to the Spec handle ID if the annotation is missing", func() {
+		handle, err := IPAMHandle{}.BackendV1ToAPIV3(&model.KVPair{
+			Key:   model.IPAMHandleKey{HandleID: "Handle1"},
+			Value: &model.IPAMHandle{HandleID: "Handle1", Deleted: true},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		handle.Annotations = nil
+		kvp := IPAMHandle{}.APIV3ToBackendV1(handle)
+		Expect(kvp.Key).To(Equal(model.IPAMHandleKey{HandleID: "Handle1"}))
+		Expect(kvp.Value.(*model.IPAMHandle).Deleted).To(BeTrue())
+	})
+
+	It("should reject a handle ID that does not form a valid name", func() {
+		_, err := IPAMHandle{}.BackendV1ToAPIV3(&model.KVPair{
+			Key:   model.IPAMHandleKey{HandleID: "handle_1"},
+			Value: &model.IPAMHandle{HandleID: "handle_1"},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject an invalid value", func() {
+		_, err := IPAMHandle{}.BackendV1ToAPIV3(&model.KVPair{Key: model.IPAMHandleKey{HandleID: "h"}, Value: "h"})
+		Expect(err).To(HaveOccurred())
+	})
+})
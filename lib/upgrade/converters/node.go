@@ -100,6 +100,14 @@ func (_ Node) BackendV1ToAPIV3(d *model.KVPair) (Resource, error) {
 		return nil, fmt.Errorf("Key is not a valid NodeKey resource: %v", d.Value)
 	}
 
+	// The BGP addresses must be of the correct IP version.
+	if bv.BGPIPv4Addr != nil && bv.BGPIPv4Addr.Version() != 4 {
+		return nil, fmt.Errorf("BGP IPv4 address is not a valid IPv4 address: %s", bv.BGPIPv4Addr)
+	}
+	if bv.BGPIPv6Addr != nil && bv.BGPIPv6Addr.Version() != 6 {
+		return nil, fmt.Errorf("BGP IPv6 address is not a valid IPv6 address: %s", bv.BGPIPv6Addr)
+	}
+
 	apiNode := libapiv3.NewNode()
 
 	apiNode.ObjectMeta.Name = ConvertNodeName(bk.Hostname)
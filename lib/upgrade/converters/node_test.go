@@ -299,6 +299,34 @@ var _ = Describe("v1->v3 Node conversion tests (failure)", func() {
 		_, err := p.BackendV1ToAPIV3(resource)
 		Expect(err).To(HaveOccurred())
 	})
+
+	It("BackendV1ToAPIV3 with an IPv6 address in the IPv4 field produces an error", func() {
+		resource := &model.KVPair{
+			Key: model.NodeKey{
+				Hostname: "my-node",
+			},
+			Value: &model.Node{
+				BGPIPv4Addr: &ipv6IP,
+			},
+		}
+		p := Node{}
+		_, err := p.BackendV1ToAPIV3(resource)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("BackendV1ToAPIV3 with an IPv4 address in the IPv6 field produces an error", func() {
+		resource := &model.KVPair{
+			Key: model.NodeKey{
+				Hostname: "my-node",
+			},
+			Value: &model.Node{
+				BGPIPv6Addr: &ipv4IP,
+			},
+		}
+		p := Node{}
+		_, err := p.BackendV1ToAPIV3(resource)
+		Expect(err).To(HaveOccurred())
+	})
 })
 
 var nodeKVtoV3Table = []TableEntry{
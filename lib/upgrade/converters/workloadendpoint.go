@@ -15,7 +15,9 @@
 package converters
 
 import (
+	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -129,15 +131,19 @@ func (_ WorkloadEndpoint) BackendV1ToAPIV3(kvp *model.KVPair) (Resource, error)
 		container = wepKey.WorkloadID
 	case "libnetwork":
 		workload = "libnetwork"
+	case "mesos":
+		// Mesos workload IDs are of the form <framework-id>.<task-id>.  Unlike k8s,
+		// the dot does not separate a namespace, so the whole ID is both the workload
+		// and the pod.  The name is still generated from the workload.
+		workload = convertName(wepKey.WorkloadID)
+		pod = workload
 	default:
 		workload = convertName(wepKey.WorkloadID)
 	}
 
-	ipNets := convertIPNetworks(wepValue.IPv4Nets)
-	ipNets = append(ipNets, convertIPNetworks(wepValue.IPv6Nets)...)
+	ipNets := convertIPNetworks(sortIPNetworks(wepValue.AllNets()))
 
-	ipNats := convertIPNATs(wepValue.IPv4NAT)
-	ipNats = append(ipNats, convertIPNATs(wepValue.IPv6NAT)...)
+	ipNats := convertIPNATs(wepValue.AllNATs())
 
 	wep := libapiv3.NewWorkloadEndpoint()
 
@@ -169,6 +175,15 @@ func (_ WorkloadEndpoint) BackendV1ToAPIV3(kvp *model.KVPair) (Resource, error)
 		wep.Spec.MAC = wepValue.Mac.String()
 	}
 
+	// The v1 State records whether the endpoint interface is active on the host, in which
+	// case the interface and networks in the Spec are the ones in use.
+	if wepValue.State == "active" {
+		wep.Status = &libapiv3.WorkloadEndpointStatus{
+			ActiveInterfaceName: wepValue.Name,
+			ActiveIPNetworks:    append([]string(nil), ipNets...),
+		}
+	}
+
 	// Figure out the new name based on WEP fields.
 	wepids := names.WorkloadEndpointIdentifiers{
 		Node:         wep.Spec.Node,
@@ -226,6 +241,24 @@ func convertIPNetworks(ipNetworks []net.IPNet) []string {
 	return ipNets
 }
 
+// sortIPNetworks returns the supplied networks in canonical order: IPv4 networks before IPv6
+// networks, and each sorted in ascending numeric order of address and then prefix length.
+func sortIPNetworks(ipNetworks []net.IPNet) []net.IPNet {
+	sorted := append([]net.IPNet(nil), ipNetworks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if vi, vj := sorted[i].Version(), sorted[j].Version(); vi != vj {
+			return vi < vj
+		}
+		if c := bytes.Compare(sorted[i].IP.To16(), sorted[j].IP.To16()); c != 0 {
+			return c < 0
+		}
+		oi, _ := sorted[i].Mask.Size()
+		oj, _ := sorted[j].Mask.Size()
+		return oi < oj
+	})
+	return sorted
+}
+
 // convertIPNATs updates the type of IPNAT struct used.
 func convertIPNATs(v1IPNATs []model.IPNAT) []libapiv3.IPNAT {
 	var ipNATs []libapiv3.IPNAT
@@ -0,0 +1,59 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package converters_test
+
+import (
+	"testing"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+)
+
+// FuzzBackendV1ToAPIV3 checks that converting a v1 WorkloadEndpoint with arbitrary
+// identifiers returns an error rather than panicking when the identifiers are not valid.
+func FuzzBackendV1ToAPIV3(f *testing.F) {
+	for _, entry := range wepTable {
+		kvp := entry.Parameters[1].(*model.KVPair)
+		key := kvp.Key.(model.WorkloadEndpointKey)
+		value := kvp.Value.(*model.WorkloadEndpoint)
+		f.Add(key.Hostname, key.OrchestratorID, key.WorkloadID, key.EndpointID, value.Name, value.ActiveInstanceID)
+	}
+	f.Add("TestNode", "k8s", "default/frontend-5gs43", "eth0", "cali1234", "")
+	f.Add("", "", "", "", "", "")
+
+	f.Fuzz(func(t *testing.T, node, orchestrator, workload, endpoint, iface, instance string) {
+		kvp := &model.KVPair{
+			Key: model.WorkloadEndpointKey{
+				Hostname:       node,
+				OrchestratorID: orchestrator,
+				WorkloadID:     workload,
+				EndpointID:     endpoint,
+			},
+			Value: &model.WorkloadEndpoint{
+				Name:             iface,
+				ActiveInstanceID: instance,
+				Labels:           map[string]string{"calico/k8s_ns": workload},
+				ProfileIDs:       []string{workload},
+			},
+		}
+		res, err := converters.WorkloadEndpoint{}.BackendV1ToAPIV3(kvp)
+		if err == nil && res == nil {
+			t.Errorf("no resource or error returned for %v", kvp.Key)
+		}
+	})
+}
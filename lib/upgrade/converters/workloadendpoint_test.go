@@ -28,8 +28,11 @@ import (
 	"github.com/projectcalico/libcalico-go/lib/apis/v1/unversioned"
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
 	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/names"
 	"github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/testutils"
 	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+	validator "github.com/projectcalico/libcalico-go/lib/validator/v3"
 )
 
 var wepTable = []TableEntry{
@@ -322,8 +325,13 @@ var _ = DescribeTable("v1->v3 workload endpoint conversion tests",
 		Expect(v1KVPResult.Key.(model.WorkloadEndpointKey).OrchestratorID).To(Equal(v1KVP.Key.(model.WorkloadEndpointKey).OrchestratorID))
 		Expect(v1KVPResult.Key.(model.WorkloadEndpointKey).WorkloadID).To(Equal(v1KVP.Key.(model.WorkloadEndpointKey).WorkloadID))
 		Expect(v1KVPResult.Key.(model.WorkloadEndpointKey).EndpointID).To(Equal(v1KVP.Key.(model.WorkloadEndpointKey).EndpointID))
-		// Spec to Value.
-		Expect(*v1KVPResult.Value.(*model.WorkloadEndpoint)).To(Equal(*v1KVP.Value.(*model.WorkloadEndpoint)))
+		// Spec to Value. The MAC is checked using MAC.Equal since the underlying slices
+		// may differ, so is cleared before comparing the remaining fields.
+		v1Value := *v1KVPResult.Value.(*model.WorkloadEndpoint)
+		expectedV1Value := *v1KVP.Value.(*model.WorkloadEndpoint)
+		Expect(v1Value.Mac).To(testutils.EqualMAC(expectedV1Value.Mac))
+		v1Value.Mac, expectedV1Value.Mac = nil, nil
+		Expect(v1Value).To(Equal(expectedV1Value))
 
 		// Test and assert v1 backend to v3 API logic.
 		v3APIResult, err := w.BackendV1ToAPIV3(v1KVP)
@@ -365,6 +373,196 @@ var _ = Describe("v1->v3 workload endpoint conversion tests", func() {
 		Expect(err.Error()).To(Equal("malformed k8s workload ID 'default/frontend-5gs43': workload was not added " +
 			"through the Calico CNI plugin and cannot be converted"))
 	})
+
+	It("Test a Mesos workload ID is not split into a namespace and pod", func() {
+		w := converters.WorkloadEndpoint{}
+		wepBackendV1 := func(orchestrator string) *model.KVPair {
+			return &model.KVPair{
+				Key: model.WorkloadEndpointKey{
+					Hostname:       "TestNode",
+					OrchestratorID: orchestrator,
+					WorkloadID:     "marathon-1234.task-5678",
+					EndpointID:     "eth0",
+				},
+				Value: &model.WorkloadEndpoint{
+					Name:     "cali1234",
+					IPv4Nets: []net.IPNet{net.MustParseNetwork("10.0.0.1/32")},
+				},
+			}
+		}
+
+		res, err := w.BackendV1ToAPIV3(wepBackendV1("mesos"))
+		Expect(err).NotTo(HaveOccurred())
+		mesosWEP := res.(*libapiv3.WorkloadEndpoint)
+		Expect(mesosWEP.Namespace).To(Equal("default"))
+		Expect(mesosWEP.Spec.Orchestrator).To(Equal("mesos"))
+		Expect(mesosWEP.Spec.Workload).To(Equal("marathon-1234.task-5678"))
+		Expect(mesosWEP.Spec.Pod).To(Equal("marathon-1234.task-5678"))
+		Expect(mesosWEP.Name).To(Equal("testnode-mesos-marathon--1234.task--5678-eth0"))
+		Expect(validator.Validate(mesosWEP)).NotTo(HaveOccurred())
+
+		By("checking the name does not collide with a k8s WEP with the same workload ID")
+		res, err = w.BackendV1ToAPIV3(wepBackendV1("k8s"))
+		Expect(err).NotTo(HaveOccurred())
+		k8sWEP := res.(*libapiv3.WorkloadEndpoint)
+		Expect(k8sWEP.Namespace).To(Equal("marathon-1234"))
+		Expect(k8sWEP.Spec.Pod).To(Equal("task-5678"))
+		Expect(k8sWEP.Name).NotTo(Equal(mesosWEP.Name))
+	})
+
+	It("Test all NAT mappings are preserved, and duplicate internal IPs fail validation", func() {
+		w := converters.WorkloadEndpoint{}
+		wepBackendV1 := &model.KVPair{
+			Key: model.WorkloadEndpointKey{
+				Hostname:       "TestNode",
+				OrchestratorID: "cni",
+				WorkloadID:     "1337495556942031415926535",
+				EndpointID:     "eth0",
+			},
+			Value: &model.WorkloadEndpoint{
+				Name:     "cali1234",
+				IPv4Nets: []net.IPNet{net.MustParseNetwork("10.0.0.1/32"), net.MustParseNetwork("10.0.0.2/32")},
+				IPv4NAT: []model.IPNAT{
+					{IntIP: net.MustParseIP("10.0.0.1"), ExtIP: net.MustParseIP("172.0.0.1")},
+					{IntIP: net.MustParseIP("10.0.0.1"), ExtIP: net.MustParseIP("172.0.0.2")},
+					{IntIP: net.MustParseIP("10.0.0.2"), ExtIP: net.MustParseIP("172.0.0.3")},
+				},
+			},
+		}
+		res, err := w.BackendV1ToAPIV3(wepBackendV1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.(*libapiv3.WorkloadEndpoint).Spec.IPNATs).To(Equal([]libapiv3.IPNAT{
+			{InternalIP: "10.0.0.1", ExternalIP: "172.0.0.1"},
+			{InternalIP: "10.0.0.1", ExternalIP: "172.0.0.2"},
+			{InternalIP: "10.0.0.2", ExternalIP: "172.0.0.3"},
+		}))
+		Expect(validator.Validate(res)).To(MatchError(ContainSubstring("InternalIP is used by multiple NATs")))
+	})
+
+	It("Test a node name containing dots gives a valid v3 name that can be decoded", func() {
+		w := converters.WorkloadEndpoint{}
+		res, err := w.BackendV1ToAPIV3(&model.KVPair{
+			Key: model.WorkloadEndpointKey{
+				Hostname:       "Node-1.Example.com",
+				OrchestratorID: "k8s",
+				WorkloadID:     "default.frontend-5gs43",
+				EndpointID:     "eth0",
+			},
+			Value: &model.WorkloadEndpoint{
+				Name:     "cali1234",
+				IPv4Nets: []net.IPNet{net.MustParseNetwork("10.0.0.1/32")},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		wep := res.(*libapiv3.WorkloadEndpoint)
+		Expect(wep.Name).To(Equal("node--1.example.com-k8s-frontend--5gs43-eth0"))
+		Expect(validator.Validate(wep)).NotTo(HaveOccurred())
+		Expect(names.ExtractDashSeparatedParms(wep.Name, 4)).To(Equal([]string{
+			"node-1.example.com", "k8s", "frontend-5gs43", "eth0",
+		}))
+	})
+
+	It("Test IPv4-mapped IPv6 networks are stored as IPv4 networks", func() {
+		w := converters.WorkloadEndpoint{}
+		mappedNet := net.IPNet{IPNet: cnet.IPNet{
+			IP:   cnet.ParseIP("::ffff:10.0.0.1"),
+			Mask: cnet.CIDRMask(128, 128),
+		}}
+		kvp, err := w.APIV1ToBackendV1(&apiv1.WorkloadEndpoint{
+			Metadata: apiv1.WorkloadEndpointMetadata{
+				Name:         "eth0",
+				Workload:     "1337495556942031415926535",
+				Orchestrator: "cni",
+				Node:         "TestNode",
+			},
+			Spec: apiv1.WorkloadEndpointSpec{
+				IPNetworks:    []net.IPNet{mappedNet, net.MustParseNetwork("2001::/128")},
+				IPNATs:        []apiv1.IPNAT{{InternalIP: net.MustParseIP("::ffff:10.0.0.1"), ExternalIP: net.MustParseIP("172.0.0.1")}},
+				InterfaceName: "cali1234",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		wep := kvp.Value.(*model.WorkloadEndpoint)
+		Expect(wep.IPv4Nets).To(Equal([]net.IPNet{net.MustParseNetwork("10.0.0.1/32")}))
+		Expect(wep.IPv4Nets[0].IP).To(HaveLen(cnet.IPv4len))
+		Expect(wep.IPv6Nets).To(Equal([]net.IPNet{net.MustParseNetwork("2001::/128")}))
+		Expect(wep.IPv4NAT).To(HaveLen(1))
+		Expect(wep.IPv6NAT).To(BeEmpty())
+
+		res, err := w.BackendV1ToAPIV3(kvp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.(*libapiv3.WorkloadEndpoint).Spec.IPNetworks).To(Equal([]string{"10.0.0.1/32", "2001::/128"}))
+	})
+
+	DescribeTable("Test the Status is populated from the v1 State",
+		func(state string, expected *libapiv3.WorkloadEndpointStatus) {
+			w := converters.WorkloadEndpoint{}
+			wepBackendV1 := &model.KVPair{
+				Key: model.WorkloadEndpointKey{
+					Hostname:       "TestNode",
+					OrchestratorID: "cni",
+					WorkloadID:     "1337495556942031415926535",
+					EndpointID:     "eth0",
+				},
+				Value: &model.WorkloadEndpoint{
+					State:    state,
+					Name:     "cali1234",
+					IPv4Nets: []net.IPNet{net.MustParseNetwork("10.0.0.1/32")},
+					IPv6Nets: []net.IPNet{net.MustParseNetwork("2001::/128")},
+				},
+			}
+			res, err := w.BackendV1ToAPIV3(wepBackendV1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.(*libapiv3.WorkloadEndpoint).Status).To(Equal(expected))
+		},
+		Entry("active endpoint", "active", &libapiv3.WorkloadEndpointStatus{
+			ActiveInterfaceName: "cali1234",
+			ActiveIPNetworks:    []string{"10.0.0.1/32", "2001::/128"},
+		}),
+		Entry("inactive endpoint", "inactive", nil),
+		Entry("endpoint with no state", "", nil),
+	)
+
+	DescribeTable("Test the IPNetworks are sorted into canonical order",
+		func(ipv4Nets, ipv6Nets []string, expected []string) {
+			w := converters.WorkloadEndpoint{}
+			value := &model.WorkloadEndpoint{Name: "cali1234"}
+			for _, n := range ipv4Nets {
+				value.IPv4Nets = append(value.IPv4Nets, net.MustParseNetwork(n))
+			}
+			for _, n := range ipv6Nets {
+				value.IPv6Nets = append(value.IPv6Nets, net.MustParseNetwork(n))
+			}
+			res, err := w.BackendV1ToAPIV3(&model.KVPair{
+				Key: model.WorkloadEndpointKey{
+					Hostname:       "TestNode",
+					OrchestratorID: "cni",
+					WorkloadID:     "1337495556942031415926535",
+					EndpointID:     "eth0",
+				},
+				Value: value,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.(*libapiv3.WorkloadEndpoint).Spec.IPNetworks).To(Equal(expected))
+		},
+		Entry("unsorted IPv4 networks",
+			[]string{"10.0.0.10/32", "10.0.0.9/32", "9.0.0.1/32"}, nil,
+			[]string{"9.0.0.1/32", "10.0.0.9/32", "10.0.0.10/32"},
+		),
+		Entry("unsorted IPv6 networks",
+			nil, []string{"2001::10/128", "2001::9/128", "fd00::1/128"},
+			[]string{"2001::9/128", "2001::10/128", "fd00::1/128"},
+		),
+		Entry("IPv4 networks are ordered before IPv6 networks",
+			[]string{"10.0.0.2/32", "10.0.0.1/32"}, []string{"2001::2/128", "::1/128"},
+			[]string{"10.0.0.1/32", "10.0.0.2/32", "::1/128", "2001::2/128"},
+		),
+		Entry("IPv6 networks in the IPv4 list are ordered after the IPv4 networks",
+			[]string{"2001::1/128", "10.0.0.1/32"}, nil,
+			[]string{"10.0.0.1/32", "2001::1/128"},
+		),
+		Entry("no networks", nil, nil, []string(nil)),
+	)
 })
 
 func makeLabelsV1() map[string]string {
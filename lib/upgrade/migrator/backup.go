@@ -0,0 +1,145 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients/v1/compat"
+)
+
+// BackupEntry is the content of each file written by MigrateWithBackup.  Key is the etcd key
+// of the entry and Value is the exact value stored at that key, so an entry can be restored
+// with e.g. etcdctl put "$(jq -r .key <file>)" "$(jq -r .value <file>)".
+type BackupEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// MigrateWithBackup migrates the data from v1 format to v3 as Migrate does, backing up the
+// data either side of the migration.  Before migrating, each v1 etcd entry is written to
+// <backupDir>/v1/<resource type>/, and after migrating each of the stored v3 resources is
+// written to <backupDir>/v3/<kind>/.  The IPAM data is backed up in the IPAM directories,
+// <backupDir>/v1/IPAM/ and <backupDir>/v3/IPAM/.  Each file contains a single BackupEntry.
+// The v1 backup is written before any data is modified, so the migration is not attempted if
+// the backup fails.
+func (m *migrationHelper) MigrateWithBackup(ctx context.Context, backupDir string) error {
+	if err := m.backupV1(ctx, backupDir); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return MigrationError{Type: ErrorGeneric, Err: err}
+	}
+	data, err := m.Migrate()
+	if err != nil {
+		return err
+	}
+	return m.backupV3(backupDir, data)
+}
+
+// backupV1 writes the v1 data that will be migrated, including the IPAM data, to
+// <backupDir>/v1/.  If an error is returned it will be of type MigrationError.
+func (m *migrationHelper) backupV1(ctx context.Context, backupDir string) error {
+	m.status("Backing up the v1 data to %s", backupDir)
+	v1, err := m.ListV1Resources(ctx)
+	if err != nil {
+		m.statusError("Unable to query the v1 data")
+		m.statusBullet("cause: %v", err)
+		return MigrationError{Type: ErrorGeneric, Err: err}
+	}
+	for resourceType, kvps := range v1 {
+		if !m.clientv1.IsKDD() {
+			// Back up the entries as they are stored in etcd, rather than the composite
+			// entries returned by the v1 client.
+			var stored []*model.KVPair
+			for _, kvp := range kvps {
+				stored = append(stored, compat.ToDatastoreKVPairs(kvp)...)
+			}
+			kvps = stored
+		}
+		if err := writeBackup(filepath.Join(backupDir, "v1", resourceType), kvps); err != nil {
+			m.statusError("Unable to back up the v1 data")
+			m.statusBullet("cause: %v", err)
+			return MigrationError{
+				Type: ErrorGeneric,
+				Err:  fmt.Errorf("unable to back up the v1 %s data: %v", resourceType, err),
+			}
+		}
+	}
+	m.statusBullet("v1 data backed up successfully")
+	return nil
+}
+
+// backupV3 writes the v3 resources and IPAM data stored by the migration to <backupDir>/v3/.
+// If an error is returned it will be of type MigrationError.
+func (m *migrationHelper) backupV3(backupDir string, data *MigrationData) error {
+	// The stored resources have been updated with the name, UID and creation timestamp used
+	// in storage, so these are backed up as they are in the datastore.
+	m.status("Backing up the v3 data to %s", backupDir)
+	v3 := map[string][]*model.KVPair{}
+	for _, r := range data.Resources {
+		kind := r.GetObjectKind().GroupVersionKind().Kind
+		v3[kind] = append(v3[kind], &model.KVPair{Key: resourceToKey(r), Value: r})
+	}
+	if len(data.IPAM) != 0 {
+		v3[ResourceTypeIPAM] = data.IPAM
+	}
+	for kind, kvps := range v3 {
+		if err := writeBackup(filepath.Join(backupDir, "v3", kind), kvps); err != nil {
+			m.statusError("Unable to back up the v3 data")
+			m.statusBullet("cause: %v", err)
+			return MigrationError{
+				Type: ErrorGeneric,
+				Err:  fmt.Errorf("unable to back up the v3 %s data: %v", kind, err),
+			}
+		}
+	}
+	m.statusBullet("v3 data backed up successfully")
+	return nil
+}
+
+// writeBackup writes a BackupEntry file for each of the KVPairs to the supplied directory.
+// The file name is the escaped etcd key, so that each entry has a unique file.
+func writeBackup(dir string, kvps []*model.KVPair) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for _, kvp := range kvps {
+		key, err := model.KeyToDefaultPath(kvp.Key)
+		if err != nil {
+			return err
+		}
+		value, err := model.SerializeValue(kvp)
+		if err != nil {
+			return fmt.Errorf("unable to serialize %s: %v", key, err)
+		}
+		b, err := json.MarshalIndent(BackupEntry{Key: key, Value: string(value)}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, url.QueryEscape(key)+".json"), b, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients/v1/compat"
+)
+
+var _ = Describe("Test migrating with a backup", func() {
+	var backupDir string
+	var be *fakeBackend
+	BeforeEach(func() {
+		var err error
+		backupDir, err = ioutil.TempDir("", "migration-backup")
+		Expect(err).NotTo(HaveOccurred())
+		be = &fakeBackend{kvps: map[string]*model.KVPair{}}
+	})
+	AfterEach(func() {
+		os.RemoveAll(backupDir)
+	})
+
+	// readBackup returns the entries backed up in the supplied directory, keyed by etcd key.
+	readBackup := func(dir string) map[string]string {
+		files, err := ioutil.ReadDir(filepath.Join(backupDir, dir))
+		Expect(err).NotTo(HaveOccurred())
+		entries := map[string]string{}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(filepath.Join(backupDir, dir, f.Name()))
+			Expect(err).NotTo(HaveOccurred())
+			var e BackupEntry
+			Expect(json.Unmarshal(b, &e)).To(Succeed())
+			entries[e.Key] = e.Value
+		}
+		return entries
+	}
+
+	clientv1 := fakeClientV1{
+		kdd: true,
+		kvps: []*model.KVPair{
+			{Key: model.GlobalConfigKey{Name: "LogSeverityScreen"}, Value: "Debug"},
+			{Key: model.GlobalBGPConfigKey{Name: "LogLevel"}, Value: "debug"},
+		},
+	}
+
+	It("should back up the v1 and the v3 data", func() {
+		mh := New(fakeClientV3{backend: be}, clientv1, nil)
+		Expect(mh.MigrateWithBackup(context.Background(), backupDir)).To(Succeed())
+
+		Expect(readBackup("v1/FelixConfiguration")).To(Equal(map[string]string{
+			"/calico/v1/config/LogSeverityScreen": "Debug",
+		}))
+		Expect(readBackup("v1/BGPConfiguration")).To(Equal(map[string]string{
+			"/calico/bgp/v1/global/LogLevel": "debug",
+		}))
+
+		v3 := readBackup("v3/FelixConfiguration")
+		Expect(v3).To(HaveKey("/calico/resources/v3/projectcalico.org/felixconfigurations/default"))
+		Expect(v3).To(HaveLen(1))
+		Expect(readBackup("v3/BGPConfiguration")).To(HaveKey("/calico/resources/v3/projectcalico.org/bgpconfigurations/default"))
+
+		// The backed up v3 resources should have been stored.
+		Expect(be.kvps).To(HaveKey(model.ResourceKey{Kind: "FelixConfiguration", Name: "default"}.String()))
+		Expect(be.kvps).To(HaveKey(model.ResourceKey{Kind: "BGPConfiguration", Name: "default"}.String()))
+	})
+
+	It("should not migrate if the v1 data cannot be backed up", func() {
+		blocker := filepath.Join(backupDir, "v1")
+		Expect(ioutil.WriteFile(blocker, nil, 0600)).To(Succeed())
+
+		mh := New(fakeClientV3{backend: be}, clientv1, nil)
+		Expect(mh.MigrateWithBackup(context.Background(), backupDir)).NotTo(Succeed())
+		Expect(be.kvps).To(BeEmpty())
+	})
+
+	It("should back up the v1 and the v3 IPAM data", func() {
+		cidr := net.MustParseCIDR("10.0.0.0/26")
+		handle := &model.KVPair{Key: model.IPAMHandleKey{HandleID: "handle1"}, Value: &model.IPAMHandle{HandleID: "handle1"}}
+		block := &model.KVPair{Key: model.BlockKey{CIDR: cidr}, Value: &model.AllocationBlock{CIDR: cidr}}
+		affinity := &model.KVPair{
+			Key:   model.BlockAffinityKey{CIDR: cidr, Host: "node1"},
+			Value: &model.BlockAffinity{State: model.StateConfirmed},
+		}
+		mh := &migrationHelper{clientv1: fakeClientV1{kvps: []*model.KVPair{handle, block, affinity}}}
+
+		Expect(mh.backupV1(context.Background(), backupDir)).To(Succeed())
+		Expect(readBackup("v1/IPAM")).To(HaveKey("/calico/ipam/v2/handle/handle1"))
+		Expect(readBackup("v1/IPAM")).To(HaveKey("/calico/ipam/v2/assignment/ipv4/block/10.0.0.0-26"))
+		Expect(readBackup("v1/IPAM")).To(HaveKey("/calico/ipam/v2/host/node1/ipv4/block/10.0.0.0-26"))
+
+		Expect(mh.backupV3(backupDir, &MigrationData{IPAM: []*model.KVPair{handle, block}})).To(Succeed())
+		Expect(readBackup("v3/IPAM")).To(HaveLen(2))
+		Expect(readBackup("v3/IPAM")).To(HaveKey("/calico/ipam/v2/handle/handle1"))
+	})
+
+	It("should back up the components of the composite v1 entries", func() {
+		asn := numorstring.ASNumber(64512)
+		ip := net.MustParseIP("10.0.0.1")
+		node := &model.KVPair{
+			Key:   model.NodeKey{Hostname: "node1"},
+			Value: &model.Node{BGPIPv4Addr: &ip, BGPASNumber: &asn},
+		}
+		var keys []string
+		for _, kvp := range compat.ToDatastoreKVPairs(node) {
+			key, err := model.KeyToDefaultPath(kvp.Key)
+			Expect(err).NotTo(HaveOccurred())
+			value, err := model.SerializeValue(kvp)
+			Expect(err).NotTo(HaveOccurred())
+			keys = append(keys, key+"="+string(value))
+		}
+		Expect(keys).To(Equal([]string{
+			"/calico/v1/host/node1/metadata={}",
+			"/calico/bgp/v1/host/node1/ip_addr_v4=10.0.0.1",
+			"/calico/bgp/v1/host/node1/as_num=64512",
+		}))
+
+		mesh := &model.KVPair{Key: model.GlobalBGPConfigKey{Name: "NodeMeshEnabled"}, Value: "true"}
+		Expect(compat.ToDatastoreKVPairs(mesh)).To(Equal([]*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "node_mesh"}, Value: `{"enabled":true}`},
+		}))
+		Expect(mesh.Value).To(Equal("true"))
+
+		profile := &model.KVPair{Key: model.ProfileKey{Name: "profile1"}, Value: &model.Profile{}}
+		Expect(compat.ToDatastoreKVPairs(profile)).To(HaveLen(3))
+	})
+})
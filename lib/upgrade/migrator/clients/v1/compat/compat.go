@@ -26,11 +26,23 @@ import (
 	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients/v1/etcdv2"
 )
 
+// Client is the interface to the etcdv2 datastore used by the ModelAdaptor.  It is
+// implemented by the etcdv2.EtcdClient, and may be implemented by other stores of the
+// etcdv2 entries, such as an export of the datastore.
+type Client interface {
+	Update(d *model.KVPair) (*model.KVPair, error)
+	Apply(d *model.KVPair) (*model.KVPair, error)
+	Get(k model.Key) (*model.KVPair, error)
+	List(l model.ListInterface) ([]*model.KVPair, error)
+}
+
+var _ Client = &etcdv2.EtcdClient{}
+
 type ModelAdaptor struct {
-	client *etcdv2.EtcdClient
+	client Client
 }
 
-func NewAdaptor(c *etcdv2.EtcdClient) *ModelAdaptor {
+func NewAdaptor(c Client) *ModelAdaptor {
 	return &ModelAdaptor{client: c}
 }
 
@@ -338,6 +350,69 @@ func ToTagsLabelsRules(d *model.KVPair) (t, l, r *model.KVPair) {
 	return t, l, r
 }
 
+// ToDatastoreKVPairs converts a KVPair returned by the ModelAdaptor into the KVPairs that are
+// stored in the etcdv2 datastore.  This is the inverse of the processing performed by Get and
+// List: composite Profiles and Nodes are split into their components, and the Global BGP
+// Config is converted back to the back-compatible names and values.
+func ToDatastoreKVPairs(d *model.KVPair) []*model.KVPair {
+	switch k := d.Key.(type) {
+	case model.ProfileKey:
+		t, l, r := ToTagsLabelsRules(d)
+		return []*model.KVPair{t, l, r}
+	case model.NodeKey:
+		return toDatastoreNode(k, d.Value.(*model.Node))
+	case model.GlobalBGPConfigKey:
+		return []*model.KVPair{toDatastoreGlobalBGPConfig(*d)}
+	}
+	return []*model.KVPair{d}
+}
+
+// toDatastoreNode converts a composite Node into the host metadata, BGP config and
+// orchestrator references that are read by getNodeSubcomponents.
+func toDatastoreNode(nk model.NodeKey, nv *model.Node) []*model.KVPair {
+	kvps := []*model.KVPair{{Key: model.HostMetadataKey{Hostname: nk.Hostname}, Value: &model.HostMetadata{}}}
+	bgpConfig := func(name, value string) {
+		kvps = append(kvps, &model.KVPair{
+			Key:   model.NodeBGPConfigKey{Nodename: nk.Hostname, Name: name},
+			Value: value,
+		})
+	}
+	if nv.BGPIPv4Addr != nil {
+		bgpConfig("ip_addr_v4", nv.BGPIPv4Addr.String())
+	}
+	if nv.BGPIPv4Net != nil {
+		bgpConfig("network_v4", nv.BGPIPv4Net.String())
+	}
+	if nv.BGPIPv6Addr != nil {
+		bgpConfig("ip_addr_v6", nv.BGPIPv6Addr.String())
+	}
+	if nv.BGPIPv6Net != nil {
+		bgpConfig("network_v6", nv.BGPIPv6Net.String())
+	}
+	if nv.BGPASNumber != nil {
+		bgpConfig("as_num", nv.BGPASNumber.String())
+	}
+	if len(nv.OrchRefs) > 0 {
+		kvps = append(kvps, &model.KVPair{Key: model.OrchRefKey{Hostname: nk.Hostname}, Value: nv.OrchRefs})
+	}
+	return kvps
+}
+
+// toDatastoreGlobalBGPConfig modifies the Global BGP Config KVPair to the format required in the
+// datastore.  This is the inverse of fromDatastoreGlobalBGPConfig.
+func toDatastoreGlobalBGPConfig(d model.KVPair) *model.KVPair {
+	d.Key = toDatastoreGlobalBGPConfigKey(d.Key.(model.GlobalBGPConfigKey))
+	if d.Key.(model.GlobalBGPConfigKey).Name == "node_mesh" && d.Value != nil {
+		var enabled bool
+		if err := json.Unmarshal([]byte(d.Value.(string)), &enabled); err != nil {
+			log.Info("Error parsing node to node mesh")
+		}
+		v, _ := json.Marshal(nodeToNodeMesh{Enabled: enabled})
+		d.Value = string(v)
+	}
+	return &d
+}
+
 // toDatastoreGlobalBGPConfigKey modifies the Global BGP Config key to the one required by
 // the datastore (for back-compatibility).
 func toDatastoreGlobalBGPConfigKey(key model.GlobalBGPConfigKey) model.GlobalBGPConfigKey {
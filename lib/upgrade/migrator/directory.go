@@ -0,0 +1,234 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients/v1/compat"
+)
+
+// hostDirectory is the root of the per-host entries in the etcdv2 datastore.
+const hostDirectory = "/calico/v1/host/"
+
+// NewDirectoryClientV1 returns a v1 client that reads the etcdv2 entries exported to the
+// JSON files in dir, or any of its subdirectories, rather than from a live datastore.
+// Each file contains either a single BackupEntry or a list of them, such as the files
+// written by MigrateWithBackup.  The client is read-only.
+//
+// The client may be passed to New to use the migration helper without access to the etcdv2
+// datastore, e.g. to run DryValidate against an export of an air-gapped deployment.
+func NewDirectoryClientV1(dir string) (clients.V1ClientInterface, error) {
+	entries := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var backup []BackupEntry
+		if err := json.Unmarshal(b, &backup); err != nil {
+			var entry BackupEntry
+			if err := json.Unmarshal(b, &entry); err != nil {
+				return fmt.Errorf("unable to parse %s: %v", path, err)
+			}
+			backup = []BackupEntry{entry}
+		}
+		for _, e := range backup {
+			entries[e.Key] = e.Value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("dir", dir).Infof("Read %d v1 entries", len(entries))
+	return compat.NewAdaptor(&directoryClientV1{entries: entries}), nil
+}
+
+// directoryClientV1 implements the compat.Client interface over a set of etcdv2 entries,
+// in the same way as the etcdv2 client.
+type directoryClientV1 struct {
+	// The entry values, keyed by etcd key.
+	entries map[string]string
+}
+
+func (c *directoryClientV1) Update(d *model.KVPair) (*model.KVPair, error) {
+	return nil, cerrors.ErrorOperationNotSupported{Operation: "Update", Identifier: d.Key, Reason: "the v1 data is read-only"}
+}
+
+func (c *directoryClientV1) Apply(d *model.KVPair) (*model.KVPair, error) {
+	return nil, cerrors.ErrorOperationNotSupported{Operation: "Apply", Identifier: d.Key, Reason: "the v1 data is read-only"}
+}
+
+func (c *directoryClientV1) Get(k model.Key) (*model.KVPair, error) {
+	key, err := model.KeyToDefaultPath(k)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := c.entries[key]
+	if !ok {
+		// As with the etcdv2 client, older deployments may not have the host metadata,
+		// so return an empty metadata if there are any entries for the host.
+		if hk, ok := k.(model.HostMetadataKey); ok && c.hasHost(hk.Hostname) {
+			return &model.KVPair{Key: k, Value: &model.HostMetadata{}}, nil
+		}
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: k}
+	}
+	v, err := model.ParseValue(k, []byte(value))
+	if err != nil {
+		return nil, err
+	}
+	return &model.KVPair{Key: k, Value: v}, nil
+}
+
+func (c *directoryClientV1) List(l model.ListInterface) ([]*model.KVPair, error) {
+	if hl, ok := l.(model.HostMetadataListOptions); ok {
+		return c.listHostMetadata(hl), nil
+	}
+
+	var list []*model.KVPair
+	for _, key := range c.sortedKeys() {
+		if k := l.KeyFromDefaultPath(key); k != nil {
+			if v, err := model.ParseValue(k, []byte(c.entries[key])); err == nil {
+				list = append(list, &model.KVPair{Key: k, Value: v})
+			}
+		}
+	}
+	if pl, ok := l.(model.ProfileListOptions); ok {
+		return pl.ListConvert(list), nil
+	}
+	return list, nil
+}
+
+// listHostMetadata returns a HostMetadata for each host with entries, since older
+// deployments may not have the host metadata.
+func (c *directoryClientV1) listHostMetadata(l model.HostMetadataListOptions) []*model.KVPair {
+	var kvps []*model.KVPair
+	seen := map[string]bool{}
+	for _, key := range c.sortedKeys() {
+		if !strings.HasPrefix(key, hostDirectory) {
+			continue
+		}
+		host := strings.SplitN(strings.TrimPrefix(key, hostDirectory), "/", 2)[0]
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+		if k := l.KeyFromDefaultPath(hostDirectory + host + "/metadata"); k != nil {
+			kvps = append(kvps, &model.KVPair{Key: k, Value: &model.HostMetadata{}})
+		}
+	}
+	return kvps
+}
+
+func (c *directoryClientV1) hasHost(hostname string) bool {
+	for key := range c.entries {
+		if strings.HasPrefix(key, hostDirectory+hostname+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *directoryClientV1) sortedKeys() []string {
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ConvertDirectory converts the etcdv2 entries exported to the JSON files in inputDir, in
+// the format read by NewDirectoryClientV1, and writes the converted v3 resources to
+// outputDir as YAML manifests that may be applied with kubectl or calicoctl.  Each resource
+// is written to <outputDir>/<kind>/<name>.yaml, with the name prefixed by the namespace for
+// namespaced resources.  The IPAM data is not converted.
+//
+// The conversion is the same as that of the live migration, and the options are applied in
+// the same way.  No manifests are written if any of the resources fail to convert; to list
+// the resources that fail, run DryValidate using a NewDirectoryClientV1 for inputDir.  If
+// an error is returned it will be of type MigrationError.
+func ConvertDirectory(inputDir, outputDir string, opts ...Option) error {
+	clientv1, err := NewDirectoryClientV1(inputDir)
+	if err != nil {
+		return MigrationError{
+			Type: ErrorGeneric,
+			Err:  fmt.Errorf("unable to read the v1 data from %s: %v", inputDir, err),
+		}
+	}
+	m := New(nil, clientv1, nil, opts...).(*migrationHelper)
+	data, err := m.queryAndConvertResources()
+	if err != nil {
+		return MigrationError{
+			Type: ErrorGeneric,
+			Err:  fmt.Errorf("error converting data: %v", err),
+		}
+	}
+	if data.HasErrors() {
+		return MigrationError{
+			Type: ErrorConvertingData,
+			Err: fmt.Errorf("error converting data: %d conversion error(s), %d validation error(s) and %d name clash(es)",
+				len(data.ConversionErrors), len(data.ConvertedResourceValidationErrors), len(data.NameClashes)),
+		}
+	}
+
+	for _, r := range data.Resources {
+		if err := writeManifest(outputDir, r); err != nil {
+			return MigrationError{
+				Type: ErrorMigratingData,
+				Err:  fmt.Errorf("unable to write the v3 manifests: %v", err),
+			}
+		}
+	}
+	return nil
+}
+
+// writeManifest writes the resource as a YAML manifest to a file in the directory for its
+// kind.
+func writeManifest(outputDir string, r converters.Resource) error {
+	dir := filepath.Join(outputDir, r.GetObjectKind().GroupVersionKind().Kind)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := r.GetObjectMeta().GetName()
+	if ns := r.GetObjectMeta().GetNamespace(); ns != "" {
+		name = ns + "." + name
+	}
+	b, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("unable to serialize %s: %v", resourceToKey(r), err)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name+".yaml"), b, 0600)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients/v1/compat"
+)
+
+var _ = Describe("Test converting a directory", func() {
+	var inputDir, outputDir string
+	BeforeEach(func() {
+		var err error
+		inputDir, err = ioutil.TempDir("", "migration-input")
+		Expect(err).NotTo(HaveOccurred())
+		outputDir, err = ioutil.TempDir("", "migration-output")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(inputDir)
+		os.RemoveAll(outputDir)
+	})
+
+	// writeInput writes the datastore entries for the supplied v1 KVPairs to the input
+	// directory.
+	writeInput := func(kvps ...*model.KVPair) {
+		var entries []*model.KVPair
+		for _, kvp := range kvps {
+			entries = append(entries, compat.ToDatastoreKVPairs(kvp)...)
+		}
+		Expect(writeBackup(filepath.Join(inputDir, "v1"), entries)).To(Succeed())
+	}
+
+	ipPool := func(cidr string) *model.KVPair {
+		c := net.MustParseCIDR(cidr)
+		return &model.KVPair{Key: model.IPPoolKey{CIDR: c}, Value: &model.IPPool{CIDR: c, IPAM: true}}
+	}
+
+	asn := numorstring.ASNumber(64512)
+	ip := net.MustParseIP("10.0.0.1")
+	node := &model.KVPair{
+		Key:   model.NodeKey{Hostname: "node1"},
+		Value: &model.Node{BGPIPv4Addr: &ip, BGPASNumber: &asn},
+	}
+
+	It("should write a manifest for each converted resource", func() {
+		writeInput(ipPool("10.0.0.0/16"), ipPool("10.1.0.0/16"), node)
+		Expect(ConvertDirectory(inputDir, outputDir)).To(Succeed())
+
+		files, err := ioutil.ReadDir(filepath.Join(outputDir, "IPPool"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		b, err := ioutil.ReadFile(filepath.Join(outputDir, "IPPool", "10-0-0-0-16.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		var pool apiv3.IPPool
+		Expect(yaml.Unmarshal(b, &pool)).To(Succeed())
+		Expect(pool.Kind).To(Equal("IPPool"))
+		Expect(pool.Spec.CIDR).To(Equal("10.0.0.0/16"))
+
+		b, err = ioutil.ReadFile(filepath.Join(outputDir, "Node", "node1.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		var n libapiv3.Node
+		Expect(yaml.Unmarshal(b, &n)).To(Succeed())
+		Expect(n.Spec.BGP).NotTo(BeNil())
+		Expect(n.Spec.BGP.IPv4Address).To(Equal("10.0.0.1/32"))
+		Expect(n.Spec.BGP.ASNumber).To(Equal(&asn))
+	})
+
+	It("should not write any manifests if a resource fails to convert", func() {
+		writeInput(ipPool("10.0.0.0/16"), ipPool("10.0.0.0/24"))
+		err := ConvertDirectory(inputDir, outputDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorConvertingData))
+
+		files, err := ioutil.ReadDir(outputDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+
+	It("should validate the input directory with a dry run", func() {
+		writeInput(ipPool("10.0.0.0/16"), ipPool("10.0.0.0/24"), node)
+		clientv1, err := NewDirectoryClientV1(inputDir)
+		Expect(err).NotTo(HaveOccurred())
+		report, err := New(nil, clientv1, nil).DryValidate(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		var buf bytes.Buffer
+		Expect(report.WriteText(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("IPPool: 0 converted, 1 failed\n"))
+		Expect(buf.String()).To(ContainSubstring("Node: 1 converted, 0 failed\n"))
+	})
+
+	It("should read input files containing a list of entries", func() {
+		entries := `[
+			{"key": "/calico/v1/config/LogSeverityScreen", "value": "Debug"},
+			{"key": "/calico/bgp/v1/global/as_num", "value": "64512"}
+		]`
+		Expect(ioutil.WriteFile(filepath.Join(inputDir, "dump.json"), []byte(entries), 0600)).To(Succeed())
+		Expect(ConvertDirectory(inputDir, outputDir)).To(Succeed())
+
+		b, err := ioutil.ReadFile(filepath.Join(outputDir, "FelixConfiguration", "default.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("logSeverityScreen: Debug"))
+		b, err = ioutil.ReadFile(filepath.Join(outputDir, "BGPConfiguration", "default.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("asNumber: 64512"))
+	})
+
+	It("should fail to read an invalid input file", func() {
+		Expect(ioutil.WriteFile(filepath.Join(inputDir, "bad.json"), []byte("{"), 0600)).To(Succeed())
+		err := ConvertDirectory(inputDir, outputDir)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorGeneric))
+	})
+})
@@ -0,0 +1,116 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+	validatorv3 "github.com/projectcalico/libcalico-go/lib/validator/v3"
+)
+
+// estimateSampleSize is the number of v1 resources that are converted to measure the
+// conversion rate.
+const estimateSampleSize = 10
+
+// sampleConverters contains the converters used to sample the conversion rate of the
+// resource types that are converted one resource at a time.  The configuration resource
+// types are converted from the combined v1 config and are not sampled.
+var sampleConverters = map[string]converters.Converter{
+	ResourceTypeNode:                converters.Node{},
+	ResourceTypeBGPPeer:             converters.BGPPeer{},
+	ResourceTypeHostEndpoint:        converters.HostEndpoint{},
+	ResourceTypeIPPool:              converters.IPPool{},
+	ResourceTypeGlobalNetworkPolicy: converters.Policy{},
+	ResourceTypeProfile:             converters.Profile{},
+	ResourceTypeWorkloadEndpoint:    converters.WorkloadEndpoint{},
+}
+
+// MigrationEstimate is an estimate of the time taken to migrate the v1 data.  It is
+// returned by EstimateMigrationDuration.
+type MigrationEstimate struct {
+	// The number of v1 resources to be migrated.
+	TotalResources int
+
+	// The estimated time taken to list and convert the v1 resources.
+	EstimatedDuration time.Duration
+
+	// The measured number of resources converted per second.
+	RatePerSecond float64
+}
+
+// EstimateMigrationDuration estimates the time taken to migrate the v1 data, to help plan
+// a maintenance window.  It lists the v1 resources, converts and validates a random sample
+// of them to measure the conversion rate, and extrapolates the rate to all of the
+// resources.  Nothing is written to the v3 datastore, so the estimate does not include the
+// time taken to write the converted resources.
+func (m *migrationHelper) EstimateMigrationDuration(ctx context.Context) (*MigrationEstimate, error) {
+	start := time.Now()
+	resources, err := m.ListV1Resources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listDuration := time.Since(start)
+
+	estimate := &MigrationEstimate{}
+	var samples []sampleResource
+	for rt, kvps := range resources {
+		estimate.TotalResources += len(kvps)
+		if c, ok := sampleConverters[rt]; ok {
+			for _, kvp := range kvps {
+				samples = append(samples, sampleResource{kvp: kvp, converter: c})
+			}
+		}
+	}
+	if len(samples) == 0 {
+		estimate.EstimatedDuration = listDuration
+		return estimate, nil
+	}
+
+	rand.Shuffle(len(samples), func(i, j int) { samples[i], samples[j] = samples[j], samples[i] })
+	if len(samples) > estimateSampleSize {
+		samples = samples[:estimateSampleSize]
+	}
+
+	start = time.Now()
+	for _, s := range samples {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Conversion and validation errors are reported by the migration itself, here we
+		// are only interested in how long the work takes.
+		if r, err := s.converter.BackendV1ToAPIV3(s.kvp); err == nil {
+			_ = validatorv3.Validate(r)
+		}
+	}
+	perResource := time.Since(start) / time.Duration(len(samples))
+	if perResource <= 0 {
+		perResource = 1
+	}
+
+	estimate.RatePerSecond = float64(time.Second) / float64(perResource)
+	estimate.EstimatedDuration = listDuration + perResource*time.Duration(estimate.TotalResources)
+	m.status("Estimated migration of %d v1 resources will take %v", estimate.TotalResources, estimate.EstimatedDuration)
+	return estimate, nil
+}
+
+// sampleResource is a v1 resource sampled to measure the conversion rate.
+type sampleResource struct {
+	kvp       *model.KVPair
+	converter converters.Converter
+}
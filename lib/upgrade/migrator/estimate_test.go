@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("Test migration duration estimate", func() {
+	ipPools := func(n int) fakeClientV1 {
+		clientv1 := fakeClientV1{}
+		for i := 0; i < n; i++ {
+			c := net.MustParseCIDR(fmt.Sprintf("10.%d.0.0/16", i))
+			clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+				Key:   model.IPPoolKey{CIDR: c},
+				Value: &model.IPPool{CIDR: c, IPAM: true},
+			})
+		}
+		return clientv1
+	}
+
+	It("should count the resources and extrapolate the conversion rate", func() {
+		estimate, err := New(nil, ipPools(25), nil).EstimateMigrationDuration(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimate.TotalResources).To(Equal(25))
+		Expect(estimate.RatePerSecond).To(BeNumerically(">", 0))
+		Expect(estimate.EstimatedDuration).To(BeNumerically(">", 0))
+	})
+
+	It("should return an empty estimate if there is nothing to migrate", func() {
+		estimate, err := New(nil, fakeClientV1{}, nil).EstimateMigrationDuration(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(estimate.TotalResources).To(BeZero())
+		Expect(estimate.RatePerSecond).To(BeZero())
+	})
+
+	It("should stop when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := New(nil, ipPools(1), nil).EstimateMigrationDuration(ctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
@@ -185,6 +185,7 @@ func (fc fakeClientV1) Get(k model.Key) (*model.KVPair, error) {
 func (fc fakeClientV1) List(l model.ListInterface) ([]*model.KVPair, error) {
 	r := []*model.KVPair{}
 	_, isPL := l.(model.ProfileListOptions)
+	_, isNL := l.(model.NodeListOptions)
 	for _, kvp := range fc.kvps {
 		p, _ := model.KeyToDefaultPath(kvp.Key)
 		if l.KeyFromDefaultPath(p) != nil {
@@ -195,6 +196,9 @@ func (fc fakeClientV1) List(l model.ListInterface) ([]*model.KVPair, error) {
 			// combining the rules/tags/labels.
 		} else if _, ok := kvp.Key.(model.ProfileKey); ok && isPL {
 			r = append(r, kvp)
+			// Similarly, a NodeKey is a composite of several v1 keys.
+		} else if _, ok := kvp.Key.(model.NodeKey); ok && isNL {
+			r = append(r, kvp)
 		}
 	}
 	return r, nil
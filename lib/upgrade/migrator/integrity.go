@@ -0,0 +1,120 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+)
+
+// WithIntegrityCheck controls whether the v3 resources are verified after they are stored.
+// When enabled, each stored resource is read back from the v3 datastore and compared with
+// the converted resource; any differences are reported as IntegrityErrors and cause the
+// migration to fail.
+func WithIntegrityCheck(enabled bool) Option {
+	return func(m *migrationHelper) {
+		m.integrityCheck = enabled
+	}
+}
+
+// IntegrityError contains details about a v3 resource that did not match the converted
+// resource when it was read back from the v3 datastore. Got is nil if the resource does
+// not exist.
+type IntegrityError struct {
+	Key      model.Key
+	Expected converters.Resource
+	Got      converters.Resource
+}
+
+func (e IntegrityError) Error() string {
+	if e.Got == nil {
+		return fmt.Sprintf("resource %s was not found in the v3 datastore", e.Key)
+	}
+	return fmt.Sprintf("resource %s in the v3 datastore does not match the converted resource", e.Key)
+}
+
+// verifyV3Resources reads back each of the stored resources and records an IntegrityError
+// for each one that does not match the converted resource.
+func (m *migrationHelper) verifyV3Resources(ctx context.Context, data *MigrationData) error {
+	m.statusBullet("Verifying the resources stored in the v3 datastore")
+	bc := m.clientv3.(backendClientAccessor).Backend()
+	for _, r := range data.Resources {
+		expected, err := asStored(&model.KVPair{Key: resourceToKey(r), Value: r})
+		if err != nil {
+			return err
+		}
+		got, err := bc.Get(ctx, expected.Key, "")
+		if err != nil {
+			if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
+				return err
+			}
+			data.IntegrityErrors = append(data.IntegrityErrors, IntegrityError{Key: expected.Key, Expected: r})
+			continue
+		}
+		gotResource, ok := got.Value.(converters.Resource)
+		if !ok {
+			return fmt.Errorf("unexpected value type %T for resource %s", got.Value, expected.Key)
+		}
+		if !expected.DeepEqual(&model.KVPair{Key: got.Key, Value: withStorageMetadata(gotResource, expected.Value.(converters.Resource))}) {
+			log.WithField("Key", expected.Key).Warning("Stored resource does not match the converted resource")
+			data.IntegrityErrors = append(data.IntegrityErrors, IntegrityError{
+				Key:      expected.Key,
+				Expected: r,
+				Got:      gotResource,
+			})
+		}
+	}
+
+	if len(data.IntegrityErrors) > 0 {
+		for _, ie := range data.IntegrityErrors {
+			m.statusBullet("%v", ie)
+		}
+		return fmt.Errorf("%d resource(s) failed the integrity check", len(data.IntegrityErrors))
+	}
+	m.statusBullet("success: all resources verified")
+	return nil
+}
+
+// asStored returns a copy of the supplied KVPair as it would be read back from the
+// datastore, by serializing and parsing the value.  This discards the differences that
+// do not survive serialization, such as between nil and empty collections.
+func asStored(kvp *model.KVPair) (*model.KVPair, error) {
+	b, err := model.SerializeValue(kvp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize resource %s: %v", kvp.Key, err)
+	}
+	v, err := model.ParseValue(kvp.Key, b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse serialized resource %s: %v", kvp.Key, err)
+	}
+	return &model.KVPair{Key: kvp.Key, Value: v}, nil
+}
+
+// withStorageMetadata returns a copy of the stored resource with the metadata that is
+// managed by the datastore (the resource version and the creation timestamp, which is
+// stored at a lower precision) taken from the converted resource, so that the two may be
+// compared.
+func withStorageMetadata(stored, converted converters.Resource) converters.Resource {
+	r := stored.DeepCopyObject().(converters.Resource)
+	r.GetObjectMeta().SetResourceVersion(converted.GetObjectMeta().GetResourceVersion())
+	r.GetObjectMeta().SetCreationTimestamp(converted.GetObjectMeta().GetCreationTimestamp())
+	return r
+}
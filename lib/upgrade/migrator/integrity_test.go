@@ -0,0 +1,153 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// lossyBackend is a fakeBackend that returns modified copies of, or fails to find, some
+// of the IPPools that it stores, as the datastore would after a lossy write.
+type lossyBackend struct {
+	*fakeBackend
+	corrupt map[string]bool
+	missing map[string]bool
+}
+
+func (b *lossyBackend) Get(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+	if b.missing[key.String()] {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+	}
+	kvp, err := b.fakeBackend.Get(ctx, key, revision)
+	if err != nil || !b.corrupt[key.String()] {
+		return kvp, err
+	}
+	pool := kvp.Value.(*apiv3.IPPool).DeepCopy()
+	pool.Spec.Disabled = !pool.Spec.Disabled
+	return &model.KVPair{Key: kvp.Key, Value: pool, Revision: "2"}, nil
+}
+
+// serializingBackend is a fakeBackend that stores the serialized values, as the datastore
+// would, and parses them when they are read back.
+type serializingBackend struct {
+	*fakeBackend
+}
+
+func (b *serializingBackend) Create(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	v, err := model.SerializeValue(kvp)
+	Expect(err).NotTo(HaveOccurred())
+	return b.fakeBackend.Create(ctx, &model.KVPair{Key: kvp.Key, Value: v})
+}
+
+func (b *serializingBackend) Get(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+	kvp, err := b.fakeBackend.Get(ctx, key, revision)
+	if err != nil {
+		return nil, err
+	}
+	v, err := model.ParseValue(key, kvp.Value.([]byte))
+	Expect(err).NotTo(HaveOccurred())
+	return &model.KVPair{Key: key, Value: v, Revision: "1"}, nil
+}
+
+var _ = Describe("Test the integrity check of the migrated resources", func() {
+	var be *lossyBackend
+	var clientv1 fakeClientV1
+	BeforeEach(func() {
+		be = &lossyBackend{
+			fakeBackend: &fakeBackend{kvps: map[string]*model.KVPair{}},
+			corrupt:     map[string]bool{},
+			missing:     map[string]bool{},
+		}
+		clientv1 = fakeClientV1{}
+		for _, cidr := range []string{"10.0.0.0/16", "10.1.0.0/16", "10.2.0.0/16"} {
+			c := net.MustParseCIDR(cidr)
+			clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+				Key:   model.IPPoolKey{CIDR: c},
+				Value: &model.IPPool{CIDR: c, IPAM: true},
+			})
+		}
+	})
+
+	It("should succeed if the stored resources match", func() {
+		mh := New(fakeClientV3{backend: be}, clientv1, nil, WithIntegrityCheck(true))
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.IntegrityErrors).To(BeEmpty())
+	})
+
+	It("should report the stored resources that do not match", func() {
+		key := model.ResourceKey{Kind: apiv3.KindIPPool, Name: "10-1-0-0-16"}
+		be.corrupt[key.String()] = true
+
+		mh := New(fakeClientV3{backend: be}, clientv1, nil, WithIntegrityCheck(true))
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorMigratingData))
+		Expect(report.IntegrityErrors).To(HaveLen(1))
+
+		ie := report.IntegrityErrors[0]
+		Expect(ie.Key).To(Equal(key))
+		Expect(ie.Expected.(*apiv3.IPPool).Spec.Disabled).To(BeFalse())
+		Expect(ie.Got.(*apiv3.IPPool).Spec.Disabled).To(BeTrue())
+	})
+
+	It("should report stored resources that are missing", func() {
+		key := model.ResourceKey{Kind: apiv3.KindIPPool, Name: "10-2-0-0-16"}
+		be.missing[key.String()] = true
+
+		mh := New(fakeClientV3{backend: be}, clientv1, nil, WithIntegrityCheck(true))
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).To(HaveOccurred())
+		Expect(report.IntegrityErrors).To(HaveLen(1))
+		Expect(report.IntegrityErrors[0].Key).To(Equal(key))
+		Expect(report.IntegrityErrors[0].Got).To(BeNil())
+	})
+
+	It("should not read back the resources by default", func() {
+		key := model.ResourceKey{Kind: apiv3.KindIPPool, Name: "10-1-0-0-16"}
+		be.corrupt[key.String()] = true
+
+		mh := New(fakeClientV3{backend: be}, clientv1, nil)
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.IntegrityErrors).To(BeEmpty())
+	})
+
+	It("should succeed for resources that are serialized by the datastore", func() {
+		// The empty rule lists of the converted Profile are read back as nil.
+		clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+			Key: model.ProfileKey{Name: "profile1"},
+			Value: &model.Profile{
+				Rules: model.ProfileRules{InboundRules: []model.Rule{}, OutboundRules: []model.Rule{}},
+			},
+		})
+		sb := &serializingBackend{fakeBackend: &fakeBackend{kvps: map[string]*model.KVPair{}}}
+
+		mh := New(fakeClientV3{backend: sb}, clientv1, nil, WithIntegrityCheck(true))
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeProfile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Resources).To(HaveLen(1))
+		Expect(report.IntegrityErrors).To(BeEmpty())
+	})
+})
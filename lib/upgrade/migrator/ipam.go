@@ -0,0 +1,236 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/set"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+)
+
+// ResourceTypeIPAM is the resource type of the MigrationReport returned by MigrateIPAM, and
+// of the IPAM report returned by DryValidate.  It is not one of the ResourceTypes, since the
+// IPAM data is not migrated by MigrateResourceType.
+const ResourceTypeIPAM = "IPAM"
+
+// MigrateIPAM converts the v1 IPAM data (the IPAM handles, allocation blocks and block
+// affinities) and stores it in the v3 datastore, independently of the other resources.  Like
+// MigrateResourceType, this does not pause Calico networking or abort the upgrade on failure.
+//
+// The IPAM data may be migrated before the nodes, so the affinity of each block is converted
+// without checking the node.  Before the blocks are stored, the affinities to nodes that exist
+// in neither the v1 nor the v3 datastore, and that have no v1 per-host Felix config, are
+// removed, and these are listed in the report.  If an error is returned it will be of type
+// MigrationError.
+func (m *migrationHelper) MigrateIPAM(ctx context.Context) (*MigrationReport, error) {
+	report := &MigrationReport{ResourceType: ResourceTypeIPAM}
+	m.status("Migrating IPAM data")
+	if m.clientv1.IsKDD() {
+		m.statusBullet("no data to migrate - not supported")
+		return report, nil
+	}
+
+	if err := m.queryAndConvertIPAMData(&report.MigrationData); err != nil {
+		return report, MigrationError{
+			Type: ErrorGeneric,
+			Err:  fmt.Errorf("error converting IPAM data: %v", err),
+		}
+	}
+	if report.HasErrors() {
+		m.statusError("Error converting IPAM data, check output for details and resolve issues before retrying")
+		return report, MigrationError{
+			Type: ErrorConvertingData,
+			Err:  fmt.Errorf("error converting IPAM data: %d allocation block(s) failed to convert", len(report.ConversionErrors)),
+		}
+	}
+
+	if err := m.storeIPAMData(ctx, &report.MigrationData, true); err != nil {
+		return report, err
+	}
+	m.status("Migration of IPAM data from v1 to v3 successful")
+	return report, nil
+}
+
+// queryAndConvertIPAMData queries the v1 IPAM data and converts it to the v3 format, adding
+// the converted entries to the IPAM of the supplied MigrationData.  An allocation block that
+// cannot be converted is added to the ConversionErrors.  The node affinities are not
+// validated: see storeIPAMData.
+func (m *migrationHelper) queryAndConvertIPAMData(data *MigrationData) error {
+	// Query all of the IPAM data:
+	// -  IPAMHandle
+	// -  Blocks
+	// -  BlockAffinity
+	// IPAMHandle does not require any conversion.
+	m.statusBullet("handling IPAM handles")
+	handles, err := m.clientv1.List(model.IPAMHandleListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list IPAM handles: %v", err)
+	}
+	data.IPAM = append(data.IPAM, handles...)
+
+	// The blocks are validated against the handles in the v1 datastore.
+	blockConverter := converters.IPAMBlock{Handles: set.NewStringSet()}
+	for _, kvp := range handles {
+		blockConverter.Handles.Add(kvp.Key.(model.IPAMHandleKey).HandleID)
+	}
+
+	// AllocationBlocks need to have their host affinity updated to use the
+	// normalized node name.
+	m.statusBullet("handling IPAM allocation blocks")
+	kvps, err := m.clientv1.List(model.BlockListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list IPAM allocation blocks: %v", err)
+	}
+	for _, kvp := range kvps {
+		kvpv3, err := blockConverter.BackendV1ToBackendV3(kvp)
+		if err != nil {
+			m.statusError("Unable to convert IPAM allocation block: %v", kvp.Key)
+			m.statusBullet("cause: %v", err)
+			data.ConversionErrors = append(data.ConversionErrors, ConversionError{
+				Cause:   err,
+				KeyV1:   kvp.Key,
+				ValueV1: kvp.Value,
+			})
+			continue
+		}
+		data.IPAM = append(data.IPAM, kvpv3)
+	}
+
+	// BlockAffinities need to have their host updated to use the normalized node
+	// name.
+	m.statusBullet("handling IPAM affinity blocks")
+	kvps, err = m.clientv1.List(model.BlockAffinityListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list IPAM affinity blocks: %v", err)
+	}
+	for _, kvp := range kvps {
+		k := kvp.Key.(model.BlockAffinityKey)
+		k.Host = converters.ConvertNodeName(k.Host)
+		data.IPAM = append(data.IPAM, &model.KVPair{Key: k, Value: kvp.Value})
+	}
+	return nil
+}
+
+// storeIPAMData stores the converted IPAM data of the supplied MigrationData in the v3
+// datastore.  If removeUnknownAffinities is set, the affinities are validated against the
+// nodes and Felix hosts in the v1 datastore and the nodes in the v3 datastore, which may have
+// been migrated in the meantime: the affinity of a block to any other node is removed before
+// the block is stored, and a block affinity for any other node is not stored, so that the
+// block may be claimed by another node.  The removed affinities are added to the supplied
+// MigrationData.  Its IPAM is updated to contain the stored entries.  If an error is returned
+// it will be of type MigrationError.
+func (m *migrationHelper) storeIPAMData(ctx context.Context, data *MigrationData, removeUnknownAffinities bool) error {
+	var nodes set.StringSet
+	if removeUnknownAffinities {
+		m.statusBullet("validating IPAM block affinities")
+		var err error
+		if nodes, err = m.listNodeNames(ctx); err != nil {
+			m.statusError("Unable to list the nodes")
+			m.statusBullet("cause: %v", err)
+			return MigrationError{
+				Type: ErrorMigratingData,
+				Err:  fmt.Errorf("error migrating IPAM data: %v", err),
+			}
+		}
+	}
+
+	// Create/Apply the converted handles, blocks and affinities into the v3 datastore.
+	m.statusBullet("storing IPAM data in v3 format")
+	stored := make([]*model.KVPair, 0, len(data.IPAM))
+	for _, kvp := range data.IPAM {
+		if removeUnknownAffinities {
+			switch k := kvp.Key.(type) {
+			case model.BlockKey:
+				block := kvp.Value.(*model.AllocationBlock)
+				if node := block.Host(); node != "" && !nodes.Contains(node) {
+					log.WithFields(log.Fields{
+						"CIDR": block.CIDR,
+						"Node": node,
+					}).Info("IPAM block is affine to a node that does not exist, removing affinity")
+					stripped := *block
+					stripped.Affinity = nil
+					stripped.HostAffinity = nil
+					kvp = &model.KVPair{Key: k, Value: &stripped}
+					data.RemovedIPAMAffinities = append(data.RemovedIPAMAffinities, k)
+				}
+			case model.BlockAffinityKey:
+				if !nodes.Contains(k.Host) {
+					log.WithField("Key", k).Info("Skipping IPAM block affinity for a node that does not exist")
+					data.RemovedIPAMAffinities = append(data.RemovedIPAMAffinities, k)
+					continue
+				}
+			}
+		}
+		if err := m.applyToBackend(ctx, kvp); err != nil {
+			m.statusError("Error writing IPAM data to v3 datastore")
+			m.statusBullet("cause: %v", err)
+			return MigrationError{
+				Type: ErrorMigratingData,
+				Err:  fmt.Errorf("error storing converted IPAM data: %v", err),
+			}
+		}
+		stored = append(stored, kvp)
+	}
+	data.IPAM = stored
+
+	// We migrated the data successfully.
+	m.statusBullet("IPAM data migrated successfully")
+	return nil
+}
+
+// listNodeNames returns the v3 names of the nodes in either the v1 or the v3 datastore.  The
+// v1 hosts that only have per-host Felix config are included, since Felix may be running on a
+// host that has no v1 node.
+func (m *migrationHelper) listNodeNames(ctx context.Context) (set.StringSet, error) {
+	v1Nodes, err := m.listV1Resources(model.NodeListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list v1 nodes: %v", err)
+	}
+	hostConfig, err := m.clientv1.List(model.HostConfigListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list v1 host config: %v", err)
+	}
+	nodes, err := m.listV3NodeNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, kvp := range v1Nodes {
+		nodes.Add(converters.ConvertNodeName(kvp.Key.(model.NodeKey).Hostname))
+	}
+	for _, kvp := range hostConfig {
+		nodes.Add(converters.ConvertNodeName(kvp.Key.(model.HostConfigKey).Hostname))
+	}
+	return nodes, nil
+}
+
+// listV3NodeNames returns the names of the nodes in the v3 datastore.
+func (m *migrationHelper) listV3NodeNames(ctx context.Context) (set.StringSet, error) {
+	bc := m.clientv3.(backendClientAccessor).Backend()
+	list, err := bc.List(ctx, model.ResourceListOptions{Kind: libapiv3.KindNode}, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list v3 nodes: %v", err)
+	}
+	nodes := set.NewStringSet()
+	for _, kvp := range list.KVPairs {
+		nodes.Add(kvp.Key.(model.ResourceKey).Name)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("Test migrating the IPAM data", func() {
+	strPtr := func(s string) *string { return &s }
+	blockKey := func(cidr string) model.BlockKey {
+		return model.BlockKey{CIDR: net.MustParseNetwork(cidr)}
+	}
+	block := func(cidr, node, handle string) *model.KVPair {
+		return &model.KVPair{
+			Key: blockKey(cidr),
+			Value: &model.AllocationBlock{
+				CIDR:       net.MustParseNetwork(cidr),
+				Affinity:   strPtr("host:" + node),
+				Attributes: []model.AllocationAttribute{{AttrPrimary: strPtr(handle)}},
+			},
+		}
+	}
+	affinityKey := func(cidr, node string) model.BlockAffinityKey {
+		return model.BlockAffinityKey{CIDR: net.MustParseNetwork(cidr), Host: node}
+	}
+	affinity := func(cidr, node string) *model.KVPair {
+		return &model.KVPair{
+			Key:   affinityKey(cidr, node),
+			Value: &model.BlockAffinity{State: model.StateConfirmed},
+		}
+	}
+	storedAffinity := func(be *fakeBackend, cidr string) *string {
+		kvp, ok := be.kvps[blockKey(cidr).String()]
+		Expect(ok).To(BeTrue(), cidr)
+		return kvp.Value.(*model.AllocationBlock).Affinity
+	}
+
+	var be *fakeBackend
+	var clientv1 fakeClientV1
+	BeforeEach(func() {
+		// Node1 has not been migrated, node2 only exists in the v3 datastore and node3
+		// has been deleted.
+		be = &fakeBackend{kvps: map[string]*model.KVPair{}}
+		node2 := &model.KVPair{
+			Key:   model.ResourceKey{Kind: libapiv3.KindNode, Name: "node2"},
+			Value: libapiv3.NewNode(),
+		}
+		be.kvps[node2.Key.String()] = node2
+		clientv1 = fakeClientV1{kvps: []*model.KVPair{
+			{Key: model.NodeKey{Hostname: "Node1"}, Value: &model.Node{}},
+			{Key: model.IPAMHandleKey{HandleID: "handle1"}, Value: &model.IPAMHandle{HandleID: "handle1"}},
+			block("10.0.0.0/26", "Node1", "handle1"),
+			block("10.0.0.64/26", "node2", "handle1"),
+			block("10.0.0.128/26", "node3", "handle1"),
+			affinity("10.0.0.0/26", "Node1"),
+			affinity("10.0.0.64/26", "node2"),
+			affinity("10.0.0.128/26", "node3"),
+		}}
+	})
+
+	It("should remove the affinities to nodes that do not exist", func() {
+		report, err := New(fakeClientV3{backend: be}, clientv1, nil).MigrateIPAM(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.ResourceType).To(Equal(ResourceTypeIPAM))
+		Expect(report.HasErrors()).To(BeFalse())
+		Expect(report.RemovedIPAMAffinities).To(ConsistOf(
+			model.Key(blockKey("10.0.0.128/26")),
+			model.Key(affinityKey("10.0.0.128/26", "node3")),
+		))
+
+		Expect(be.kvps).To(HaveKey(model.IPAMHandleKey{HandleID: "handle1"}.String()))
+		Expect(storedAffinity(be, "10.0.0.0/26")).To(Equal(strPtr("host:node1")))
+		Expect(storedAffinity(be, "10.0.0.64/26")).To(Equal(strPtr("host:node2")))
+		Expect(storedAffinity(be, "10.0.0.128/26")).To(BeNil())
+		Expect(be.kvps).To(HaveKey(affinityKey("10.0.0.0/26", "node1").String()))
+		Expect(be.kvps).To(HaveKey(affinityKey("10.0.0.64/26", "node2").String()))
+		Expect(be.kvps).NotTo(HaveKey(affinityKey("10.0.0.128/26", "node3").String()))
+		Expect(be.kvps).NotTo(HaveKey(affinityKey("10.0.0.0/26", "Node1").String()))
+	})
+
+	It("should keep the affinities to hosts that only have Felix config", func() {
+		clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+			Key:   model.HostConfigKey{Hostname: "node3", Name: "InterfacePrefix"},
+			Value: "cali",
+		})
+		report, err := New(fakeClientV3{backend: be}, clientv1, nil).MigrateIPAM(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.RemovedIPAMAffinities).To(BeEmpty())
+		Expect(storedAffinity(be, "10.0.0.128/26")).To(Equal(strPtr("host:node3")))
+		Expect(be.kvps).To(HaveKey(affinityKey("10.0.0.128/26", "node3").String()))
+	})
+
+	It("should remove the affinity before the block is first stored", func() {
+		cb := &countingBackend{fakeBackend: be, writes: map[string]int{}}
+		_, err := New(fakeClientV3{backend: cb}, clientv1, nil).MigrateIPAM(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storedAffinity(be, "10.0.0.128/26")).To(BeNil())
+		Expect(cb.writes[blockKey("10.0.0.128/26").String()]).To(Equal(1))
+	})
+
+	It("should convert the IPAM data with the other resources", func() {
+		clientv1.kvps = append(clientv1.kvps, block("10.0.0.192/26", "Node1", "missing"))
+		mh := &migrationHelper{clientv1: clientv1}
+		data, err := mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.IPAM).To(HaveLen(7))
+		Expect(data.HasErrors()).To(BeTrue())
+		Expect(data.ConversionErrors[0].KeyV1).To(Equal(blockKey("10.0.0.192/26")))
+	})
+
+	It("should keep every affinity when storing the IPAM data for a full migration", func() {
+		mh := &migrationHelper{clientv1: clientv1, clientv3: fakeClientV3{backend: be}}
+		data, err := mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mh.storeIPAMData(context.Background(), data, false)).To(Succeed())
+		Expect(data.RemovedIPAMAffinities).To(BeEmpty())
+		Expect(storedAffinity(be, "10.0.0.128/26")).To(Equal(strPtr("host:node3")))
+		Expect(be.kvps).To(HaveKey(affinityKey("10.0.0.128/26", "node3").String()))
+	})
+
+	It("should report the blocks that fail to convert without storing anything", func() {
+		clientv1.kvps = append(clientv1.kvps, block("10.0.0.192/26", "Node1", "missing"))
+		report, err := New(fakeClientV3{backend: be}, clientv1, nil).MigrateIPAM(context.Background())
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorConvertingData))
+		Expect(report.ConversionErrors).To(HaveLen(1))
+		Expect(report.ConversionErrors[0].KeyV1).To(Equal(blockKey("10.0.0.192/26")))
+		Expect(be.kvps).To(HaveLen(1))
+	})
+
+	It("should not migrate anything for the Kubernetes datastore", func() {
+		clientv1.kdd = true
+		report, err := New(fakeClientV3{backend: be}, clientv1, nil).MigrateIPAM(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.ResourceType).To(Equal(ResourceTypeIPAM))
+		Expect(be.kvps).To(HaveLen(1))
+	})
+})
+
+// countingBackend counts the writes of each key to a fakeBackend.
+type countingBackend struct {
+	*fakeBackend
+	writes map[string]int
+}
+
+func (b *countingBackend) Create(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	b.writes[kvp.Key.String()]++
+	return b.fakeBackend.Create(ctx, kvp)
+}
+
+func (b *countingBackend) Update(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	b.writes[kvp.Key.String()]++
+	return b.fakeBackend.Update(ctx, kvp)
+}
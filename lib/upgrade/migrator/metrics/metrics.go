@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics contains the Prometheus metrics recorded by the v1 to v3 data migrator.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ResultSuccess is the result label of a resource that was converted and validated.
+	ResultSuccess = "success"
+
+	// ResultError is the result label of a resource that could not be converted, or
+	// whose converted resource failed validation.
+	ResultError = "error"
+)
+
+var (
+	conversionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "calico_migration_conversions_total",
+		Help: "Number of v1 resources converted to v3 by the migrator.",
+	}, []string{"resource_type", "result"})
+
+	conversionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "calico_migration_conversion_duration_seconds",
+		Help:    "Time taken to convert a v1 resource to v3.",
+		Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{"resource_type"})
+)
+
+// RegisterMetrics registers the migration metrics with the supplied registerer. The
+// metrics are recorded whether or not they are registered.
+func RegisterMetrics(r prometheus.Registerer) {
+	r.MustRegister(conversionsTotal, conversionDuration)
+}
+
+// RecordConversion records the result of converting a single resource of the supplied
+// type, along with the time taken by the conversion.
+func RecordConversion(resourceType string, duration time.Duration, result string) {
+	conversionsTotal.WithLabelValues(resourceType, result).Inc()
+	conversionDuration.WithLabelValues(resourceType).Observe(duration.Seconds())
+}
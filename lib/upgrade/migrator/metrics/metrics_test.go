@@ -0,0 +1,78 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package metrics
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Migration metrics", func() {
+	It("should register the metrics", func() {
+		r := prometheus.NewRegistry()
+		RegisterMetrics(r)
+
+		// Vector metrics are only gathered once they have a child.
+		RecordConversion("Profile", time.Millisecond, ResultSuccess)
+		families, err := r.Gather()
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, f := range families {
+			names = append(names, f.GetName())
+		}
+		Expect(names).To(ConsistOf(
+			"calico_migration_conversions_total",
+			"calico_migration_conversion_duration_seconds",
+		))
+	})
+
+	It("should record the result and duration of each conversion", func() {
+		samples := durationSamples("IPPool")
+		success := testutil.ToFloat64(conversionsTotal.WithLabelValues("IPPool", ResultSuccess))
+		failure := testutil.ToFloat64(conversionsTotal.WithLabelValues("IPPool", ResultError))
+
+		RecordConversion("IPPool", 2*time.Millisecond, ResultSuccess)
+		RecordConversion("IPPool", 2*time.Millisecond, ResultSuccess)
+		RecordConversion("IPPool", 3*time.Millisecond, ResultError)
+
+		Expect(testutil.ToFloat64(conversionsTotal.WithLabelValues("IPPool", ResultSuccess))).To(Equal(success + 2))
+		Expect(testutil.ToFloat64(conversionsTotal.WithLabelValues("IPPool", ResultError))).To(Equal(failure + 1))
+		Expect(durationSamples("IPPool")).To(Equal(samples + 3))
+	})
+})
+
+func durationSamples(resourceType string) uint64 {
+	r := prometheus.NewRegistry()
+	RegisterMetrics(r)
+	families, err := r.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, f := range families {
+		if f.GetName() != "calico_migration_conversion_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "resource_type" && l.GetValue() == resourceType {
+					return m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return 0
+}
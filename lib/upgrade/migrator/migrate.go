@@ -28,15 +28,20 @@ import (
 	"k8s.io/apimachinery/pkg/util/uuid"
 
 	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
-	"github.com/projectcalico/api/pkg/lib/numorstring"
+	"github.com/projectcalico/libcalico-go/lib/apiconfig"
 	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
 	bapi "github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/etcdv3"
 	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/clientv3"
 	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/names"
+	cnet "github.com/projectcalico/libcalico-go/lib/net"
 	"github.com/projectcalico/libcalico-go/lib/options"
+	"github.com/projectcalico/libcalico-go/lib/selector"
 	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
 	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/clients"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/migrator/metrics"
 	validatorv3 "github.com/projectcalico/libcalico-go/lib/validator/v3"
 )
 
@@ -46,21 +51,62 @@ const (
 	numAppliesPerUpdate     = 100
 	retryInterval           = 5 * time.Second
 
+	// The maximum time allowed for the etcd datastore connectivity check.
+	connectivityCheckTimeout = 30 * time.Second
+
 	// The minimum version to upgrade from. This should not include the leading 'v'.
 	minUpgradeVersion = "2.6.5"
 )
 
+// The resource types that are migrated. Any of these may be migrated individually using
+// MigrateResourceType.
+const (
+	// ResourceTypeFelixConfiguration covers both the FelixConfiguration and the
+	// ClusterInformation resources, which are converted from the same v1 config.
+	ResourceTypeFelixConfiguration  = "FelixConfiguration"
+	ResourceTypeBGPConfiguration    = "BGPConfiguration"
+	ResourceTypeNode                = "Node"
+	ResourceTypeBGPPeer             = "BGPPeer"
+	ResourceTypeHostEndpoint        = "HostEndpoint"
+	ResourceTypeIPPool              = "IPPool"
+	ResourceTypeGlobalNetworkPolicy = "GlobalNetworkPolicy"
+	ResourceTypeProfile             = "Profile"
+	ResourceTypeWorkloadEndpoint    = "WorkloadEndpoint"
+)
+
+// ResourceTypes contains the resource types in the order in which they are migrated.
+var ResourceTypes = []string{
+	ResourceTypeFelixConfiguration,
+	ResourceTypeBGPConfiguration,
+	ResourceTypeNode,
+	ResourceTypeBGPPeer,
+	ResourceTypeHostEndpoint,
+	ResourceTypeIPPool,
+	ResourceTypeGlobalNetworkPolicy,
+	ResourceTypeProfile,
+	ResourceTypeWorkloadEndpoint,
+}
+
 // Interface is the migration interface used for migrating data from version
 // v2.x to v3.x.
 type Interface interface {
 	ValidateConversion() (*MigrationData, error)
+	DryValidate(ctx context.Context) (*ValidationReport, error)
+	EstimateMigrationDuration(ctx context.Context) (*MigrationEstimate, error)
 	IsDestinationEmpty() (bool, error)
 	ShouldMigrate() (bool, error)
 	CanMigrate() error
 	Migrate() (*MigrationData, error)
+	MigrateWithBackup(ctx context.Context, backupDir string) error
+	MigrateResourceType(ctx context.Context, resourceType string) (*MigrationReport, error)
+	MigrateIPAM(ctx context.Context) (*MigrationReport, error)
+	ListV1Resources(ctx context.Context) (map[string][]*model.KVPair, error)
 	IsMigrationInProgress() (bool, error)
 	Abort() error
 	Complete() error
+	RollbackMigration(ctx context.Context) error
+	MigrationState() *MigrationState
+	WatchMigrationProgress(ctx context.Context) (<-chan MigrationProgressEvent, error)
 }
 
 // StatusWriterInterface is an optional interface supplied by the consumer of
@@ -71,13 +117,57 @@ type StatusWriterInterface interface {
 	Error(string)
 }
 
+// Option is an optional setting for the migration helper.
+type Option func(*migrationHelper)
+
+// WithLabelFilter limits the Profiles that are converted to those whose labels match
+// the supplied selector. Profiles that do not match are skipped. An invalid selector
+// causes the conversion to fail.
+func WithLabelFilter(selector string) Option {
+	return func(m *migrationHelper) {
+		m.profileSelector = selector
+	}
+}
+
+// WithCreateOnly controls how the converted resources are written to the v3 datastore.
+// When enabled, each resource is created with a write that is conditional on the resource
+// not already existing, rather than being created or updated.  This ensures the migration
+// never overwrites a v3 resource that was written after the v1 snapshot was taken; such a
+// resource causes the migration to fail instead.
+//
+// This also applies to the resources written by an earlier attempt, so re-running a failed
+// migration with this option fails on the first resource that was already stored.  Remove
+// the stored resources with RollbackMigration, using the same helper, before retrying.
+//
+// The v1 reads are not pinned to a revision: the v1 (etcdv2) datastore does not support
+// reads at a revision, so the consistency of the v1 snapshot continues to rely on Calico
+// networking being paused for the migration.
+func WithCreateOnly(enabled bool) Option {
+	return func(m *migrationHelper) {
+		m.createOnly = enabled
+	}
+}
+
+// WithDatastoreConnectivityCheck enables a pre-flight check of the etcdv3 datastore described
+// by the supplied config.  When set, CanMigrate fails if the datastore is not reachable or is
+// unhealthy, rather than the migration failing part way through.
+func WithDatastoreConnectivityCheck(config *apiconfig.EtcdConfig) Option {
+	return func(m *migrationHelper) {
+		m.etcdConfig = config
+	}
+}
+
 // New creates a new migration helper implementing Interface.
-func New(clientv3 clientv3.Interface, clientv1 clients.V1ClientInterface, statusWriter StatusWriterInterface) Interface {
-	return &migrationHelper{
+func New(clientv3 clientv3.Interface, clientv1 clients.V1ClientInterface, statusWriter StatusWriterInterface, opts ...Option) Interface {
+	m := &migrationHelper{
 		clientv3:     clientv3,
 		clientv1:     clientv1,
 		statusWriter: statusWriter,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // migrationHelper implements the migrate.Interface.
@@ -85,6 +175,30 @@ type migrationHelper struct {
 	clientv3     clientv3.Interface
 	clientv1     clients.V1ClientInterface
 	statusWriter StatusWriterInterface
+
+	// The selector used to filter the Profiles to convert. If empty, all Profiles
+	// are converted.
+	profileSelector string
+
+	// Whether the v3 resources are only written if they do not already exist.
+	createOnly bool
+
+	// The config of the etcdv3 datastore to check before migrating. If nil, the
+	// connectivity check is skipped.
+	etcdConfig *apiconfig.EtcdConfig
+
+	// Whether RollbackMigration only reports the resources it would delete.
+	dryRunRollback bool
+
+	// Whether the stored v3 resources are read back and compared with the converted
+	// resources.
+	integrityCheck bool
+
+	// The v3 resources created by the migration.
+	state MigrationState
+
+	// The channels that receive the progress of the conversion.
+	progress progressWatchers
 }
 
 // Error types encountered during validation and migration.
@@ -129,6 +243,19 @@ type MigrationData struct {
 	// Entries that were skipped because they will be handled by the Kubernetes
 	// Policy controller.
 	HandledByPolicyCtrl []model.Key
+
+	// Stored resources that did not match the converted resources when read back
+	// from the v3 datastore. Only populated when WithIntegrityCheck is enabled.
+	IntegrityErrors []IntegrityError
+
+	// The converted IPAM handles, allocation blocks and block affinities. Once the IPAM
+	// data is migrated, these are the entries as stored in the v3 datastore.
+	IPAM []*model.KVPair
+
+	// The IPAM blocks and block affinities whose node affinity was removed during the
+	// migration of the IPAM data, because the node exists in neither the v1 nor the v3
+	// datastore.
+	RemovedIPAMAffinities []model.Key
 }
 
 // HasErrors returns whether there are any errors contained in the MigrationData.
@@ -138,6 +265,15 @@ func (c *MigrationData) HasErrors() bool {
 		len(c.NameClashes) != 0
 }
 
+// MigrationReport contains details about the data migrated for a single resource type
+// using MigrateResourceType.
+type MigrationReport struct {
+	// The migrated resource type.
+	ResourceType string
+
+	MigrationData
+}
+
 // ConversionError contains details about a specific error converting a
 // v1 resource to a v3 resource.
 type ConversionError struct {
@@ -258,7 +394,7 @@ func (m *migrationHelper) Migrate() (*MigrationData, error) {
 	m.statusBullet("data converted successfully")
 
 	m.status("Storing v3 data")
-	if err = m.storeV3Resources(data); err != nil {
+	if err = m.storeV3Resources(context.Background(), data); err != nil {
 		m.statusError("Unable to store the v3 resources")
 		m.statusBullet("cause: %v", err)
 		return nil, m.abortAfterError(
@@ -266,16 +402,14 @@ func (m *migrationHelper) Migrate() (*MigrationData, error) {
 		)
 	}
 
-	// And we also need to migrate the IPAM data.
+	// And we also need to migrate the IPAM data.  The nodes are migrated with it, so the
+	// block affinities are stored unchanged.
 	m.status("Migrating IPAM data")
 	if m.clientv1.IsKDD() {
 		m.statusBullet("no data to migrate - not supported")
-	} else if err = m.migrateIPAMData(); err != nil {
-		m.statusError("Unable to migrate the v3 IPAM data")
-		m.statusBullet("cause: %v", err)
-		return nil, m.abortAfterError(
-			fmt.Errorf("error migrating IPAM data: %v", err), ErrorMigratingData,
-		)
+	} else if err = m.storeIPAMData(context.Background(), data, false); err != nil {
+		me := err.(MigrationError)
+		return nil, m.abortAfterError(me.Err, me.Type)
 	}
 
 	m.status("Data migration from v1 to v3 successful")
@@ -294,6 +428,125 @@ func (m *migrationHelper) abortAfterError(err error, errType ErrorType) error {
 	return MigrationError{Type: errType, Err: err, NeedsAbort: true}
 }
 
+// MigrateResourceType converts the v1 resources of a single type, which must be one of
+// ResourceTypes, and stores them in the v3 datastore. This may be used to re-run the
+// migration of a resource type after a partial failure. Unlike Migrate, this does not
+// pause Calico networking, migrate the IPAM data or abort the upgrade on failure; the
+// Ready flag in the ClusterInformation is left unchanged.
+// If an error is returned it will be of type MigrationError.
+func (m *migrationHelper) MigrateResourceType(ctx context.Context, resourceType string) (*MigrationReport, error) {
+	if !isResourceType(resourceType) {
+		return nil, MigrationError{
+			Type: ErrorGeneric,
+			Err: fmt.Errorf("unknown resource type '%s': must be one of %s",
+				resourceType, strings.Join(ResourceTypes, ", ")),
+		}
+	}
+
+	m.status("Querying v1 %s resources and converting to v3", resourceType)
+	report := &MigrationReport{ResourceType: resourceType}
+	if err := m.queryAndConvertResourceType(&report.MigrationData, resourceType); err != nil {
+		m.statusError("Unable to convert the v1 %s resources to v3", resourceType)
+		m.statusBullet("cause: %v", err)
+		return nil, MigrationError{
+			Type: ErrorGeneric,
+			Err:  fmt.Errorf("error converting data: %v", err),
+		}
+	}
+	if report.HasErrors() {
+		m.statusError("Error converting data, check output for details and resolve issues before retrying")
+		return report, MigrationError{
+			Type: ErrorConvertingData,
+			Err:  fmt.Errorf("error converting %s data", resourceType),
+		}
+	}
+	m.statusBullet("data converted successfully")
+
+	// The conversion pauses Calico networking on etcd, which is only wanted as part of
+	// the full migration.
+	if resourceType == ResourceTypeFelixConfiguration {
+		if err := m.keepDatastoreReady(ctx, &report.MigrationData); err != nil {
+			m.statusError("Unable to query the current Ready flag")
+			m.statusBullet("cause: %v", err)
+			return report, MigrationError{Type: ErrorGeneric, Err: err}
+		}
+	}
+
+	m.status("Storing v3 data")
+	if err := m.storeV3Resources(ctx, &report.MigrationData); err != nil {
+		m.statusError("Unable to store the v3 resources")
+		m.statusBullet("cause: %v", err)
+		return report, MigrationError{
+			Type: ErrorMigratingData,
+			Err:  fmt.Errorf("error storing converted data: %v", err),
+		}
+	}
+
+	m.status("Migration of %s resources from v1 to v3 successful", resourceType)
+	return report, nil
+}
+
+// v1ResourceList is a set of v1 data that is converted to a resource type.
+type v1ResourceList struct {
+	resourceType string
+	listOptions  model.ListInterface
+
+	// Whether the data is migrated when the datastore is the Kubernetes API.
+	migratedForKDD bool
+}
+
+// v1ResourceLists contains the v1 data that is converted to each resource type, in the
+// order in which the resource types are migrated, followed by the IPAM data.
+var v1ResourceLists = []v1ResourceList{
+	{ResourceTypeFelixConfiguration, model.GlobalConfigListOptions{}, true},
+	{ResourceTypeFelixConfiguration, model.HostConfigListOptions{}, false},
+	{ResourceTypeBGPConfiguration, model.GlobalBGPConfigListOptions{}, true},
+	{ResourceTypeNode, model.NodeListOptions{}, false},
+	{ResourceTypeBGPPeer, model.GlobalBGPPeerListOptions{}, false},
+	{ResourceTypeBGPPeer, model.NodeBGPPeerListOptions{}, true},
+	{ResourceTypeHostEndpoint, model.HostEndpointListOptions{}, false},
+	{ResourceTypeIPPool, model.IPPoolListOptions{}, false},
+	{ResourceTypeGlobalNetworkPolicy, model.PolicyListOptions{}, false},
+	{ResourceTypeProfile, model.ProfileListOptions{}, false},
+	{ResourceTypeWorkloadEndpoint, model.WorkloadEndpointListOptions{}, false},
+	{ResourceTypeIPAM, model.IPAMHandleListOptions{}, false},
+	{ResourceTypeIPAM, model.BlockListOptions{}, false},
+	{ResourceTypeIPAM, model.BlockAffinityListOptions{}, false},
+}
+
+// ListV1Resources returns the v1 data that would be migrated, grouped by resource type, with
+// the IPAM data grouped under ResourceTypeIPAM.  This may be used to take an inventory of
+// the v1 datastore before migrating.  The v1 data is returned before conversion, so it
+// includes entries that are filtered during the migration (e.g. policies created by the
+// Kubernetes policy controller).
+func (m *migrationHelper) ListV1Resources(ctx context.Context) (map[string][]*model.KVPair, error) {
+	resources := map[string][]*model.KVPair{}
+	for _, rl := range v1ResourceLists {
+		if m.clientv1.IsKDD() && !rl.migratedForKDD {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		kvps, err := m.listV1Resources(rl.listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list v1 %s resources: %v", rl.resourceType, err)
+		}
+		resources[rl.resourceType] = append(resources[rl.resourceType], kvps...)
+	}
+	return resources, nil
+}
+
+// isResourceType returns true if the supplied resource type is one of ResourceTypes.
+func isResourceType(resourceType string) bool {
+	for _, rt := range ResourceTypes {
+		if rt == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
 // IsMigrationInProgress infers from ShouldMigrate and the Ready flag if the datastore
 // is being migrated and returns true if it is. If migration is needed and the Ready
 // flag is false then it is assumed migration is in progress. This could provide a
@@ -408,129 +661,161 @@ func (m *migrationHelper) v3DatastoreIsClean() (bool, error) {
 // shot.
 func (m *migrationHelper) queryAndConvertResources() (*MigrationData, error) {
 	data := &MigrationData{}
-
-	// Query and convert global felix configuration and cluster info.
-	if err := m.queryAndConvertFelixConfigV1ToV3(data); err != nil {
-		return nil, err
+	for _, rt := range ResourceTypes {
+		if err := m.queryAndConvertResourceType(data, rt); err != nil {
+			return nil, err
+		}
 	}
 
-	m.statusBullet("handling BGPConfiguration (global) resource")
-	// Query the global BGP configuration:  default AS number; node-to-node mesh.
-	if err := m.queryAndConvertGlobalBGPConfigV1ToV3(data); err != nil {
+	// The IPAM data is converted with the resources so that it is validated before
+	// anything is stored.
+	if m.clientv1.IsKDD() {
+		m.statusBullet("skipping IPAM data - not supported")
+	} else if err := m.queryAndConvertIPAMData(data); err != nil {
 		return nil, err
 	}
+	return data, nil
+}
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping Node resources - these do not need migrating")
-	} else {
+// queryAndConvertResourceType queries the v1 resources of the supplied type and converts
+// them to v3, adding the converted resources and any conversion errors to data.
+func (m *migrationHelper) queryAndConvertResourceType(data *MigrationData, resourceType string) error {
+	switch resourceType {
+	case ResourceTypeFelixConfiguration:
+		// Query and convert global felix configuration and cluster info.
+		return m.queryAndConvertFelixConfigV1ToV3(data)
+
+	case ResourceTypeBGPConfiguration:
+		m.statusBullet("handling BGPConfiguration (global) resource")
+		// Query the global BGP configuration:  default AS number; node-to-node mesh.
+		return m.queryAndConvertGlobalBGPConfigV1ToV3(data)
+
+	case ResourceTypeNode:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping Node resources - these do not need migrating")
+			return nil
+		}
 		m.statusBullet("handling Node resources")
 		// Query and convert the Nodes
-		if err := m.queryAndConvertV1ToV3Nodes(data); err != nil {
-			return nil, err
-		}
-	}
+		return m.queryAndConvertV1ToV3Nodes(data)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping BGPPeer (global) resources - these do not need migrating")
-	} else {
-		m.statusBullet("handling BGPPeer (global) resources")
-		// Query and convert the BGPPeers
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.GlobalBGPPeerListOptions{}, converters.BGPPeer{}, noFilter,
-		); err != nil {
-			return nil, err
+	case ResourceTypeBGPPeer:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping BGPPeer (global) resources - these do not need migrating")
+		} else {
+			m.statusBullet("handling BGPPeer (global) resources")
+			// Query and convert the BGPPeers
+			if err := m.queryAndConvertV1ToV3Resources(
+				data, ResourceTypeBGPPeer, model.GlobalBGPPeerListOptions{}, converters.BGPPeer{}, noFilter,
+			); err != nil {
+				return err
+			}
 		}
-	}
 
-	m.statusBullet("handling BGPPeer (node) resources")
-	if err := m.queryAndConvertV1ToV3Resources(
-		data, model.NodeBGPPeerListOptions{}, converters.BGPPeer{}, noFilter,
-	); err != nil {
-		return nil, err
-	}
+		m.statusBullet("handling BGPPeer (node) resources")
+		return m.queryAndConvertV1ToV3Resources(
+			data, ResourceTypeBGPPeer, model.NodeBGPPeerListOptions{}, converters.BGPPeer{}, noFilter,
+		)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping HostEndpoint resources - not supported")
-	} else {
+	case ResourceTypeHostEndpoint:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping HostEndpoint resources - not supported")
+			return nil
+		}
 		m.statusBullet("handling HostEndpoint resources")
 		// Query and convert the HostEndpoints
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.HostEndpointListOptions{}, converters.HostEndpoint{}, noFilter,
-		); err != nil {
-			return nil, err
-		}
-	}
+		return m.queryAndConvertV1ToV3Resources(
+			data, ResourceTypeHostEndpoint, model.HostEndpointListOptions{}, converters.HostEndpoint{}, noFilter,
+		)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping IPPool resources - these do not need migrating")
-	} else {
+	case ResourceTypeIPPool:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping IPPool resources - these do not need migrating")
+			return nil
+		}
 		m.statusBullet("handling IPPool resources")
 		// Query and convert the IPPools
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.IPPoolListOptions{}, converters.IPPool{}, noFilter,
-		); err != nil {
-			return nil, err
-		}
-	}
+		return m.queryAndConvertV1ToV3IPPools(data)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping GlobalNetworkPolicy resources - these do not need migrating")
-	} else {
+	case ResourceTypeGlobalNetworkPolicy:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping GlobalNetworkPolicy resources - these do not need migrating")
+			return nil
+		}
 		m.statusBullet("handling GlobalNetworkPolicy resources")
 		// Query and convert the Policies
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.PolicyListOptions{}, converters.Policy{}, filterGNP,
-		); err != nil {
-			return nil, err
-		}
-	}
+		return m.queryAndConvertV1ToV3Resources(
+			data, ResourceTypeGlobalNetworkPolicy, model.PolicyListOptions{}, converters.Policy{}, filterGNP,
+		)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping Profile resources - these do not need migrating")
-	} else {
+	case ResourceTypeProfile:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping Profile resources - these do not need migrating")
+			return nil
+		}
 		m.statusBullet("handling Profile resources")
 		// Query and convert the Profiles
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.ProfileListOptions{}, converters.Profile{}, filterProfile,
-		); err != nil {
-			return nil, err
-		}
-	}
+		return m.queryAndConvertV1ToV3Profiles(data)
 
-	if m.clientv1.IsKDD() {
-		m.statusBullet("skipping WorkloadEndpoint resources - these do not need migrating")
-	} else {
+	case ResourceTypeWorkloadEndpoint:
+		if m.clientv1.IsKDD() {
+			m.statusBullet("skipping WorkloadEndpoint resources - these do not need migrating")
+			return nil
+		}
 		m.statusBullet("handling WorkloadEndpoint resources")
 		// Query and convert the WorkloadEndpoints
-		if err := m.queryAndConvertV1ToV3Resources(
-			data, model.WorkloadEndpointListOptions{}, converters.WorkloadEndpoint{}, filterWEP,
-		); err != nil {
-			return nil, err
-		}
+		return m.queryAndConvertV1ToV3Resources(
+			data, ResourceTypeWorkloadEndpoint, model.WorkloadEndpointListOptions{}, converters.WorkloadEndpoint{}, filterWEP,
+		)
 	}
 
-	return data, nil
+	return fmt.Errorf("unknown resource type '%s'", resourceType)
 }
 
 // Query the v1 format resources and convert to the v3 format. Successfully
 // migrated resources are appended to res, and conversion errors to convErr.
 func (m *migrationHelper) queryAndConvertV1ToV3Resources(
 	data *MigrationData,
+	resourceType string,
 	listInterface model.ListInterface,
 	converter converters.Converter,
 	filterOut policyCtrlFilterOut,
 ) error {
 	// Start by listing the results from the v1 client.
+	kvps, err := m.listV1Resources(listInterface)
+	if err != nil {
+		return err
+	}
+	m.convertV1ToV3Resources(data, resourceType, kvps, converter, filterOut)
+	return nil
+}
+
+// listV1Resources lists the v1 format resources. A resource type that does not exist
+// or is not supported by the v1 datastore is treated as an empty list.
+func (m *migrationHelper) listV1Resources(listInterface model.ListInterface) ([]*model.KVPair, error) {
 	kvps, err := m.clientv1.List(listInterface)
 	if err != nil {
 		switch err.(type) {
 		case cerrors.ErrorResourceDoesNotExist, cerrors.ErrorOperationNotSupported:
-			return nil
+			return nil, nil
 		default:
-			return err
+			return nil, err
 		}
 	}
+	return kvps, nil
+}
 
+// Convert the v1 format resources to the v3 format. Successfully converted
+// resources are appended to the MigrationData along with any conversion errors.
+// The result of each conversion is recorded in the migration metrics against the
+// supplied resource type.
+func (m *migrationHelper) convertV1ToV3Resources(
+	data *MigrationData,
+	resourceType string,
+	kvps []*model.KVPair,
+	converter converters.Converter,
+	filterOut policyCtrlFilterOut,
+) {
 	// Keep track of the converted names so that we can determine if we have any
 	// name clashes. We don't generally expect this, but we do need to police against
 	// it just in case.
@@ -542,11 +827,17 @@ func (m *migrationHelper) queryAndConvertV1ToV3Resources(
 		if filterOut(kvp.Key) {
 			log.Infof("Filter out Policy Controller created resource: %s", kvp.Key)
 			data.HandledByPolicyCtrl = append(data.HandledByPolicyCtrl, kvp.Key)
+			m.reportProgress(resourceType, kvp.Key.String(), MigrationResultSkipped, 0)
 			continue
 		}
 
+		start := time.Now()
 		r, err := converter.BackendV1ToAPIV3(kvp)
+		duration := time.Since(start)
 		if err != nil {
+			metrics.RecordConversion(resourceType, duration, metrics.ResultError)
+			m.reportProgress(resourceType, kvp.Key.String(), MigrationResultFailed, duration)
+			log.WithError(err).WithField("EtcdKey", model.KeyToEtcdKeyString(kvp.Key)).Info("Unable to convert resource")
 			data.ConversionErrors = append(data.ConversionErrors, ConversionError{
 				KeyV1:   kvp.Key,
 				ValueV1: kvp.Value,
@@ -582,65 +873,158 @@ func (m *migrationHelper) queryAndConvertV1ToV3Resources(
 		}
 
 		// Only store the resource and the converted name if it's valid.
-		if valid {
-			data.Resources = append(data.Resources, r)
-			data.NameConversions = append(data.NameConversions, NameConversion{
-				KeyV1: kvp.Key,
-				KeyV3: resourceToKey(r),
-			})
+		if !valid {
+			metrics.RecordConversion(resourceType, duration, metrics.ResultError)
+			m.reportProgress(resourceType, kvp.Key.String(), MigrationResultFailed, duration)
+			continue
+		}
+		metrics.RecordConversion(resourceType, duration, metrics.ResultSuccess)
+		m.reportProgress(resourceType, kvp.Key.String(), MigrationResultConverted, duration)
+		data.Resources = append(data.Resources, r)
+		data.NameConversions = append(data.NameConversions, NameConversion{
+			KeyV1: kvp.Key,
+			KeyV3: resourceToKey(r),
+		})
+	}
+}
+
+// Query the v1 format IPPools and convert to the v3 format. The v1 data model allows
+// IPPools to be nested, but v3 does not allow pools to overlap. If any of the v1 pool
+// CIDRs overlap, a conversion error is added for each overlapping pair and none of the
+// pools are converted.
+func (m *migrationHelper) queryAndConvertV1ToV3IPPools(data *MigrationData) error {
+	kvps, err := m.listV1Resources(model.IPPoolListOptions{})
+	if err != nil {
+		return err
+	}
+
+	if overlaps := checkIPPoolOverlaps(kvps); len(overlaps) != 0 {
+		m.statusBullet("found %d overlapping IPPool(s), IPPools will not be migrated", len(overlaps))
+		for _, kvp := range kvps {
+			m.reportProgress(ResourceTypeIPPool, kvp.Key.String(), MigrationResultFailed, 0)
 		}
+		data.ConversionErrors = append(data.ConversionErrors, overlaps...)
+		return nil
 	}
 
+	m.convertV1ToV3Resources(data, ResourceTypeIPPool, kvps, converters.IPPool{}, noFilter)
 	return nil
 }
 
-func (m *migrationHelper) queryAndConvertGlobalBGPConfigV1ToV3(data *MigrationData) error {
-	globalBGPConfig := apiv3.NewBGPConfiguration()
-	globalBGPConfig.Name = "default"
+// Query the v1 format Profiles and convert to the v3 format. If a profile selector
+// has been configured, only the Profiles whose labels match the selector are converted.
+func (m *migrationHelper) queryAndConvertV1ToV3Profiles(data *MigrationData) error {
+	kvps, err := m.listV1Resources(model.ProfileListOptions{})
+	if err != nil {
+		return err
+	}
 
-	log.Info("Converting BGP config -> BGPConfiguration(default)")
-	var setValue bool
-	if kvp, err := m.clientv1.Get(model.GlobalBGPConfigKey{Name: "AsNumber"}); err != nil {
-		if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
-			return err
-		}
-		log.Info("No global default ASNumber configured")
-	} else if kvp.Value.(string) != "" {
-		asNum, err := numorstring.ASNumberFromString(kvp.Value.(string))
+	if m.profileSelector != "" {
+		sel, err := selector.Parse(m.profileSelector)
 		if err != nil {
-			log.WithError(err).WithField("ASNumber", kvp.Value).Info("Invalid global default ASNumber")
-			data.ConversionErrors = append(data.ConversionErrors, ConversionError{
+			return fmt.Errorf("invalid Profile label selector %q: %v", m.profileSelector, err)
+		}
+
+		var selected []*model.KVPair
+		for _, kvp := range kvps {
+			p, ok := kvp.Value.(*model.Profile)
+			if !ok || !sel.Evaluate(p.Labels) {
+				log.Infof("Skipping Profile that does not match label selector: %s", kvp.Key)
+				m.reportProgress(ResourceTypeProfile, kvp.Key.String(), MigrationResultSkipped, 0)
+				continue
+			}
+			selected = append(selected, kvp)
+		}
+		m.statusBullet("converting %d of %d Profile(s) matching selector: %s", len(selected), len(kvps), m.profileSelector)
+		kvps = selected
+	}
+
+	m.convertV1ToV3Resources(data, ResourceTypeProfile, kvps, converters.Profile{}, filterProfile)
+	return nil
+}
+
+// checkIPPoolOverlaps returns a ConversionError for each pair of v1 IPPools whose
+// CIDRs overlap.
+func checkIPPoolOverlaps(kvps []*model.KVPair) []ConversionError {
+	var overlaps []ConversionError
+	for i, kvp := range kvps {
+		pool, ok := kvp.Value.(*model.IPPool)
+		if !ok {
+			continue
+		}
+		poolNet := pool.CIDR.Network()
+		for _, otherKvp := range kvps[i+1:] {
+			other, ok := otherKvp.Value.(*model.IPPool)
+			if !ok {
+				continue
+			}
+			otherNet := other.CIDR.Network()
+			if !poolNet.IsNetOverlap(otherNet.IPNet) {
+				continue
+			}
+			log.WithFields(log.Fields{
+				"CIDR":      poolNet,
+				"OtherCIDR": otherNet,
+			}).Info("Found overlapping IPPools")
+			overlaps = append(overlaps, ConversionError{
+				KeyV1:   kvp.Key,
 				ValueV1: kvp.Value,
-				Cause:   fmt.Errorf("default ASNumber is not valid: %s", kvp.Value.(string)),
+				Cause: fmt.Errorf("IPPool %s (%s) overlaps with IPPool %s (%s): overlapping IPPools are not "+
+					"supported in v3", names.CIDRToName(pool.CIDR), poolNet, names.CIDRToName(other.CIDR), otherNet),
 			})
-			return err
 		}
-		globalBGPConfig.Spec.ASNumber = &asNum
-		setValue = true
 	}
+	return overlaps
+}
 
-	if kvp, err := m.clientv1.Get(model.GlobalBGPConfigKey{Name: "LogLevel"}); err != nil {
-		if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
-			return err
+func (m *migrationHelper) queryAndConvertGlobalBGPConfigV1ToV3(data *MigrationData) error {
+	log.Info("Converting BGP config -> BGPConfiguration(default)")
+	var kvps []*model.KVPair
+	for _, name := range []string{"AsNumber", "LogLevel", "NodeMeshEnabled"} {
+		kvp, err := m.clientv1.Get(model.GlobalBGPConfigKey{Name: name})
+		if err != nil {
+			if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
+				return err
+			}
+			log.WithField("name", name).Info("No global BGP config configured")
+			continue
 		}
-		log.Info("No global BGP log level configured")
-	} else if kvp.Value.(string) != "" {
-		globalBGPConfig.Spec.LogSeverityScreen = convertLogLevel(kvp.Value.(string))
-		setValue = true
+		kvps = append(kvps, kvp)
 	}
 
-	if kvp, err := m.clientv1.Get(model.GlobalBGPConfigKey{Name: "NodeMeshEnabled"}); err != nil {
-		if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
-			return err
+	// Include any BGP communities stored in custom keys.
+	all, err := m.listV1Resources(model.GlobalBGPConfigListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, kvp := range all {
+		if k, ok := kvp.Key.(model.GlobalBGPConfigKey); ok && strings.HasPrefix(k.Name, converters.GlobalBGPConfigCommunityPrefix) {
+			kvps = append(kvps, kvp)
 		}
-		log.Info("No global node to node mesh enabled setting configured")
-	} else if kvp.Value.(string) != "" {
-		nodeMeshEnabled := strings.ToLower(kvp.Value.(string)) == "true"
-		globalBGPConfig.Spec.NodeToNodeMeshEnabled = &nodeMeshEnabled
-		setValue = true
 	}
-	if setValue {
-		data.Resources = append(data.Resources, globalBGPConfig)
+
+	start := time.Now()
+	res, errs := converters.GlobalBGPConfig{}.BackendV1ToAPIV3(kvps)
+	duration := time.Since(start)
+	keyV3 := model.ResourceKey{Kind: apiv3.KindBGPConfiguration, Name: "default"}
+	result, progress := metrics.ResultSuccess, MigrationResultConverted
+	if len(errs) != 0 {
+		result, progress = metrics.ResultError, MigrationResultFailed
+	}
+	metrics.RecordConversion(ResourceTypeBGPConfiguration, duration, result)
+	if res != nil || len(errs) != 0 {
+		m.reportProgress(ResourceTypeBGPConfiguration, keyV3.String(), progress, duration)
+	}
+
+	m.addConfigConversionErrors(errs, keyV3, data)
+	if len(errs) != 0 {
+		// An invalid AS number would change the AS of every node using the default, so
+		// the remaining config is not migrated without it.  Likewise, a community must
+		// be resolved rather than silently dropped.
+		return nil
+	}
+	if res != nil {
+		data.Resources = append(data.Resources, res)
 	}
 	return nil
 }
@@ -651,7 +1035,7 @@ func (m *migrationHelper) queryAndConvertV1ToV3Nodes(data *MigrationData) error
 	// Start by querying the nodes and converting them, we don't add the nodes to the list
 	// of results just yet.
 	err := m.queryAndConvertV1ToV3Resources(
-		data, model.NodeListOptions{}, converters.Node{}, noFilter,
+		data, ResourceTypeNode, model.NodeListOptions{}, converters.Node{}, noFilter,
 	)
 	if err != nil {
 		return err
@@ -668,19 +1052,34 @@ func (m *migrationHelper) queryAndConvertV1ToV3Nodes(data *MigrationData) error
 			return err
 		}
 	}
-	addrs := map[string]string{}
+	addrs := map[string]*model.KVPair{}
 	for _, kvp := range kvps {
 		k := kvp.Key.(model.HostConfigKey)
-		addrs[converters.ConvertNodeName(k.Hostname)] = kvp.Value.(string)
+		addrs[converters.ConvertNodeName(k.Hostname)] = kvp
 	}
 
 	// Update the node resources to include the tunnel addresses.  Loop through the converted
 	// resources and modify any node that has a corresponding tunnel address (it's a pointer
-	// so we can adjust the in-situ resource).
+	// so we can adjust the in-situ resource).  The tunnel address is stored alongside the
+	// BGP addresses, so both are preserved.
 	for _, r := range data.Resources {
 		if nr, ok := r.(*libapiv3.Node); ok {
-			addr := addrs[nr.Name]
-			if addr == "" || nr.Spec.BGP == nil {
+			kvp := addrs[nr.Name]
+			if kvp == nil || nr.Spec.BGP == nil {
+				continue
+			}
+			addr, _ := kvp.Value.(string)
+			if addr == "" {
+				// No tunnel address is configured.
+				continue
+			}
+			if ip := cnet.ParseIP(addr); ip == nil || ip.Version() != 4 {
+				data.ConversionErrors = append(data.ConversionErrors, ConversionError{
+					KeyV1:   kvp.Key,
+					ValueV1: kvp.Value,
+					KeyV3:   resourceToKey(nr),
+					Cause:   fmt.Errorf("IPIP tunnel address is not a valid IPv4 address: %s", addr),
+				})
 				continue
 			}
 			nr.Spec.BGP.IPv4IPIPTunnelAddr = addr
@@ -762,6 +1161,17 @@ func (m *migrationHelper) ShouldMigrate() (bool, error) {
 // the calico-upgrade script which performs additional checks to verify that the v3
 // datastore is clean.  If the v1 version is not present, this is considered an error case.
 func (m *migrationHelper) CanMigrate() error {
+	if m.etcdConfig != nil {
+		m.status("Checking connectivity to the v3 API datastore")
+		ctx, cancel := context.WithTimeout(context.Background(), connectivityCheckTimeout)
+		defer cancel()
+		if err := etcdv3.CheckDatastoreConnectivity(ctx, m.etcdConfig); err != nil {
+			m.statusBullet("unable to access the v3 API datastore: %v", err)
+			return err
+		}
+		m.statusBullet("the v3 API datastore is reachable and healthy")
+	}
+
 	m.status("Checking Calico version is suitable for migration")
 	v, err := m.getV1ClusterVersion()
 	if err != nil {
@@ -838,29 +1248,6 @@ func versionRequiresMigration(v string) (bool, error) {
 	return false, fmt.Errorf("migration to v3 requires a tagged release of Calico v%s+", minUpgradeVersion)
 }
 
-// convertLogLevel converts the v1 log level to the equivalent v3 log level. We
-// ignore errors, defaulting to info in the event of a conversion error.
-func convertLogLevel(logLevel string) string {
-	switch strings.ToLower(logLevel) {
-	case "debug":
-		return "Debug"
-	case "info":
-		return "Info"
-	case "warning":
-		return "Warning"
-	case "error":
-		return "Error"
-	case "fatal":
-		return "Fatal"
-	case "panic":
-		return "Fatal"
-	case "":
-		return ""
-	default:
-		return "Info"
-	}
-}
-
 // Display a 79-char word wrapped status message and log.
 func (m *migrationHelper) status(format string, a ...interface{}) {
 	msg := fmt.Sprintf(format, a...)
@@ -1009,7 +1396,7 @@ func resourceToKey(r converters.Resource) model.Key {
 }
 
 // storeV3Resources stores the converted resources in the v3 datastore.
-func (m *migrationHelper) storeV3Resources(data *MigrationData) error {
+func (m *migrationHelper) storeV3Resources(ctx context.Context, data *MigrationData) error {
 	m.statusBullet("Storing resources in v3 format")
 	for n, r := range data.Resources {
 		// Convert the resource to a KVPair and access the backend datastore directly.
@@ -1017,7 +1404,7 @@ func (m *migrationHelper) storeV3Resources(data *MigrationData) error {
 		// processing. Since we are applying directly to the backend we need to set the UUID
 		// and creation timestamp which is normally handled by clientv3.
 		r = toStorage(r)
-		if err := m.applyToBackend(&model.KVPair{
+		if err := m.applyToBackend(ctx, &model.KVPair{
 			Key:   resourceToKey(r),
 			Value: r,
 		}); err != nil {
@@ -1029,6 +1416,10 @@ func (m *migrationHelper) storeV3Resources(data *MigrationData) error {
 		}
 	}
 	m.statusBullet("success: resources stored in v3 datastore")
+
+	if m.integrityCheck {
+		return m.verifyV3Resources(ctx, data)
+	}
 	return nil
 }
 
@@ -1055,87 +1446,13 @@ func convertPolicyNameForStorage(name string) string {
 	return "default." + name
 }
 
-// migrateIPAMData queries, converts and migrates all of the IPAM data from v1
-// to v3 formats.
-func (m *migrationHelper) migrateIPAMData() error {
-	var kvpsv3 []*model.KVPair
-
-	// Query all of the IPAM data:
-	// -  Blocks
-	// -  BlockAffinity
-	// -  IPAMHandle
-	// AllocationBlocks need to have their host affinity updated to use the
-	// normalized node name.
-	m.statusBullet("listing and converting IPAM allocation blocks")
-	kvps, err := m.clientv1.List(model.BlockListOptions{})
-	if err != nil {
-		m.statusError("Unable to list IPAM allocation blocks")
-		m.statusBullet("cause: %v", err)
-		return fmt.Errorf("unable to list IPAM allocation blocks: %v", err)
-	}
-	for _, kvp := range kvps {
-		ab := kvp.Value.(*model.AllocationBlock)
-		node := ""
-		if ab.Affinity != nil && strings.HasPrefix(*ab.Affinity, "host:") {
-			node = strings.TrimPrefix(*ab.Affinity, "host:")
-		} else if ab.HostAffinity != nil {
-			node = *ab.HostAffinity
-		}
-
-		if node != "" {
-			aff := "host:" + converters.ConvertNodeName(node)
-			ab.Affinity = &aff
-		}
-		kvpsv3 = append(kvpsv3, kvp)
-	}
-
-	// BlockAffinities need to have their host updated to use the
-	// normalized node name.
-	m.statusBullet("listing and converting IPAM affinity blocks")
-	kvps, err = m.clientv1.List(model.BlockAffinityListOptions{})
-	if err != nil {
-		m.statusError("Unable to list IPAM affinity blocks")
-		m.statusBullet("cause: %v", err)
-		return fmt.Errorf("unable to list IPAM affinity blocks: %v", err)
-	}
-	for _, kvp := range kvps {
-		k := kvp.Key.(model.BlockAffinityKey)
-		k.Host = converters.ConvertNodeName(k.Host)
-		kvp.Key = k
-		kvpsv3 = append(kvpsv3, kvp)
-	}
-
-	// IPAMHandle does not require any conversion.
-	m.statusBullet("listing IPAM handles")
-	kvps, err = m.clientv1.List(model.IPAMHandleListOptions{})
-	if err != nil {
-		m.statusError("Unable to list IPAM handles")
-		m.statusBullet("cause: %v", err)
-		return fmt.Errorf("unable to list IPAM handles: %v", err)
-	}
-	kvpsv3 = append(kvpsv3, kvps...)
-
-	// Create/Apply the converted entries into the v3 datastore.
-	m.statusBullet("storing IPAM data in v3 format")
-	for _, kvp := range kvpsv3 {
-		if err := m.applyToBackend(kvp); err != nil {
-			m.statusError("Error writing IPAM data to v3 datastore")
-			return fmt.Errorf("error storing converted IPAM data: %v", err)
-		}
-	}
-
-	// We migrated the data successfully.
-	m.statusBullet("IPAM data migrated successfully")
-	return nil
-}
-
 // backendClientAccessor is an interface used to access the backend client from the main clientv3.
 type backendClientAccessor interface {
 	Backend() bapi.Client
 }
 
 // applyToBackend applies the supplied KVPair directly to the backend datastore.
-func (m *migrationHelper) applyToBackend(kvp *model.KVPair) error {
+func (m *migrationHelper) applyToBackend(ctx context.Context, kvp *model.KVPair) error {
 	// Extract the backend client API from the v3 client.
 	bc := m.clientv3.(backendClientAccessor).Backend()
 
@@ -1143,28 +1460,33 @@ func (m *migrationHelper) applyToBackend(kvp *model.KVPair) error {
 	logCxt := log.WithField("Key", kvp.Key)
 	logCxt.Debug("Attempting to create resource")
 	kvp.Revision = ""
-	_, err := bc.Create(context.Background(), kvp)
+	_, err := bc.Create(ctx, kvp)
 	if err == nil {
 		logCxt.Debug("Resource created")
+		m.state.recordCreated(kvp.Key)
 		return nil
 	}
 	if _, ok := err.(cerrors.ErrorResourceAlreadyExists); !ok {
 		logCxt.WithError(err).Info("Failed to create resource")
 		return err
 	}
+	if m.createOnly {
+		logCxt.Info("Resource already exists and create-only writes are enabled, not updating")
+		return err
+	}
 
 	logCxt.Debug("Resource already exists, try update")
 	for i := 0; i < maxApplyRetries; i++ {
 		// Query the current settings and update the kvp revision so that we can
 		// perform an update.
 		logCxt.Debug("Attempting to update resource")
-		current, err := bc.Get(context.Background(), kvp.Key, "")
+		current, err := bc.Get(ctx, kvp.Key, "")
 		if err != nil {
 			return err
 		}
 		kvp.Revision = current.Revision
 
-		_, err = bc.Update(context.Background(), kvp)
+		_, err = bc.Update(ctx, kvp)
 		if err == nil {
 			logCxt.Debug("Resource updated")
 			return nil
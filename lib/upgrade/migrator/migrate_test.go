@@ -27,9 +27,11 @@ import (
 
 	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	"github.com/projectcalico/libcalico-go/lib/apiconfig"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
 	"github.com/projectcalico/libcalico-go/lib/backend"
 	"github.com/projectcalico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
 	"github.com/projectcalico/libcalico-go/lib/net"
 	"github.com/projectcalico/libcalico-go/lib/options"
 	"github.com/projectcalico/libcalico-go/lib/testutils"
@@ -174,6 +176,374 @@ var _ = Describe("Test OpenStack migration filters", func() {
 	})
 })
 
+var _ = Describe("Test Profile label filter", func() {
+	profileKVP := func(name string, labels map[string]string) *model.KVPair {
+		return &model.KVPair{
+			Key: model.ProfileKey{Name: name},
+			Value: &model.Profile{
+				Rules: model.ProfileRules{
+					InboundRules: []model.Rule{converters.V1ModelInRule1},
+				},
+				Tags:   []string{},
+				Labels: labels,
+			},
+		}
+	}
+	clientv1 := fakeClientV1{
+		kvps: []*model.KVPair{
+			profileKVP("profile1", map[string]string{"projectcalico.org/ns-profile": "true"}),
+			profileKVP("profile2", map[string]string{"projectcalico.org/ns-profile": "false"}),
+			profileKVP("profile3", map[string]string{"projectcalico.org/ns-profile": "true", "tier": "web"}),
+			profileKVP("profile4", map[string]string{"tier": "web"}),
+			profileKVP("profile5", nil),
+			profileKVP("k8s_ns.profile6", map[string]string{"projectcalico.org/ns-profile": "true"}),
+		},
+	}
+
+	convertedNames := func(data *MigrationData) []string {
+		names := []string{}
+		for _, r := range data.Resources {
+			names = append(names, r.GetObjectMeta().GetName())
+		}
+		return names
+	}
+
+	DescribeTable("should only convert the Profiles matching the selector",
+		func(selector string, expected []string) {
+			mh := New(nil, clientv1, nil, WithLabelFilter(selector)).(*migrationHelper)
+			data, err := mh.queryAndConvertResources()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(data.ConversionErrors).To(HaveLen(0))
+			Expect(convertedNames(data)).To(ConsistOf(expected))
+		},
+		Entry("no selector", "", []string{"profile1", "profile2", "profile3", "profile4", "profile5"}),
+		Entry("equality selector", `projectcalico.org/ns-profile == "true"`, []string{"profile1", "profile3"}),
+		Entry("has selector", "has(tier)", []string{"profile3", "profile4"}),
+		Entry("combined selector", `projectcalico.org/ns-profile == "true" && tier == "web"`, []string{"profile3"}),
+		Entry("selector matching nothing", `tier == "db"`, []string{}),
+	)
+
+	It("should fail with an invalid selector", func() {
+		mh := New(nil, clientv1, nil, WithLabelFilter("tier ==")).(*migrationHelper)
+		_, err := mh.queryAndConvertResources()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Test IPPool overlap detection", func() {
+
+	ipPoolKVP := func(cidr string) *model.KVPair {
+		c := net.MustParseCIDR(cidr)
+		return &model.KVPair{
+			Key: model.IPPoolKey{CIDR: c},
+			Value: &model.IPPool{
+				CIDR: c,
+				IPAM: true,
+			},
+		}
+	}
+
+	It("should convert IPPools that do not overlap", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{
+				ipPoolKVP("10.0.0.0/16"),
+				ipPoolKVP("10.1.0.0/16"),
+				ipPoolKVP("2001::/120"),
+			},
+		}
+
+		convertAndCheckResourcesConverted(clientv1, 3)
+	})
+
+	It("should not convert IPPools when a v1 IPPool is nested within another", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{
+				ipPoolKVP("10.0.0.0/8"),
+				ipPoolKVP("10.0.0.0/16"),
+				ipPoolKVP("192.168.0.0/16"),
+			},
+		}
+
+		mh := &migrationHelper{clientv1: clientv1}
+		data, err := mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.HasErrors()).To(BeTrue())
+		Expect(data.Resources).To(HaveLen(0))
+		Expect(data.ConversionErrors).To(HaveLen(1))
+		Expect(data.ConversionErrors[0].KeyV1).To(Equal(model.IPPoolKey{CIDR: net.MustParseCIDR("10.0.0.0/8")}))
+		Expect(data.ConversionErrors[0].Cause.Error()).To(Equal(
+			"IPPool 10-0-0-0-8 (10.0.0.0/8) overlaps with IPPool 10-0-0-0-16 (10.0.0.0/16): " +
+				"overlapping IPPools are not supported in v3"))
+	})
+
+	It("should report each pair of overlapping IPPools", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{
+				ipPoolKVP("10.0.0.0/8"),
+				ipPoolKVP("10.0.0.0/16"),
+				ipPoolKVP("10.0.1.1/24"),
+			},
+		}
+
+		mh := &migrationHelper{clientv1: clientv1}
+		data, err := mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.Resources).To(HaveLen(0))
+		Expect(data.ConversionErrors).To(HaveLen(3))
+		Expect(data.ConversionErrors[2].Cause.Error()).To(Equal(
+			"IPPool 10-0-0-0-16 (10.0.0.0/16) overlaps with IPPool 10-0-1-1-24 (10.0.1.0/24): " +
+				"overlapping IPPools are not supported in v3"))
+	})
+})
+
+var _ = Describe("Test Node migration", func() {
+	ipv4 := net.MustParseIP("192.168.1.1")
+	ipv6 := net.MustParseIP("fed::5")
+	nodeKVP := &model.KVPair{
+		Key: model.NodeKey{Hostname: "node1"},
+		Value: &model.Node{
+			BGPIPv4Addr: &ipv4,
+			BGPIPv6Addr: &ipv6,
+		},
+	}
+	tunnelKVP := func(addr string) *model.KVPair {
+		return &model.KVPair{
+			Key:   model.HostConfigKey{Hostname: "node1", Name: "IpInIpTunnelAddr"},
+			Value: addr,
+		}
+	}
+
+	It("should preserve both the BGP addresses and the IPIP tunnel address", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{nodeKVP, tunnelKVP("10.0.0.1")},
+		}
+
+		data := &MigrationData{}
+		mh := &migrationHelper{clientv1: clientv1}
+		Expect(mh.queryAndConvertV1ToV3Nodes(data)).NotTo(HaveOccurred())
+		Expect(data.ConversionErrors).To(HaveLen(0))
+		Expect(data.Resources).To(HaveLen(1))
+		node := data.Resources[0].(*libapiv3.Node)
+		Expect(node.Spec.BGP.IPv4Address).To(Equal("192.168.1.1/32"))
+		Expect(node.Spec.BGP.IPv6Address).To(Equal("fed::5/128"))
+		Expect(node.Spec.BGP.IPv4IPIPTunnelAddr).To(Equal("10.0.0.1"))
+	})
+
+	It("should report an invalid IPIP tunnel address", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{nodeKVP, tunnelKVP("fed::6")},
+		}
+
+		data := &MigrationData{}
+		mh := &migrationHelper{clientv1: clientv1}
+		Expect(mh.queryAndConvertV1ToV3Nodes(data)).NotTo(HaveOccurred())
+		Expect(data.ConversionErrors).To(HaveLen(1))
+		Expect(data.ConversionErrors[0].KeyV1).To(Equal(tunnelKVP("").Key))
+		Expect(data.Resources[0].(*libapiv3.Node).Spec.BGP.IPv4IPIPTunnelAddr).To(Equal(""))
+	})
+
+	It("should skip an empty IPIP tunnel address", func() {
+		clientv1 := fakeClientV1{
+			kvps: []*model.KVPair{nodeKVP, tunnelKVP("")},
+		}
+
+		data := &MigrationData{}
+		mh := &migrationHelper{clientv1: clientv1}
+		Expect(mh.queryAndConvertV1ToV3Nodes(data)).NotTo(HaveOccurred())
+		Expect(data.ConversionErrors).To(BeEmpty())
+		Expect(data.Resources[0].(*libapiv3.Node).Spec.BGP.IPv4IPIPTunnelAddr).To(Equal(""))
+	})
+})
+
+var _ = Describe("Test selective migration of a resource type", func() {
+	ipPool := net.MustParseCIDR("10.0.0.0/16")
+	clientv1 := fakeClientV1{
+		kvps: []*model.KVPair{
+			{
+				Key:   model.IPPoolKey{CIDR: ipPool},
+				Value: &model.IPPool{CIDR: ipPool, IPAM: true},
+			},
+			{
+				Key: model.ProfileKey{Name: "profile1"},
+				Value: &model.Profile{
+					Rules: model.ProfileRules{
+						InboundRules: []model.Rule{converters.V1ModelInRule1},
+					},
+				},
+			},
+			{
+				Key:   model.GlobalConfigKey{Name: "InterfacePrefix"},
+				Value: "cali",
+			},
+		},
+	}
+
+	DescribeTable("should only convert the requested resource type",
+		func(resourceType string, expectedKind string) {
+			data := &MigrationData{}
+			mh := &migrationHelper{clientv1: clientv1}
+			Expect(mh.queryAndConvertResourceType(data, resourceType)).NotTo(HaveOccurred())
+			Expect(data.HasErrors()).To(BeFalse())
+			Expect(data.Resources).To(HaveLen(1))
+			Expect(data.Resources[0].GetObjectKind().GroupVersionKind().Kind).To(Equal(expectedKind))
+		},
+		Entry("IPPool", ResourceTypeIPPool, v3.KindIPPool),
+		Entry("Profile", ResourceTypeProfile, v3.KindProfile),
+		Entry("FelixConfiguration", ResourceTypeFelixConfiguration, v3.KindFelixConfiguration),
+	)
+
+	It("should convert each resource type in turn when converting all resources", func() {
+		mh := &migrationHelper{clientv1: clientv1}
+		data, err := mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data.Resources).To(HaveLen(3))
+	})
+
+	It("should reject an unknown resource type", func() {
+		mh := &migrationHelper{clientv1: clientv1}
+		_, err := mh.MigrateResourceType(context.Background(), "NetworkPolicy")
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorGeneric))
+		Expect(err.Error()).To(ContainSubstring("unknown resource type 'NetworkPolicy'"))
+	})
+
+	It("should return the conversion errors without storing the resources", func() {
+		mh := &migrationHelper{clientv1: fakeClientV1{
+			kvps: []*model.KVPair{
+				{
+					Key:   model.IPPoolKey{CIDR: net.MustParseCIDR("10.0.0.0/8")},
+					Value: &model.IPPool{CIDR: net.MustParseCIDR("10.0.0.0/8")},
+				},
+				{
+					Key:   model.IPPoolKey{CIDR: ipPool},
+					Value: &model.IPPool{CIDR: ipPool},
+				},
+			},
+		}}
+		report, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(MigrationError).Type).To(Equal(ErrorConvertingData))
+		Expect(report).NotTo(BeNil())
+		Expect(report.ResourceType).To(Equal(ResourceTypeIPPool))
+		Expect(report.ConversionErrors).To(HaveLen(1))
+	})
+
+	clusterInfoV1 := func(ready bool) fakeClientV1 {
+		return fakeClientV1{
+			kvps: []*model.KVPair{
+				{
+					Key:   model.GlobalConfigKey{Name: "ClusterType"},
+					Value: "k8s,bgp",
+				},
+				{
+					Key:   model.ReadyFlagKey{},
+					Value: ready,
+				},
+			},
+		}
+	}
+	clusterInfoKey := model.ResourceKey{Kind: v3.KindClusterInformation, Name: "default"}
+	storedReady := func(be *fakeBackend) *bool {
+		kvp, err := be.Get(context.Background(), clusterInfoKey, "")
+		Expect(err).NotTo(HaveOccurred())
+		return kvp.Value.(*v3.ClusterInformation).Spec.DatastoreReady
+	}
+
+	It("should keep the Ready flag of the existing ClusterInformation", func() {
+		be := &fakeBackend{kvps: map[string]*model.KVPair{}}
+		ready := true
+		existing := v3.NewClusterInformation()
+		existing.Name = "default"
+		existing.Spec.DatastoreReady = &ready
+		_, err := be.Create(context.Background(), &model.KVPair{Key: clusterInfoKey, Value: existing})
+		Expect(err).NotTo(HaveOccurred())
+
+		mh := New(fakeClientV3{backend: be}, clusterInfoV1(false), nil)
+		_, err = mh.MigrateResourceType(context.Background(), ResourceTypeFelixConfiguration)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storedReady(be)).To(Equal(&ready))
+	})
+
+	It("should use the v1 Ready flag if there is no existing ClusterInformation", func() {
+		be := &fakeBackend{kvps: map[string]*model.KVPair{}}
+		mh := New(fakeClientV3{backend: be}, clusterInfoV1(true), nil)
+		_, err := mh.MigrateResourceType(context.Background(), ResourceTypeFelixConfiguration)
+		Expect(err).NotTo(HaveOccurred())
+		ready := true
+		Expect(storedReady(be)).To(Equal(&ready))
+	})
+})
+
+var _ = Describe("Test listing the v1 resources", func() {
+	ipPool := net.MustParseCIDR("10.0.0.0/16")
+	kvps := []*model.KVPair{
+		{
+			Key:   model.IPPoolKey{CIDR: ipPool},
+			Value: &model.IPPool{CIDR: ipPool, IPAM: true},
+		},
+		{
+			Key:   model.ProfileKey{Name: "profile1"},
+			Value: &model.Profile{},
+		},
+		{
+			Key:   model.ProfileKey{Name: "profile2"},
+			Value: &model.Profile{},
+		},
+		{
+			Key:   model.GlobalConfigKey{Name: "InterfacePrefix"},
+			Value: "cali",
+		},
+		{
+			Key:   model.HostConfigKey{Hostname: "node1", Name: "InterfacePrefix"},
+			Value: "tap",
+		},
+		{
+			Key:   model.IPAMHandleKey{HandleID: "handle1"},
+			Value: &model.IPAMHandle{HandleID: "handle1"},
+		},
+		{
+			Key:   model.BlockKey{CIDR: net.MustParseCIDR("10.0.0.0/26")},
+			Value: &model.AllocationBlock{CIDR: net.MustParseCIDR("10.0.0.0/26")},
+		},
+		{
+			Key:   model.BlockAffinityKey{CIDR: net.MustParseCIDR("10.0.0.0/26"), Host: "node1"},
+			Value: &model.BlockAffinity{State: model.StateConfirmed},
+		},
+	}
+
+	It("should group the v1 resources by resource type", func() {
+		mh := &migrationHelper{clientv1: fakeClientV1{kvps: kvps}}
+		resources, err := mh.ListV1Resources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		for _, rt := range ResourceTypes {
+			Expect(resources).To(HaveKey(rt))
+		}
+		Expect(resources[ResourceTypeIPPool]).To(ConsistOf(kvps[0]))
+		Expect(resources[ResourceTypeProfile]).To(ConsistOf(kvps[1], kvps[2]))
+		Expect(resources[ResourceTypeFelixConfiguration]).To(ConsistOf(kvps[3], kvps[4]))
+		Expect(resources[ResourceTypeWorkloadEndpoint]).To(BeEmpty())
+		Expect(resources[ResourceTypeIPAM]).To(ConsistOf(kvps[5], kvps[6], kvps[7]))
+	})
+
+	It("should only list the resource types that are migrated for KDD", func() {
+		mh := &migrationHelper{clientv1: fakeClientV1{kdd: true, kvps: kvps}}
+		resources, err := mh.ListV1Resources(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources).NotTo(HaveKey(ResourceTypeIPPool))
+		Expect(resources).NotTo(HaveKey(ResourceTypeProfile))
+		Expect(resources).NotTo(HaveKey(ResourceTypeIPAM))
+		Expect(resources[ResourceTypeFelixConfiguration]).To(ConsistOf(kvps[3]))
+	})
+
+	It("should return an error if the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		mh := &migrationHelper{clientv1: fakeClientV1{kvps: kvps}}
+		_, err := mh.ListV1Resources(ctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
+
 var _ = testutils.E2eDatastoreDescribe("Migration tests", testutils.DatastoreEtcdV3, func(config apiconfig.CalicoAPIConfig) {
 
 	ctx := context.Background()
@@ -256,4 +626,40 @@ var _ = testutils.E2eDatastoreDescribe("Migration tests", testutils.DatastoreEtc
 			errors.New("unable to migrate data from version '': unable to parse the version")),
 		Entry("v3 only calico version (blank)", nil, &blank, false),
 	)
+
+	DescribeTable("Applying a resource that already exists in the v3 datastore",
+		func(createOnly bool) {
+			v3Client, err := clientv3.New(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			be, err := backend.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+			be.Clean()
+
+			mh := New(v3Client, fakeClientV1{}, nil, WithCreateOnly(createOnly)).(*migrationHelper)
+			kvp := func(prefix string) *model.KVPair {
+				return &model.KVPair{
+					Key:   model.IPAMHandleKey{HandleID: "handle1"},
+					Value: &model.IPAMHandle{HandleID: "handle1", Block: map[string]int{prefix: 1}},
+				}
+			}
+
+			By("Applying the resource when it does not exist")
+			Expect(mh.applyToBackend(ctx, kvp("10.0.0.0/26"))).NotTo(HaveOccurred())
+
+			By("Applying the resource again with a different value")
+			err = mh.applyToBackend(ctx, kvp("10.0.0.64/26"))
+			current, getErr := be.Get(ctx, model.IPAMHandleKey{HandleID: "handle1"}, "")
+			Expect(getErr).NotTo(HaveOccurred())
+			if createOnly {
+				Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceAlreadyExists{}))
+				Expect(current.Value.(*model.IPAMHandle).Block).To(HaveKey("10.0.0.0/26"))
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(current.Value.(*model.IPAMHandle).Block).To(HaveKey("10.0.0.64/26"))
+			}
+		},
+		Entry("should update the resource without create-only writes", false),
+		Entry("should fail without updating the resource with create-only writes", true),
+	)
 })
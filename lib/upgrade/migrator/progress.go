@@ -0,0 +1,122 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// progressChanSize is the size of the channel returned by WatchMigrationProgress.
+const progressChanSize = 100
+
+// MigrationResult is the result of migrating a single v1 resource.
+type MigrationResult string
+
+const (
+	// MigrationResultConverted is the result of a resource that was converted and validated.
+	MigrationResultConverted MigrationResult = "converted"
+
+	// MigrationResultFailed is the result of a resource that could not be converted, or
+	// whose converted resource failed validation.
+	MigrationResultFailed MigrationResult = "failed"
+
+	// MigrationResultSkipped is the result of a resource that is not migrated, e.g. because
+	// it is handled by the Kubernetes policy controller or does not match the label filter.
+	MigrationResultSkipped MigrationResult = "skipped"
+)
+
+// MigrationProgressEvent is sent by WatchMigrationProgress for each v1 resource handled by
+// the migration.
+type MigrationProgressEvent struct {
+	// The resource type, one of ResourceTypes.
+	ResourceType string
+
+	// The name of the resource.  This is the v1 key of the resources that are converted
+	// one at a time, and the v3 key of the configuration resources.
+	ResourceName string
+
+	// The result of the migration of the resource.
+	Result MigrationResult
+
+	// The time taken to convert the resource.
+	Duration time.Duration
+
+	// The time at which the resource was handled.
+	Timestamp time.Time
+}
+
+// progressWatchers is the set of channels that receive MigrationProgressEvents.
+type progressWatchers struct {
+	lock     sync.Mutex
+	watchers map[chan MigrationProgressEvent]struct{}
+}
+
+// WatchMigrationProgress returns a channel that receives a MigrationProgressEvent for each
+// v1 resource that is converted, fails or is skipped by the migration, or by any other
+// operation that converts the v1 data (e.g. ValidateConversion).  The channel is closed
+// when the context is cancelled.
+//
+// The channel is buffered so that a slow consumer does not block the migration.  If the
+// buffer is full then the event is dropped rather than waiting for the consumer.
+func (m *migrationHelper) WatchMigrationProgress(ctx context.Context) (<-chan MigrationProgressEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make(chan MigrationProgressEvent, progressChanSize)
+	m.progress.lock.Lock()
+	if m.progress.watchers == nil {
+		m.progress.watchers = map[chan MigrationProgressEvent]struct{}{}
+	}
+	m.progress.watchers[events] = struct{}{}
+	m.progress.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.progress.lock.Lock()
+		defer m.progress.lock.Unlock()
+		delete(m.progress.watchers, events)
+		close(events)
+	}()
+	return events, nil
+}
+
+// reportProgress sends a MigrationProgressEvent to each of the progress watchers.
+func (m *migrationHelper) reportProgress(resourceType, name string, result MigrationResult, duration time.Duration) {
+	m.progress.lock.Lock()
+	defer m.progress.lock.Unlock()
+	if len(m.progress.watchers) == 0 {
+		return
+	}
+
+	event := MigrationProgressEvent{
+		ResourceType: resourceType,
+		ResourceName: name,
+		Result:       result,
+		Duration:     duration,
+		Timestamp:    time.Now(),
+	}
+	for events := range m.progress.watchers {
+		select {
+		case events <- event:
+		default:
+			log.WithField("Resource", name).Debug("Migration progress channel is full, dropping event")
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+	"github.com/projectcalico/libcalico-go/lib/upgrade/converters"
+)
+
+var _ = Describe("Test migration progress", func() {
+	profileKVP := func(name string, labels map[string]string) *model.KVPair {
+		return &model.KVPair{
+			Key: model.ProfileKey{Name: name},
+			Value: &model.Profile{
+				Rules: model.ProfileRules{
+					InboundRules: []model.Rule{converters.V1ModelInRule1},
+				},
+				Tags:   []string{},
+				Labels: labels,
+			},
+		}
+	}
+	ipPoolKVP := func(cidr string) *model.KVPair {
+		c := net.MustParseCIDR(cidr)
+		return &model.KVPair{
+			Key:   model.IPPoolKey{CIDR: c},
+			Value: &model.IPPool{CIDR: c, IPAM: true},
+		}
+	}
+
+	// drain returns the events that have been sent on the channel.
+	drain := func(events <-chan MigrationProgressEvent) []MigrationProgressEvent {
+		received := []MigrationProgressEvent{}
+		for {
+			select {
+			case e := <-events:
+				received = append(received, e)
+			default:
+				return received
+			}
+		}
+	}
+
+	It("should send an event for each converted and skipped resource", func() {
+		clientv1 := fakeClientV1{kvps: []*model.KVPair{
+			profileKVP("profile1", map[string]string{"tier": "web"}),
+			profileKVP("profile2", nil),
+		}}
+		mh := New(nil, clientv1, nil, WithLabelFilter("has(tier)")).(*migrationHelper)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, err := mh.WatchMigrationProgress(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = mh.queryAndConvertResources()
+		Expect(err).NotTo(HaveOccurred())
+
+		results := map[string]MigrationResult{}
+		for _, e := range drain(events) {
+			if e.ResourceType != ResourceTypeProfile {
+				continue
+			}
+			Expect(e.Timestamp.IsZero()).To(BeFalse())
+			results[e.ResourceName] = e.Result
+		}
+		Expect(results).To(Equal(map[string]MigrationResult{
+			model.ProfileKey{Name: "profile1"}.String(): MigrationResultConverted,
+			model.ProfileKey{Name: "profile2"}.String(): MigrationResultSkipped,
+		}))
+	})
+
+	It("should send a failed event for each overlapping IPPool", func() {
+		clientv1 := fakeClientV1{kvps: []*model.KVPair{
+			ipPoolKVP("10.0.0.0/16"),
+			ipPoolKVP("10.0.1.0/24"),
+		}}
+		mh := New(nil, clientv1, nil).(*migrationHelper)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, err := mh.WatchMigrationProgress(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		data := &MigrationData{}
+		Expect(mh.queryAndConvertResourceType(data, ResourceTypeIPPool)).To(Succeed())
+
+		received := drain(events)
+		Expect(received).To(HaveLen(2))
+		for _, e := range received {
+			Expect(e.ResourceType).To(Equal(ResourceTypeIPPool))
+			Expect(e.Result).To(Equal(MigrationResultFailed))
+		}
+	})
+
+	It("should not block the migration when the consumer is slow", func() {
+		clientv1 := fakeClientV1{}
+		for i := 0; i < progressChanSize+10; i++ {
+			clientv1.kvps = append(clientv1.kvps, profileKVP(fmt.Sprintf("profile%d", i), nil))
+		}
+		mh := New(nil, clientv1, nil).(*migrationHelper)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, err := mh.WatchMigrationProgress(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		data := &MigrationData{}
+		Expect(mh.queryAndConvertResourceType(data, ResourceTypeProfile)).To(Succeed())
+		Expect(data.Resources).To(HaveLen(progressChanSize + 10))
+		Expect(drain(events)).To(HaveLen(progressChanSize))
+	})
+
+	It("should close the channel when the context is cancelled", func() {
+		mh := New(nil, fakeClientV1{}, nil).(*migrationHelper)
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := mh.WatchMigrationProgress(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cancel()
+		Eventually(events).Should(BeClosed())
+
+		// Further progress is not sent to the closed channel.
+		mh.reportProgress(ResourceTypeProfile, "profile1", MigrationResultConverted, 0)
+	})
+
+	It("should fail if the context is already cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := New(nil, fakeClientV1{}, nil).WatchMigrationProgress(ctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,100 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+)
+
+// MigrationState tracks the v3 resources created by the migration helper, so that a
+// partial migration can be rolled back.
+type MigrationState struct {
+	lock    sync.Mutex
+	created []model.Key
+}
+
+// Created returns the keys of the v3 resources created by the migration, in the order in
+// which they were created.
+func (s *MigrationState) Created() []model.Key {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]model.Key{}, s.created...)
+}
+
+func (s *MigrationState) recordCreated(key model.Key) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.created = append(s.created, key)
+}
+
+func (s *MigrationState) setCreated(keys []model.Key) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.created = keys
+}
+
+// WithDryRunRollback controls whether RollbackMigration deletes the v3 resources. When
+// enabled, the resources that would be deleted are only reported.
+func WithDryRunRollback(enabled bool) Option {
+	return func(m *migrationHelper) {
+		m.dryRunRollback = enabled
+	}
+}
+
+// MigrationState returns the state of the migration performed by this helper.
+func (m *migrationHelper) MigrationState() *MigrationState {
+	return &m.state
+}
+
+// RollbackMigration deletes the v3 resources created by this helper, in the reverse order
+// in which they were created, and then clears the MigrationState. Resources that already
+// existed, and were updated by the migration, are not deleted since their previous values
+// are not known. If a resource cannot be deleted, the resources that have not yet been
+// deleted remain in the MigrationState so that the rollback may be retried.
+func (m *migrationHelper) RollbackMigration(ctx context.Context) error {
+	keys := m.state.Created()
+	if m.dryRunRollback {
+		m.status("Dry run: the rollback would delete %d v3 resources", len(keys))
+		for i := len(keys) - 1; i >= 0; i-- {
+			m.statusBullet("would delete %s", keys[i])
+		}
+		return nil
+	}
+
+	m.status("Rolling back the migration: deleting %d v3 resources", len(keys))
+	bc := m.clientv3.(backendClientAccessor).Backend()
+	for i := len(keys) - 1; i >= 0; i-- {
+		if _, err := bc.Delete(ctx, keys[i], ""); err != nil {
+			if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
+				log.WithError(err).WithField("Key", keys[i]).Info("Failed to delete resource")
+				m.statusError("Unable to delete %s", keys[i])
+				m.statusBullet("cause: %v", err)
+				m.state.setCreated(keys[:i+1])
+				return fmt.Errorf("unable to delete %s: %v", keys[i], err)
+			}
+			log.WithField("Key", keys[i]).Debug("Resource already deleted")
+		}
+	}
+	m.state.setCreated(nil)
+	m.statusBullet("success: migrated v3 resources deleted")
+	return nil
+}
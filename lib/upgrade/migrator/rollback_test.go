@@ -0,0 +1,137 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bapi "github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+// fakeBackend stores the KVPairs created by the migration helper. Only the methods used
+// by the migration helper are implemented.
+type fakeBackend struct {
+	bapi.Client
+	kvps map[string]*model.KVPair
+}
+
+func (b *fakeBackend) Create(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	if _, ok := b.kvps[kvp.Key.String()]; ok {
+		return nil, cerrors.ErrorResourceAlreadyExists{Identifier: kvp.Key}
+	}
+	b.kvps[kvp.Key.String()] = kvp
+	return kvp, nil
+}
+
+func (b *fakeBackend) Get(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+	kvp, ok := b.kvps[key.String()]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+	}
+	return kvp, nil
+}
+
+func (b *fakeBackend) Update(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	b.kvps[kvp.Key.String()] = kvp
+	return kvp, nil
+}
+
+func (b *fakeBackend) Delete(ctx context.Context, key model.Key, revision string) (*model.KVPair, error) {
+	kvp, ok := b.kvps[key.String()]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+	}
+	delete(b.kvps, key.String())
+	return kvp, nil
+}
+
+func (b *fakeBackend) List(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error) {
+	l := &model.KVPairList{}
+	for _, kvp := range b.kvps {
+		if p, err := model.KeyToDefaultPath(kvp.Key); err == nil && list.KeyFromDefaultPath(p) != nil {
+			l.KVPairs = append(l.KVPairs, kvp)
+		}
+	}
+	return l, nil
+}
+
+// fakeClientV3 provides access to a fakeBackend.
+type fakeClientV3 struct {
+	clientv3.Interface
+	backend bapi.Client
+}
+
+func (c fakeClientV3) Backend() bapi.Client {
+	return c.backend
+}
+
+var _ = Describe("Test rolling back a migration", func() {
+	var be *fakeBackend
+	var clientv1 fakeClientV1
+	BeforeEach(func() {
+		be = &fakeBackend{kvps: map[string]*model.KVPair{}}
+		clientv1 = fakeClientV1{}
+		for _, cidr := range []string{"10.0.0.0/16", "10.1.0.0/16", "10.2.0.0/16"} {
+			c := net.MustParseCIDR(cidr)
+			clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+				Key:   model.IPPoolKey{CIDR: c},
+				Value: &model.IPPool{CIDR: c, IPAM: true},
+			})
+		}
+	})
+
+	It("should delete the migrated resources", func() {
+		mh := New(fakeClientV3{backend: be}, clientv1, nil)
+		_, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(be.kvps).To(HaveLen(3))
+		Expect(mh.MigrationState().Created()).To(HaveLen(3))
+
+		Expect(mh.RollbackMigration(context.Background())).To(Succeed())
+		Expect(be.kvps).To(BeEmpty())
+		Expect(mh.MigrationState().Created()).To(BeEmpty())
+	})
+
+	It("should not delete resources that existed before the migration", func() {
+		existing := &model.KVPair{Key: model.ResourceKey{Kind: "IPPool", Name: "10-1-0-0-16"}}
+		be.kvps[existing.Key.String()] = existing
+
+		mh := New(fakeClientV3{backend: be}, clientv1, nil)
+		_, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mh.MigrationState().Created()).To(HaveLen(2))
+
+		Expect(mh.RollbackMigration(context.Background())).To(Succeed())
+		Expect(be.kvps).To(HaveLen(1))
+		Expect(be.kvps).To(HaveKey(existing.Key.String()))
+	})
+
+	It("should only report the resources to delete in a dry run", func() {
+		mh := New(fakeClientV3{backend: be}, clientv1, nil, WithDryRunRollback(true))
+		_, err := mh.MigrateResourceType(context.Background(), ResourceTypeIPPool)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(mh.RollbackMigration(context.Background())).To(Succeed())
+		Expect(be.kvps).To(HaveLen(3))
+		Expect(mh.MigrationState().Created()).To(HaveLen(3))
+	})
+})
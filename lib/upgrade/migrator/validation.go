@@ -0,0 +1,120 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ValidationReport lists, for each resource type, the v1 resources that will fail to
+// migrate.  It is returned by DryValidate.
+type ValidationReport struct {
+	// A report for each resource type, in the order in which the types are migrated.
+	Reports []MigrationReport
+}
+
+// AllPassed returns true if every v1 resource can be migrated.
+func (r *ValidationReport) AllPassed() bool {
+	for i := range r.Reports {
+		if r.Reports[i].HasErrors() {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText writes a human readable summary of the report to w, listing each v1 resource
+// that will fail to migrate and the reason why.
+func (r *ValidationReport) WriteText(w io.Writer) error {
+	failed := 0
+	for _, report := range r.Reports {
+		numErrs := len(report.ConversionErrors) + len(report.ConvertedResourceValidationErrors) + len(report.NameClashes)
+		failed += numErrs
+		if _, err := fmt.Fprintf(w, "%s: %d converted, %d failed\n",
+			report.ResourceType, len(report.Resources)+len(report.IPAM), numErrs); err != nil {
+			return err
+		}
+		for _, e := range report.ConversionErrors {
+			if _, err := fmt.Fprintf(w, "-  unable to convert %s: %v\n", e.KeyV1, e.Cause); err != nil {
+				return err
+			}
+		}
+		for _, e := range report.ConvertedResourceValidationErrors {
+			if _, err := fmt.Fprintf(w, "-  %s converts to an invalid %s: %v\n", e.KeyV1, e.KeyV3, e.Cause); err != nil {
+				return err
+			}
+		}
+		for _, c := range report.NameClashes {
+			if _, err := fmt.Fprintf(w, "-  %s and %s both convert to %s\n", c.KeyV1, c.OtherKeyV1, c.KeyV3); err != nil {
+				return err
+			}
+		}
+	}
+
+	var err error
+	if failed == 0 {
+		_, err = fmt.Fprintln(w, "All v1 resources passed validation")
+	} else {
+		_, err = fmt.Fprintf(w, "%d v1 resource(s) failed validation\n", failed)
+	}
+	return err
+}
+
+// DryValidate runs the conversion of each resource type, and of the IPAM data, without
+// writing anything to the v3 datastore, and returns a ValidationReport of the v1 resources
+// that will fail to migrate: those that cannot be converted, those that convert to an
+// invalid v3 resource, and those whose converted names clash.  An error is only returned if
+// the v1 resources cannot be queried.
+func (m *migrationHelper) DryValidate(ctx context.Context) (*ValidationReport, error) {
+	m.status("Validating conversion of v1 data to v3 (dry run)")
+	report := &ValidationReport{}
+	for _, rt := range ResourceTypes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r := MigrationReport{ResourceType: rt}
+		if err := m.queryAndConvertResourceType(&r.MigrationData, rt); err != nil {
+			m.statusError("Unable to query and convert the v1 %s resources", rt)
+			m.statusBullet("cause: %v", err)
+			return nil, MigrationError{
+				Type: ErrorGeneric,
+				Err:  fmt.Errorf("error converting %s data: %v", rt, err),
+			}
+		}
+		report.Reports = append(report.Reports, r)
+	}
+
+	if !m.clientv1.IsKDD() {
+		r := MigrationReport{ResourceType: ResourceTypeIPAM}
+		if err := m.queryAndConvertIPAMData(&r.MigrationData); err != nil {
+			m.statusError("Unable to query and convert the v1 IPAM data")
+			m.statusBullet("cause: %v", err)
+			return nil, MigrationError{
+				Type: ErrorGeneric,
+				Err:  fmt.Errorf("error converting IPAM data: %v", err),
+			}
+		}
+		report.Reports = append(report.Reports, r)
+	}
+
+	if report.AllPassed() {
+		m.status("Dry run: all v1 resources passed validation")
+	} else {
+		m.statusError("Dry run: some v1 resources failed validation, resolve issues before starting upgrade")
+	}
+	return report, nil
+}
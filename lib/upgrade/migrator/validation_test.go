@@ -0,0 +1,115 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrator
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/net"
+)
+
+var _ = Describe("Test dry run validation", func() {
+	missingHandle := "missing"
+	ipPools := func(cidrs ...string) fakeClientV1 {
+		clientv1 := fakeClientV1{}
+		for _, cidr := range cidrs {
+			c := net.MustParseCIDR(cidr)
+			clientv1.kvps = append(clientv1.kvps, &model.KVPair{
+				Key:   model.IPPoolKey{CIDR: c},
+				Value: &model.IPPool{CIDR: c, IPAM: true},
+			})
+		}
+		return clientv1
+	}
+
+	It("should pass valid resources without writing them", func() {
+		be := &fakeBackend{kvps: map[string]*model.KVPair{}}
+		mh := New(fakeClientV3{backend: be}, ipPools("10.0.0.0/16", "10.1.0.0/16"), nil)
+		report, err := mh.DryValidate(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.AllPassed()).To(BeTrue())
+		Expect(report.Reports).To(HaveLen(len(ResourceTypes) + 1))
+		Expect(be.kvps).To(BeEmpty())
+
+		var buf bytes.Buffer
+		Expect(report.WriteText(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("IPPool: 2 converted, 0 failed\n"))
+		Expect(buf.String()).To(HaveSuffix("All v1 resources passed validation\n"))
+	})
+
+	It("should report the resources that fail conversion", func() {
+		mh := New(nil, ipPools("10.0.0.0/16", "10.0.0.0/24"), nil)
+		report, err := mh.DryValidate(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.AllPassed()).To(BeFalse())
+
+		var buf bytes.Buffer
+		Expect(report.WriteText(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("IPPool: 0 converted, 1 failed\n"))
+		Expect(buf.String()).To(ContainSubstring("-  unable to convert"))
+		Expect(buf.String()).To(HaveSuffix("1 v1 resource(s) failed validation\n"))
+	})
+
+	It("should report the IPAM blocks that fail conversion", func() {
+		clientv1 := ipPools("10.0.0.0/16")
+		clientv1.kvps = append(clientv1.kvps,
+			&model.KVPair{Key: model.IPAMHandleKey{HandleID: "handle1"}, Value: &model.IPAMHandle{HandleID: "handle1"}},
+			&model.KVPair{
+				Key: model.BlockKey{CIDR: net.MustParseNetwork("10.0.0.0/26")},
+				Value: &model.AllocationBlock{
+					CIDR:       net.MustParseNetwork("10.0.0.0/26"),
+					Attributes: []model.AllocationAttribute{{AttrPrimary: &missingHandle}},
+				},
+			},
+		)
+		report, err := New(nil, clientv1, nil).DryValidate(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.AllPassed()).To(BeFalse())
+
+		var buf bytes.Buffer
+		Expect(report.WriteText(&buf)).To(Succeed())
+		Expect(buf.String()).To(ContainSubstring("IPAM: 1 converted, 1 failed\n"))
+	})
+
+	It("should report the BGP communities stored in custom keys", func() {
+		clientv1 := fakeClientV1{kvps: []*model.KVPair{
+			{Key: model.GlobalBGPConfigKey{Name: "AsNumber"}, Value: "64512"},
+			{Key: model.GlobalBGPConfigKey{Name: "communities/no-export"}, Value: "65535:65281"},
+		}}
+		report, err := New(nil, clientv1, nil).DryValidate(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.AllPassed()).To(BeFalse())
+
+		for _, r := range report.Reports {
+			if r.ResourceType == ResourceTypeBGPConfiguration {
+				Expect(r.Resources).To(BeEmpty())
+				Expect(r.ConversionErrors).To(HaveLen(1))
+				Expect(r.ConversionErrors[0].KeyV1).To(Equal(model.GlobalBGPConfigKey{Name: "communities/no-export"}))
+			}
+		}
+	})
+
+	It("should stop when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := New(nil, ipPools("10.0.0.0/16"), nil).DryValidate(ctx)
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
@@ -15,6 +15,7 @@
 package v3
 
 import (
+	goerrors "errors"
 	"fmt"
 	"net"
 	"reflect"
@@ -45,6 +46,9 @@ const (
 	totalAnnotationSizeLimitB int64 = 256 * (1 << 10) // 256 kB
 
 	globalSelector = "global()"
+
+	// The maximum length of a Linux interface name (IFNAMSIZ less the terminating NUL).
+	maxInterfaceNameLength = 15
 )
 
 var (
@@ -73,6 +77,7 @@ var (
 	globalSelectorRegex = regexp.MustCompile(fmt.Sprintf(`%v global\(\)|global\(\) %v`, andOr, andOr))
 
 	interfaceRegex        = regexp.MustCompile("^[a-zA-Z0-9_.-]{1,15}$")
+	interfaceCharsRegex   = regexp.MustCompile("^[a-zA-Z0-9_.-]*$")
 	ifaceFilterRegex      = regexp.MustCompile("^[a-zA-Z0-9:._+-]{1,15}$")
 	actionRegex           = regexp.MustCompile("^(Allow|Deny|Log|Pass)$")
 	protocolRegex         = regexp.MustCompile("^(TCP|UDP|ICMP|ICMPv6|SCTP|UDPLite)$")
@@ -95,6 +100,7 @@ var (
 	poolUnstictCIDR       = "IP pool CIDR is not strictly masked"
 	overlapsV4LinkLocal   = "IP pool range overlaps with IPv4 Link Local range 169.254.0.0/16"
 	overlapsV6LinkLocal   = "IP pool range overlaps with IPv6 Link Local range fe80::/10"
+	poolIsBroadcast       = "IP pool CIDR must not be the IPv4 broadcast address 255.255.255.255"
 	protocolPortsMsg      = "rules that specify ports must set protocol to TCP or UDP or SCTP"
 	protocolIcmpMsg       = "rules that specify ICMP fields must set protocol to ICMP"
 	protocolAndHTTPMsg    = "rules that specify HTTP fields must set protocol to TCP or empty"
@@ -670,22 +676,24 @@ func validateIPNAT(structLevel validator.StructLevel) {
 	i := structLevel.Current().Interface().(libapi.IPNAT)
 	log.Debugf("Internal IP: %s; External IP: %s", i.InternalIP, i.ExternalIP)
 
-	iip, _, err := cnet.ParseCIDROrIP(i.InternalIP)
-	if err != nil {
-		structLevel.ReportError(reflect.ValueOf(i.ExternalIP),
+	iip, _, ierr := cnet.ParseCIDROrIP(i.InternalIP)
+	if ierr != nil {
+		structLevel.ReportError(reflect.ValueOf(i.InternalIP),
 			"InternalIP", "", reason("invalid IP address"), "")
 	}
 
-	eip, _, err := cnet.ParseCIDROrIP(i.ExternalIP)
-	if err != nil {
+	eip, _, eerr := cnet.ParseCIDROrIP(i.ExternalIP)
+	if eerr != nil {
 		structLevel.ReportError(reflect.ValueOf(i.ExternalIP),
-			"InternalIP", "", reason("invalid IP address"), "")
+			"ExternalIP", "", reason("invalid IP address"), "")
 	}
 
-	// An IPNAT must have both the internal and external IP versions the same.
-	if iip.Version() != eip.Version() {
-		structLevel.ReportError(reflect.ValueOf(i.ExternalIP),
-			"ExternalIP", "", reason("mismatched IP versions"), "")
+	// An IPNAT must have both the internal and external IP versions the same.  This is
+	// a property of the NAT as a whole, so the error is reported against the IPNAT
+	// rather than either of its IPs.
+	if ierr == nil && eerr == nil && iip.Version() != eip.Version() {
+		structLevel.ReportError(reflect.ValueOf(i),
+			"", "IPNAT", reason("mismatched IP versions"), "")
 	}
 }
 
@@ -760,18 +768,36 @@ func validateFelixConfigSpec(structLevel validator.StructLevel) {
 func validateWorkloadEndpointSpec(structLevel validator.StructLevel) {
 	w := structLevel.Current().Interface().(libapi.WorkloadEndpointSpec)
 
+	// The interface name must be a valid Linux interface name.  Each limit is reported
+	// separately so that the reason for the failure is clear.
+	switch {
+	case w.InterfaceName == "":
+		structLevel.ReportError(reflect.ValueOf(w.InterfaceName),
+			"InterfaceName", "", reason("interface name must be specified"), "")
+	case len(w.InterfaceName) > maxInterfaceNameLength:
+		structLevel.ReportError(reflect.ValueOf(w.InterfaceName),
+			"InterfaceName", "", reason(fmt.Sprintf("interface name must be at most %d characters", maxInterfaceNameLength)), "")
+	}
+	if !interfaceCharsRegex.MatchString(w.InterfaceName) {
+		structLevel.ReportError(reflect.ValueOf(w.InterfaceName),
+			"InterfaceName", "", reason("interface name may only contain letters, digits, '_', '-' and '.'"), "")
+	}
+
 	// The configured networks only support /32 (for IPv4) and /128 (for IPv6) at present.
-	for _, netw := range w.IPNetworks {
+	// The index of each network is included in the field name so that the failing entry
+	// can be identified.
+	for i, netw := range w.IPNetworks {
+		fieldName := fmt.Sprintf("IPNetworks[%d]", i)
 		_, nw, err := cnet.ParseCIDROrIP(netw)
 		if err != nil {
 			structLevel.ReportError(reflect.ValueOf(netw),
-				"IPNetworks", "", reason("invalid CIDR"), "")
+				fieldName, "IPNetworks", reason("invalid CIDR"), "")
+			continue
 		}
 
-		ones, bits := nw.Mask.Size()
-		if bits != ones {
-			structLevel.ReportError(reflect.ValueOf(w.IPNetworks),
-				"IPNetworks", "", reason("IP network contains multiple addresses"), "")
+		if !nw.IsHostRoute() {
+			structLevel.ReportError(reflect.ValueOf(netw),
+				fieldName, "IPNetworks", reason("IP network contains multiple addresses"), "")
 		}
 	}
 
@@ -799,37 +825,66 @@ func validateWorkloadEndpointSpec(structLevel validator.StructLevel) {
 
 	// If NATs have been specified, then they should each be within the configured networks of
 	// the endpoint.
-	if len(w.IPNATs) > 0 {
+	for i, nat := range w.IPNATs {
+		fieldName := fmt.Sprintf("IPNATs[%d].InternalIP", i)
+		_, natCidr, err := cnet.ParseCIDROrIP(nat.InternalIP)
+		if err != nil {
+			structLevel.ReportError(reflect.ValueOf(nat.InternalIP),
+				fieldName, "IPNATs", reason("invalid InternalIP CIDR"), "")
+			break
+		}
+		// Check each NAT to ensure it is within the configured networks.  If any
+		// are not then exit without further checks.
 		valid := false
-		for _, nat := range w.IPNATs {
-			_, natCidr, err := cnet.ParseCIDROrIP(nat.InternalIP)
+		for _, cidr := range w.IPNetworks {
+			_, nw, err := cnet.ParseCIDROrIP(cidr)
 			if err != nil {
-				structLevel.ReportError(reflect.ValueOf(nat.InternalIP),
-					"IPNATs", "", reason("invalid InternalIP CIDR"), "")
+				// Invalid networks have already been reported above.
+				continue
 			}
-			// Check each NAT to ensure it is within the configured networks.  If any
-			// are not then exit without further checks.
-			valid = false
-			for _, cidr := range w.IPNetworks {
-				_, nw, err := cnet.ParseCIDROrIP(cidr)
-				if err != nil {
-					structLevel.ReportError(reflect.ValueOf(cidr),
-						"IPNetworks", "", reason("invalid CIDR"), "")
-				}
 
-				if nw.Contains(natCidr.IP) {
-					valid = true
-					break
-				}
-			}
-			if !valid {
+			if nw.Contains(natCidr.IP) {
+				valid = true
 				break
 			}
 		}
-
 		if !valid {
-			structLevel.ReportError(reflect.ValueOf(w.IPNATs),
-				"IPNATs", "", reason("NAT is not in the endpoint networks"), "")
+			structLevel.ReportError(reflect.ValueOf(nat.InternalIP),
+				fieldName, "IPNATs", reason("NAT is not in the endpoint networks"), "")
+			break
+		}
+	}
+
+	// Each NAT must have a unique internal IP.  This also rejects any NAT that is specified
+	// more than once.
+	natsByInternalIP := map[string]libapi.IPNAT{}
+	for i, nat := range w.IPNATs {
+		intIP := cnet.ParseIP(nat.InternalIP)
+		if intIP == nil {
+			// Invalid IPs are reported by the IPNAT validation.
+			continue
+		}
+		if other, ok := natsByInternalIP[intIP.String()]; ok {
+			r := "InternalIP is used by multiple NATs"
+			extIP, otherExtIP := cnet.ParseIP(nat.ExternalIP), cnet.ParseIP(other.ExternalIP)
+			if extIP != nil && otherExtIP != nil && extIP.Equal(*otherExtIP) {
+				r = "duplicate NAT"
+			}
+			structLevel.ReportError(reflect.ValueOf(nat.InternalIP),
+				fmt.Sprintf("IPNATs[%d].InternalIP", i), "IPNATs", reason(r), "")
+			continue
+		}
+		natsByInternalIP[intIP.String()] = nat
+	}
+
+	// Profiles are applied in order, so a profile listed more than once is ambiguous.  Each
+	// duplicated name is only reported once.
+	profileCounts := map[string]int{}
+	for _, p := range w.Profiles {
+		profileCounts[p]++
+		if profileCounts[p] == 2 {
+			structLevel.ReportError(reflect.ValueOf(w.Profiles),
+				"Profiles", "", reason(fmt.Sprintf("duplicate profile name %q", p)), "")
 		}
 	}
 }
@@ -859,12 +914,17 @@ func validateIPPoolSpec(structLevel validator.StructLevel) {
 	}
 
 	// Make sure the CIDR is parsable.
-	ipAddr, cidr, err := cnet.ParseCIDROrIP(pool.CIDR)
+	n, err := cnet.ParseIPNet(pool.CIDR)
 	if err != nil {
+		msg := "IPPool CIDR must be a valid subnet"
+		if goerrors.Is(err, cnet.ErrMissingPrefixLen) {
+			msg = "IPPool CIDR must have a valid prefix length"
+		}
 		structLevel.ReportError(reflect.ValueOf(pool.CIDR),
-			"IPpool.CIDR", "", reason("IPPool CIDR must be a valid subnet"), "")
+			"IPpool.CIDR", "", reason(msg), "")
 		return
 	}
+	ipAddr, cidr := &cnet.IP{IP: n.IP}, n.Network()
 
 	// Normalize the CIDR before persisting.
 	pool.CIDR = cidr.String()
@@ -914,6 +974,12 @@ func validateIPPoolSpec(structLevel validator.StructLevel) {
 			"IPpool.CIDR", "", reason(poolUnstictCIDR), "")
 	}
 
+	// A single address IPv4 pool must not be the limited broadcast address.
+	if ones, bits := cidr.Mask.Size(); cidr.Version() == 4 && ones == bits && cidr.IP.Equal(net.IPv4bcast) {
+		structLevel.ReportError(reflect.ValueOf(pool.CIDR),
+			"IPpool.CIDR", "", reason(poolIsBroadcast), "")
+	}
+
 	// IPv4 link local subnet.
 	ipv4LinkLocalNet := net.IPNet{
 		IP:   net.ParseIP("169.254.0.0"),
@@ -714,6 +714,15 @@ func init() {
 					{InternalIP: ipv6_1, ExternalIP: ipv6_2},
 				},
 			}, true),
+		Entry("should accept workload endpoint with multiple NATs that have unique internal IPs",
+			libapiv3.WorkloadEndpointSpec{
+				InterfaceName: "cali012371237",
+				IPNetworks:    []string{netv4_1, netv4_2},
+				IPNATs: []libapiv3.IPNAT{
+					{InternalIP: ipv4_1, ExternalIP: "172.16.0.1"},
+					{InternalIP: "1.2.0.0", ExternalIP: "172.16.0.2"},
+				},
+			}, true),
 		Entry("should accept workload endpoint with mixed-case ContainerID",
 			libapiv3.WorkloadEndpointSpec{
 				InterfaceName: "cali012371237",
@@ -747,6 +756,25 @@ func init() {
 				IPNetworks:    []string{netv6_1},
 				IPNATs:        []libapiv3.IPNAT{{InternalIP: ipv6_2, ExternalIP: ipv6_1}},
 			}, false),
+		Entry("should reject workload endpoint with an internal IP used by multiple NATs",
+			libapiv3.WorkloadEndpointSpec{
+				InterfaceName: "cali012371237",
+				IPNetworks:    []string{netv4_1, netv4_2},
+				IPNATs: []libapiv3.IPNAT{
+					{InternalIP: ipv4_1, ExternalIP: "172.16.0.1"},
+					{InternalIP: ipv4_1, ExternalIP: "172.16.0.2"},
+					{InternalIP: "1.2.0.0", ExternalIP: "172.16.0.3"},
+				},
+			}, false),
+		Entry("should reject workload endpoint with a duplicate NAT",
+			libapiv3.WorkloadEndpointSpec{
+				InterfaceName: "cali012371237",
+				IPNetworks:    []string{netv6_1},
+				IPNATs: []libapiv3.IPNAT{
+					{InternalIP: ipv6_1, ExternalIP: ipv6_2},
+					{InternalIP: "aabb:aabb:0::ffff", ExternalIP: "aabb:0::abcd"},
+				},
+			}, false),
 		Entry("should reject workload endpoint containerID that starts with a dash",
 			libapiv3.WorkloadEndpointSpec{
 				InterfaceName: "cali0134",
@@ -851,6 +879,8 @@ func init() {
 		Entry("should reject IP pool with IPv6 CIDR /128", api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: netv6_1}}, false),
 		Entry("should reject IP pool with IPv4 CIDR /33", api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "1.2.3.4/33"}}, false),
 		Entry("should reject IP pool with IPv6 CIDR /129", api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "aa:bb::/129"}}, false),
+		Entry("should reject IP pool with a missing prefix length", api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "1.2.3.0/"}}, false),
+		Entry("should reject IP pool with an invalid CIDR", api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "1.2.3.a/24"}}, false),
 		Entry("should reject IPIPMode 'Always' for IPv6 pool",
 			api.IPPool{
 				ObjectMeta: v1.ObjectMeta{Name: "pool.name"},
@@ -873,6 +903,10 @@ func init() {
 			api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "169.254.5.0/24"}}, false),
 		Entry("should reject IPv6 pool with a CIDR range overlapping with Link Local range",
 			api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "fe80::/120"}}, false),
+		Entry("should reject IPv4 pool that is the broadcast address",
+			api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "255.255.255.255/32", BlockSize: 32}}, false),
+		Entry("should accept a single address IPv4 pool",
+			api.IPPool{ObjectMeta: v1.ObjectMeta{Name: "pool.name"}, Spec: api.IPPoolSpec{CIDR: "10.0.0.255/32", BlockSize: 32}}, true),
 
 		// (API) IPIPMode
 		Entry("should accept IPPool with no IPIP mode specified", api.IPPoolSpec{CIDR: "1.2.3.0/24"}, true),
@@ -0,0 +1,152 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v9"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	libapi "github.com/projectcalico/libcalico-go/lib/apis/v3"
+)
+
+// FieldValidationError identifies a single field of a resource that failed validation.
+type FieldValidationError struct {
+	// Field is the JSON path of the field, e.g. "spec.ipNetworks[2]".
+	Field string
+
+	// Value is the value of the field.
+	Value interface{}
+
+	// Reason describes why the field is not valid.
+	Reason string
+}
+
+// FieldValidationErrors is the error returned by ValidateWorkloadEndpoint.  It contains
+// an entry for each field that failed validation.
+type FieldValidationErrors []FieldValidationError
+
+func (e FieldValidationErrors) Error() string {
+	return e.ErrorList().ToAggregate().Error()
+}
+
+// ErrorList returns the validation errors in the Kubernetes field.ErrorList format.
+func (e FieldValidationErrors) ErrorList() field.ErrorList {
+	errs := field.ErrorList{}
+	for _, f := range e {
+		errs = append(errs, &field.Error{
+			Type:     field.ErrorTypeInvalid,
+			Field:    f.Field,
+			BadValue: f.Value,
+			Detail:   f.Reason,
+		})
+	}
+	return errs
+}
+
+// ValidateWorkloadEndpoint validates the supplied WorkloadEndpoint.  Unlike Validate, the
+// returned error is a FieldValidationErrors that identifies each failing field using its
+// JSON path, including the index of any failing list entry.
+func ValidateWorkloadEndpoint(wep *libapi.WorkloadEndpoint) error {
+	err := validate.Struct(wep)
+	if err == nil {
+		return nil
+	}
+
+	var errs FieldValidationErrors
+	for _, f := range err.(validator.ValidationErrors) {
+		errs = append(errs, FieldValidationError{
+			Field:  jsonPath(reflect.TypeOf(wep), f.Namespace()).String(),
+			Value:  f.Value(),
+			Reason: strings.TrimPrefix(extractReason(f), reasonString),
+		})
+	}
+	return errs
+}
+
+// jsonPath converts the validator namespace of a field (e.g. "WorkloadEndpoint.Spec.IPNetworks[2]")
+// into the JSON path of the field within the supplied type (e.g. "spec.ipNetworks[2]").
+func jsonPath(t reflect.Type, namespace string) *field.Path {
+	var path *field.Path
+	child := func(name string) {
+		if path == nil {
+			path = field.NewPath(name)
+		} else {
+			path = path.Child(name)
+		}
+	}
+
+	// The first segment of the namespace is the name of the top level type.
+	parts := strings.Split(namespace, ".")
+	for _, part := range parts[1:] {
+		name := part
+		var indexes []string
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			indexes = strings.Split(strings.TrimSuffix(part[i+1:], "]"), "][")
+		}
+
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				t = f.Type
+				jsonName := strings.Split(f.Tag.Get("json"), ",")[0]
+				if jsonName == "" && !f.Anonymous {
+					jsonName = name
+				}
+				if jsonName != "" {
+					child(jsonName)
+				}
+			} else if len(indexes) == 0 {
+				// Some struct validators qualify the names of the fields they report
+				// (e.g. "Metadata.Name" within the ObjectMeta), so skip over any
+				// segment that is not a field of the struct.
+				continue
+			} else {
+				t = nil
+				child(name)
+			}
+		} else {
+			t = nil
+			child(name)
+		}
+
+		for _, idx := range indexes {
+			for t != nil && t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t != nil && t.Kind() == reflect.Map {
+				path = path.Key(idx)
+				t = t.Elem()
+			} else if i, err := strconv.Atoi(idx); err == nil {
+				path = path.Index(i)
+				if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+					t = t.Elem()
+				} else {
+					t = nil
+				}
+			} else {
+				path = path.Key(idx)
+				t = nil
+			}
+		}
+	}
+	return path
+}
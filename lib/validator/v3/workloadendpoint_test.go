@@ -0,0 +1,206 @@
+// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3_test
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	libapiv3 "github.com/projectcalico/libcalico-go/lib/apis/v3"
+	v3 "github.com/projectcalico/libcalico-go/lib/validator/v3"
+)
+
+var _ = Describe("WorkloadEndpoint field path validation", func() {
+	newWEP := func() *libapiv3.WorkloadEndpoint {
+		wep := libapiv3.NewWorkloadEndpoint()
+		wep.Name = "node1-k8s-pod1-eth0"
+		wep.Namespace = "default"
+		wep.Spec = libapiv3.WorkloadEndpointSpec{
+			Node:          "node1",
+			Orchestrator:  "k8s",
+			Pod:           "pod1",
+			Endpoint:      "eth0",
+			InterfaceName: "cali0123",
+			IPNetworks:    []string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"},
+		}
+		return wep
+	}
+
+	It("should accept a valid WorkloadEndpoint", func() {
+		Expect(v3.ValidateWorkloadEndpoint(newWEP())).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("should report the JSON path of the invalid field",
+		func(update func(wep *libapiv3.WorkloadEndpoint), expectedField string) {
+			wep := newWEP()
+			update(wep)
+			err := v3.ValidateWorkloadEndpoint(wep)
+			Expect(err).To(HaveOccurred())
+			errs, ok := err.(v3.FieldValidationErrors)
+			Expect(ok).To(BeTrue())
+			fields := []string{}
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			Expect(fields).To(ContainElement(expectedField))
+			Expect(errs.ErrorList()).To(HaveLen(len(errs)))
+		},
+		Entry("invalid CIDR in the networks",
+			func(wep *libapiv3.WorkloadEndpoint) { wep.Spec.IPNetworks[2] = "10.0.0.300/32" },
+			"spec.ipNetworks[2]",
+		),
+		Entry("network containing multiple addresses",
+			func(wep *libapiv3.WorkloadEndpoint) { wep.Spec.IPNetworks[1] = "10.0.0.0/24" },
+			"spec.ipNetworks[1]",
+		),
+		Entry("NAT outside of the endpoint networks",
+			func(wep *libapiv3.WorkloadEndpoint) {
+				wep.Spec.IPNATs = []libapiv3.IPNAT{
+					{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.1"},
+					{InternalIP: "10.0.0.10", ExternalIP: "172.16.0.2"},
+				}
+			},
+			"spec.ipNATs[1].internalIP",
+		),
+		Entry("internal IP used by multiple NATs",
+			func(wep *libapiv3.WorkloadEndpoint) {
+				wep.Spec.IPNATs = []libapiv3.IPNAT{
+					{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.1"},
+					{InternalIP: "10.0.0.2", ExternalIP: "172.16.0.2"},
+					{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.3"},
+				}
+			},
+			"spec.ipNATs[2].internalIP",
+		),
+		Entry("NAT with mismatched IP versions",
+			func(wep *libapiv3.WorkloadEndpoint) {
+				wep.Spec.IPNetworks = append(wep.Spec.IPNetworks, "fd00::1/128")
+				wep.Spec.IPNATs = []libapiv3.IPNAT{
+					{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.1"},
+					{InternalIP: "10.0.0.2", ExternalIP: "2001::"},
+				}
+			},
+			"spec.ipNATs[1]",
+		),
+		Entry("NAT with an invalid external IP",
+			func(wep *libapiv3.WorkloadEndpoint) {
+				wep.Spec.IPNATs = []libapiv3.IPNAT{{InternalIP: "10.0.0.1", ExternalIP: "172.16.0.300"}}
+			},
+			"spec.ipNATs[0].externalIP",
+		),
+		Entry("invalid IPv4 gateway",
+			func(wep *libapiv3.WorkloadEndpoint) { wep.Spec.IPv4Gateway = "aabb::1" },
+			"spec.ipv4Gateway",
+		),
+		Entry("interface name too long",
+			func(wep *libapiv3.WorkloadEndpoint) { wep.Spec.InterfaceName = "interfaceTooLong" },
+			"spec.interfaceName",
+		),
+		Entry("invalid name",
+			func(wep *libapiv3.WorkloadEndpoint) { wep.Name = "Invalid_Name" },
+			"metadata.name",
+		),
+		Entry("zero endpoint port",
+			func(wep *libapiv3.WorkloadEndpoint) {
+				wep.Spec.Ports = []apiv3.EndpointPort{
+					{Name: "http", Protocol: numorstring.ProtocolFromString("TCP"), Port: 80},
+					{Name: "https", Protocol: numorstring.ProtocolFromString("TCP"), Port: 0},
+				}
+			},
+			"spec.ports[1].port",
+		),
+	)
+
+	DescribeTable("should report the reason for an invalid interface name",
+		func(name string, expectedReasons ...string) {
+			wep := newWEP()
+			wep.Spec.InterfaceName = name
+			err := v3.ValidateWorkloadEndpoint(wep)
+			Expect(err).To(HaveOccurred())
+			reasons := []string{}
+			for _, e := range err.(v3.FieldValidationErrors) {
+				Expect(e.Field).To(Equal("spec.interfaceName"))
+				reasons = append(reasons, e.Reason)
+			}
+			Expect(reasons).To(ConsistOf(expectedReasons))
+		},
+		Entry("empty", "", "interface name must be specified"),
+		Entry("too long", "cali0123456789ab", "interface name must be at most 15 characters"),
+		Entry("invalid characters", "cali#01", "interface name may only contain letters, digits, '_', '-' and '.'"),
+		Entry("too long with invalid characters", "cali:0123456789a",
+			"interface name must be at most 15 characters",
+			"interface name may only contain letters, digits, '_', '-' and '.'",
+		),
+	)
+
+	It("should report a single error against the NAT for mismatched IP versions", func() {
+		wep := newWEP()
+		wep.Spec.IPNATs = []libapiv3.IPNAT{{InternalIP: "10.0.0.1", ExternalIP: "2001::"}}
+		err := v3.ValidateWorkloadEndpoint(wep)
+		Expect(err).To(HaveOccurred())
+		errs := err.(v3.FieldValidationErrors)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.ipNATs[0]"))
+		Expect(errs[0].Reason).To(Equal("mismatched IP versions"))
+	})
+
+	It("should reject duplicate profile names", func() {
+		wep := newWEP()
+		wep.Spec.Profiles = []string{"profile-a", "profile-b", "profile-a", "profile-a"}
+		err := v3.ValidateWorkloadEndpoint(wep)
+		Expect(err).To(HaveOccurred())
+		errs := err.(v3.FieldValidationErrors)
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Field).To(Equal("spec.profiles"))
+		Expect(errs[0].Reason).To(Equal(`duplicate profile name "profile-a"`))
+	})
+
+	It("should accept distinct profile names", func() {
+		wep := newWEP()
+		wep.Spec.Profiles = []string{"profile-a", "profile-b", "kns.default"}
+		Expect(v3.ValidateWorkloadEndpoint(wep)).NotTo(HaveOccurred())
+	})
+
+	It("should accept an interface name at the kernel limit", func() {
+		wep := newWEP()
+		wep.Spec.InterfaceName = "cali0123456789a"
+		Expect(v3.ValidateWorkloadEndpoint(wep)).NotTo(HaveOccurred())
+	})
+
+	// Unlike the ports in policy rules, an endpoint port is a single port number, so a
+	// port range is rejected when the resource is decoded.
+	It("should reject a port range in an endpoint port", func() {
+		wep := newWEP()
+		wep.Spec.Ports = []apiv3.EndpointPort{{Name: "http", Protocol: numorstring.ProtocolFromString("TCP"), Port: 80}}
+		b, err := json.Marshal(wep)
+		Expect(err).NotTo(HaveOccurred())
+
+		var decoded libapiv3.WorkloadEndpoint
+		Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+		Expect(v3.ValidateWorkloadEndpoint(&decoded)).NotTo(HaveOccurred())
+
+		b = []byte(strings.Replace(string(b), `"port":80`, `"port":"80:90"`, 1))
+		err = json.Unmarshal(b, &decoded)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("port"))
+		Expect(err.Error()).To(ContainSubstring("uint16"))
+	})
+})